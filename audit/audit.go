@@ -0,0 +1,109 @@
+// Package audit writes the append-only trail of every decision made by the
+// cleaner about a resource, as JSON Lines kept apart from the application
+// logs.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/lucasmendesl/beerus/config"
+)
+
+// Result is the outcome of a decision about a resource.
+type Result string
+
+const (
+	// ResultRemoved means the resource was removed, or would have been in
+	// dry-run mode.
+	ResultRemoved Result = "removed"
+
+	// ResultFailed means the removal of the resource failed.
+	ResultFailed Result = "failed"
+
+	// ResultKept means the resource was deliberately kept.
+	ResultKept Result = "kept"
+)
+
+// Entry is a single line of the audit trail, describing the decision made
+// about a resource and the rule behind it.
+type Entry struct {
+	Time     time.Time         `json:"time"`
+	Endpoint string            `json:"endpoint,omitempty"`
+	Resource string            `json:"resource"`
+	ID       string            `json:"id"`
+	Names    []string          `json:"names,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Rule     string            `json:"rule"`
+	DryRun   bool              `json:"dryRun"`
+	Result   Result            `json:"result"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// Log is the audit trail. It is safe for concurrent use.
+type Log struct {
+	mu sync.Mutex
+	w  io.WriteCloser
+}
+
+// New returns the audit trail described by the given configuration, writing
+// to a file rotated once it reaches the maximum size. When no path is
+// configured, the returned Log discards every entry.
+func New(config config.Audit) (*Log, error) {
+	if config.Path == "" {
+		return Discard(), nil
+	}
+
+	file, err := openRotatingFile(config.Path, int64(config.MaxSize), config.MaxBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Log{w: file}, nil
+}
+
+// Discard returns a Log discarding every entry.
+func Discard() *Log {
+	return &Log{w: nopCloser{io.Discard}}
+}
+
+// Record appends the given entry to the audit trail, filling its time when
+// it is not set.
+func (l *Log) Record(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding audit entry error: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("writing audit entry error: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the underlying file.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.w.Close()
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
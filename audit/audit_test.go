@@ -0,0 +1,158 @@
+package audit_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lucasmendesl/beerus/audit"
+	"github.com/lucasmendesl/beerus/config"
+	"github.com/stretchr/testify/require"
+)
+
+func readEntries(t *testing.T, path string) []audit.Entry {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var entries []audit.Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e audit.Entry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		entries = append(entries, e)
+	}
+	require.NoError(t, scanner.Err())
+
+	return entries
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  config.Audit
+		wantErr string
+	}{
+		{
+			name:   "discard when no path is configured",
+			config: config.Audit{},
+		},
+		{
+			name:   "file in a missing directory",
+			config: config.Audit{Path: filepath.Join(t.TempDir(), "nested", "audit.log"), MaxSize: 1 << 20, MaxBackups: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := audit.New(tt.config)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, l.Record(audit.Entry{Resource: "image", ID: "sha256:6512bd43d9ca", Result: audit.ResultKept}))
+			require.NoError(t, l.Close())
+		})
+	}
+}
+
+func TestLog_Record(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	at := time.Date(2025, time.January, 8, 10, 30, 0, 0, time.UTC)
+
+	l, err := audit.New(config.Audit{Path: path, MaxSize: 1 << 20, MaxBackups: 1})
+	require.NoError(t, err)
+
+	require.NoError(t, l.Record(audit.Entry{
+		Time:     at,
+		Resource: "container",
+		ID:       "cadc6990a82e",
+		Names:    []string{"/web"},
+		Labels:   map[string]string{"app": "web"},
+		Rule:     "no-restart-policy",
+		Result:   audit.ResultRemoved,
+	}))
+	require.NoError(t, l.Record(audit.Entry{
+		Resource: "image",
+		ID:       "sha256:c20ad4d76fe9",
+		Rule:     "expired",
+		DryRun:   true,
+		Result:   audit.ResultFailed,
+		Error:    "conflict",
+	}))
+	require.NoError(t, l.Close())
+
+	entries := readEntries(t, path)
+	require.Len(t, entries, 2)
+	require.Equal(t, audit.Entry{
+		Time:     at,
+		Resource: "container",
+		ID:       "cadc6990a82e",
+		Names:    []string{"/web"},
+		Labels:   map[string]string{"app": "web"},
+		Rule:     "no-restart-policy",
+		Result:   audit.ResultRemoved,
+	}, entries[0])
+	require.False(t, entries[1].Time.IsZero())
+	require.True(t, entries[1].DryRun)
+	require.Equal(t, audit.ResultFailed, entries[1].Result)
+	require.Equal(t, "conflict", entries[1].Error)
+}
+
+func TestLog_Rotate(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxBackups  int
+		wantBackups []string
+		wantMissing []string
+	}{
+		{
+			name:        "keep the configured number of backups",
+			maxBackups:  2,
+			wantBackups: []string{".1", ".2"},
+			wantMissing: []string{".3"},
+		},
+		{
+			name:        "no backups",
+			maxBackups:  0,
+			wantMissing: []string{".1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.log")
+
+			// every entry is larger than the maximum size, so each one of
+			// them rotates the file
+			l, err := audit.New(config.Audit{Path: path, MaxSize: 64, MaxBackups: tt.maxBackups})
+			require.NoError(t, err)
+
+			for _, id := range []string{"first", "second", "third", "fourth"} {
+				require.NoError(t, l.Record(audit.Entry{Resource: "image", ID: id, Rule: "expired", Result: audit.ResultRemoved}))
+			}
+			require.NoError(t, l.Close())
+
+			entries := readEntries(t, path)
+			require.Len(t, entries, 1)
+			require.Equal(t, "fourth", entries[0].ID)
+
+			for i, suffix := range tt.wantBackups {
+				backup := readEntries(t, path+suffix)
+				require.Len(t, backup, 1)
+				require.Equal(t, []string{"third", "second"}[i], backup[0].ID)
+			}
+
+			for _, suffix := range tt.wantMissing {
+				require.NoFileExists(t, path+suffix)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// rotatingFile is a file rotated once writing to it would exceed the maximum
+// size. The rotated files are renamed with a numeric suffix, ".1" being the
+// most recent one, and only maxBackups of them are kept.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+// openRotatingFile opens the file at the given path for appending, creating
+// it along with its directory when needed.
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating audit directory error: %w", err)
+	}
+
+	r := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening audit file error: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("reading audit file size error: %w", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+
+	return nil
+}
+
+// Write appends p to the file, rotating it first when p would make it exceed
+// the maximum size. A single write larger than the maximum size still goes
+// to a file of its own.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+
+	return n, err
+}
+
+// rotate closes the current file, shifts the backups, dropping the oldest
+// ones, and opens a new empty file.
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("closing audit file error: %w", err)
+	}
+
+	if r.maxBackups <= 0 {
+		if err := os.Remove(r.path); err != nil {
+			return fmt.Errorf("removing audit file error: %w", err)
+		}
+
+		return r.open()
+	}
+
+	if err := os.Remove(r.backup(r.maxBackups)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing audit backup error: %w", err)
+	}
+
+	for i := r.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(r.backup(i), r.backup(i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("rotating audit backup error: %w", err)
+		}
+	}
+
+	if err := os.Rename(r.path, r.backup(1)); err != nil {
+		return fmt.Errorf("rotating audit file error: %w", err)
+	}
+
+	return r.open()
+}
+
+// backup returns the path of the nth most recent backup.
+func (r *rotatingFile) backup(n int) string {
+	return fmt.Sprintf("%s.%d", r.path, n)
+}
+
+func (r *rotatingFile) Close() error {
+	return r.file.Close()
+}
@@ -10,11 +10,12 @@ import (
 // main is the entry point for the beerus application.
 //
 // It will run the root command and exit with a non-zero status code if
-// any error occurs.
+// any error occurs. The status code tells a partial cleanup failure apart
+// from any other error.
 func main() {
 	rootCmd := cmd.NewRootCmd()
 	if err := rootCmd.Execute(); err != nil {
 		slog.Error("error on executing application", "err", err)
-		os.Exit(1)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -0,0 +1,49 @@
+package cleaner
+
+import (
+	"github.com/lucasmendesl/beerus/audit"
+)
+
+// auditRemoval writes the given removal attempt to the audit trail.
+func (c *cleaner) auditRemoval(r removal) {
+	entry := audit.Entry{
+		Endpoint: c.endpoint,
+		Resource: string(r.kind),
+		ID:       r.id,
+		Names:    r.names,
+		Labels:   r.labels,
+		Rule:     string(r.rule),
+		DryRun:   c.config.DryRun,
+		Result:   audit.ResultRemoved,
+	}
+
+	if r.err != nil {
+		entry.Result = audit.ResultFailed
+		entry.Error = r.err.Error()
+	}
+
+	c.writeAudit(entry)
+}
+
+// auditKept writes to the audit trail that the given resource was kept,
+// along with the rule that kept it.
+func (c *cleaner) auditKept(kind resourceKind, id string, names []string, labels map[string]string, rule removalRule) {
+	c.writeAudit(audit.Entry{
+		Endpoint: c.endpoint,
+		Resource: string(kind),
+		ID:       id,
+		Names:    names,
+		Labels:   labels,
+		Rule:     string(rule),
+		DryRun:   c.config.DryRun,
+		Result:   audit.ResultKept,
+	})
+}
+
+// writeAudit writes the given entry to the audit trail, logging the failure
+// instead of interrupting the cleanup.
+func (c *cleaner) writeAudit(entry audit.Entry) {
+	if err := c.auditLog.Record(entry); err != nil {
+		c.log.Warn("Failed to write audit entry", "error", err, "resource", entry.Resource, "id", entry.ID)
+	}
+}
@@ -0,0 +1,52 @@
+package cleaner
+
+import (
+	"context"
+
+	"github.com/lucasmendesl/beerus/docker"
+)
+
+// buildCacheID identifies the build cache in the cleanup cycles, since it is
+// pruned as a whole instead of record by record.
+const buildCacheID = "build-cache"
+
+// pruneBuildCache prunes the BuildKit build cache following the configured
+// storage budget and lifetime threshold, when the build cache cleanup is
+// enabled. The prune is recorded in the given cycle as a single removal,
+// holding the IDs of the deleted records and the reclaimed bytes.
+func (c *cleaner) pruneBuildCache(ctx context.Context, cy *cycle) error {
+	if !c.config.BuildCache.Enabled {
+		return nil
+	}
+
+	return c.pruneBuildCacheTo(ctx, cy, int64(c.config.BuildCache.KeepStorage), ruleStorageBudget)
+}
+
+// pruneBuildCacheTo prunes the BuildKit build cache until it fits in the
+// given storage budget, following the configured lifetime threshold, and
+// records the prune in the given cycle under the given rule.
+func (c *cleaner) pruneBuildCacheTo(ctx context.Context, cy *cycle, keepStorage int64, rule removalRule) error {
+	c.log.Info("Pruning build cache", "keepStorage", keepStorage, "lifetimeThreshold", c.config.BuildCache.LifetimeThreshold)
+	report, err := c.d.PruneBuildCache(ctx, docker.BuildCachePruneOptions{
+		KeepStorage:             keepStorage,
+		LifetimeThresholdInDays: c.config.BuildCache.LifetimeThreshold,
+		IncludeShared:           c.config.BuildCache.IncludeShared,
+	})
+
+	c.record(cy, removal{
+		kind:  resourceBuildCache,
+		id:    buildCacheID,
+		names: report.CachesDeleted,
+		rule:  rule,
+		size:  int64(report.SpaceReclaimed),
+		err:   err,
+	})
+
+	if err != nil {
+		c.log.Error("Failed to prune build cache", "class", docker.ClassifyError(err), "error", err)
+		return RemovalErrors{newRemovalError(resourceBuildCache, buildCacheID, err)}
+	}
+
+	c.log.Debug("Successfully pruned build cache", "count", len(report.CachesDeleted), "reclaimed", report.SpaceReclaimed)
+	return nil
+}
@@ -2,67 +2,228 @@ package cleaner
 
 import (
 	"context"
+	"io"
 	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/lucasmendesl/beerus/audit"
 	"github.com/lucasmendesl/beerus/config"
 	"github.com/lucasmendesl/beerus/docker"
+	"github.com/lucasmendesl/beerus/executor"
+	"github.com/lucasmendesl/beerus/metrics"
+	"github.com/lucasmendesl/beerus/notifier"
+	"github.com/lucasmendesl/beerus/policy"
+	"github.com/lucasmendesl/beerus/schedule"
+	"github.com/lucasmendesl/beerus/state"
 )
 
 type cleaner struct {
-	d      docker.BeerusContainerAPI
-	config *config.Beerus
-	log    *slog.Logger
+	d        docker.BeerusContainerAPI
+	config   *config.Beerus
+	log      *slog.Logger
+	out      io.Writer
+	metrics  *metrics.Metrics
+	status   status
+	state    state.Store
+	usage    imageUsage
+	auditLog *audit.Log
+	notifier notifier.Notifier
+	exec     *executor.Executor
+	endpoint string
+	policy   *policy.Engine
+	schedule *schedule.Calendar
+	names    NameMatchers
+
+	// pendingSweep is set when removals were deferred to the next maintenance
+	// window, which is notified on deferred, so a whole sweep runs as soon
+	// as the window opens.
+	pendingSweep atomic.Bool
+	deferred     chan struct{}
+
+	// ttlExpiry is the earliest expiry of the TTL of the stopped containers
+	// kept meanwhile, which is notified on ttlChanged, so they are removed
+	// as soon as it elapses.
+	ttlMu      sync.Mutex
+	ttlExpiry  time.Time
+	ttlChanged chan struct{}
+}
+
+// Option configures optional behavior of the cleaner.
+type Option func(*cleaner)
+
+// WithOutput sets the writer used to print reports, such as the dry-run
+// plan. By default, reports are written to the standard output.
+func WithOutput(w io.Writer) Option {
+	return func(c *cleaner) {
+		c.out = w
+	}
+}
+
+// WithMetrics sets the metrics updated by the cleaner. By default, the
+// cleaner updates metrics that are not exposed anywhere.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(c *cleaner) {
+		c.metrics = m
+	}
+}
+
+// WithState sets the store where the cleaner keeps its bookkeeping, such as
+// the deletion history, the first time each image was seen, the last time
+// each image was used and the event cursor. By default, the bookkeeping is
+// only kept in memory and lost on restarts.
+func WithState(s state.Store) Option {
+	return func(c *cleaner) {
+		c.state = s
+	}
+}
+
+// WithAudit sets the audit trail where every removal and every kept resource
+// is recorded. By default, the audit entries are discarded.
+func WithAudit(l *audit.Log) Option {
+	return func(c *cleaner) {
+		c.auditLog = l
+	}
+}
+
+// WithNotifier sets the notifier receiving the summary of the initial sweep
+// and of each poller tick. By default, the summaries are discarded.
+func WithNotifier(n notifier.Notifier) Option {
+	return func(c *cleaner) {
+		c.notifier = n
+	}
+}
+
+// WithExecutor sets the executor capping the number of removals in flight.
+// It should be the one given to the Docker client of the same endpoint, so
+// the removals and the container inspections share the cap. The listings,
+// the prunes, the disk usage, ping and event calls are not capped. By
+// default, the cleaner uses its own executor, limited by the configured
+// concurrency level.
+func WithExecutor(e *executor.Executor) Option {
+	return func(c *cleaner) {
+		c.exec = e
+	}
+}
+
+// WithEndpoint sets the name of the Docker endpoint managed by the cleaner,
+// written to the audit entries, the cycle summaries and the reports, so the
+// ones of the cleaners managing other endpoints can be told apart. By
+// default, no endpoint name is reported.
+func WithEndpoint(name string) Option {
+	return func(c *cleaner) {
+		c.endpoint = name
+	}
+}
+
+// WithPolicy sets the policies deciding which containers and images are
+// removed. The policies of a kind of resource take the place of its fixed
+// rules, such as the restart policy and the lifetime threshold rules, while
+// the ignore labels, the running containers and the images in use are still
+// left out. By default, only the fixed rules apply.
+func WithPolicy(e *policy.Engine) Option {
+	return func(c *cleaner) {
+		c.policy = e
+	}
+}
+
+// WithSchedule sets the calendar deciding when the periodic sweeps run and
+// the maintenance windows outside of which the removals are deferred. By
+// default, the sweeps run on every poll interval and the resources may be
+// removed at any time.
+func WithSchedule(s *schedule.Calendar) Option {
+	return func(c *cleaner) {
+		c.schedule = s
+	}
+}
+
+// NameMatchers holds the compiled name filters of the configuration, the
+// images being selected by their repositories and tags and the containers by
+// their names.
+type NameMatchers struct {
+	Repositories docker.NameMatcher
+	Tags         docker.NameMatcher
+	Containers   docker.NameMatcher
+}
+
+// WithNameMatchers sets the compiled name filters selecting the images and
+// the containers, so their patterns are compiled once rather than on every
+// listing and every container event. By default, every resource is selected.
+func WithNameMatchers(m NameMatchers) Option {
+	return func(c *cleaner) {
+		c.names = m
+	}
 }
 
 // New returns a new cleaner object that can be used to remove images and
 // containers that are marked for removal and set up event watchers for
 // image untag and container exit events. The function takes a docker
 // client and a configuration object as parameters and returns the
-// cleaner object.
+// cleaner object. When dry-run is enabled in the configuration, removal
+// calls are recorded instead of being sent to the Docker API.
 func New(
 	d docker.BeerusContainerAPI,
 	config *config.Beerus,
 	log *slog.Logger,
+	options ...Option,
 ) *cleaner {
-	return &cleaner{
-		d:      d,
-		config: config,
-		log:    log,
+	if config.DryRun {
+		d = &dryRunRecorder{api: d, log: log}
+	}
+
+	c := &cleaner{
+		d:        d,
+		config:   config,
+		log:      log,
+		out:      os.Stdout,
+		metrics:  metrics.New(),
+		state:    state.NewMemory(),
+		auditLog: audit.Discard(),
+		notifier: notifier.Discard(),
+		exec:     executor.New(int(config.ConcurrencyLevel)),
+		deferred: make(chan struct{}, 1),
+
+		ttlChanged: make(chan struct{}, 1),
+	}
+
+	for _, option := range options {
+		option(c)
 	}
+
+	c.usage = newImageUsage(c.state)
+	return c
 }
 
 // Run starts the cleaner, which removes images and containers that are
 // marked for removal and sets up event watchers for image untag and
 // container exit events. The function takes a context.Context and
-// returns an error if any occurs during the cleanup process. The
+// returns an error if any occurs during the cleanup process. Failed
+// removals are logged and reported in the cycle summaries instead, so
+// only listing errors and fatal daemon errors stop the cleaner. The
 // function will block until the context is canceled and will return the
-// context's error in this case.
+// context's error in this case. Outside of the maintenance windows, the
+// initial sweep is deferred to the opening of the next one.
 func (c *cleaner) Run(ctx context.Context) error {
-	c.log.Info("Starting cleaner, listing containers allowed for removal")
-	containers, err := c.listAllowedContainersToRemove(ctx)
-	if err != nil {
-		c.log.Error("Failed to list removable containers", "error", err)
-		return err
-	}
+	if now := time.Now(); c.removalsAllowed(now) {
+		cy := newCycle("initial sweep")
+		if err := c.sweep(ctx, cy); err != nil {
+			return err
+		}
 
-	c.log.Info("Removing containers", "count", len(containers))
-	if err := c.removeContainers(ctx, containers...); err != nil {
-		c.log.Error("Failed to remove containers", "error", err)
-		return err
-	}
+		if err := cy.failures(); err != nil {
+			c.log.Warn("Initial sweep finished with failed removals", "error", err)
+		}
 
-	c.log.Info("Listing images allowed for removal")
-	images, err := c.listAllowedImagesToRemove(ctx)
-	if err != nil {
-		c.log.Error("Failed to list removable images", "error", err)
-		return err
+		c.finishCycle(cy)
+		c.notify(ctx, cy)
+	} else {
+		c.log.Info("Outside of the maintenance windows, deferring the initial sweep", "until", c.schedule.NextOpen(now))
+		c.deferRemovals()
 	}
 
-	c.log.Info("Removing images", "count", len(images))
-	if err := c.removeImages(ctx, images...); err != nil {
-		c.log.Error("Failed to remove images", "error", err)
-		return err
-	}
+	c.status.sweepDone.Store(true)
 
 	c.log.Info("Setting up event watchers")
 	workerErr := make(chan error, 1)
@@ -84,3 +245,143 @@ func (c *cleaner) Run(ctx context.Context) error {
 		}
 	}
 }
+
+// RunOnce performs a single cleanup pass over containers, images, volumes,
+// networks and build cache, prints a summary of the pass and returns,
+// without setting up the event watchers. The returned Summary reflects
+// every removal attempted before the pass finished, even when an error is
+// returned, so callers can tell a partial failure apart from a pass that
+// could not run at all. Every candidate is attempted, and the failed
+// removals are returned together as RemovalErrors, unless the pass was
+// stopped by a listing error or a fatal daemon error. Outside of the
+// maintenance windows, the pass is skipped and nothing is removed.
+func (c *cleaner) RunOnce(ctx context.Context) (Summary, error) {
+	defer c.d.Close()
+
+	cy := newCycle("single pass")
+
+	var err error
+	if now := time.Now(); c.removalsAllowed(now) {
+		err = c.sweep(ctx, cy)
+	} else {
+		c.log.Warn("Outside of the maintenance windows, skipping the cleanup pass", "nextWindow", c.schedule.NextOpen(now))
+	}
+
+	if err == nil {
+		err = cy.failures()
+	}
+
+	c.finishCycle(cy)
+	c.printSummary(cy)
+
+	return cy.summary(), err
+}
+
+// sweep lists the containers, images, volumes and networks allowed for
+// removal and removes them, then prunes the build cache, recording every
+// removal attempt in the given cycle. Failed removals do not stop the sweep,
+// they are recorded in the cycle instead, so only listing errors and fatal
+// daemon errors are returned.
+func (c *cleaner) sweep(ctx context.Context, cy *cycle) error {
+	c.log.Info("Starting cleaner, listing containers allowed for removal")
+	if err := c.sweepContainers(ctx, cy); err != nil {
+		return err
+	}
+
+	c.log.Info("Listing images allowed for removal")
+	images, err := c.listAllowedImagesToRemove(ctx)
+	if err != nil {
+		c.log.Error("Failed to list removable images", "error", err)
+		return err
+	}
+
+	c.log.Info("Removing images", "count", len(images))
+	if err := c.removeImages(ctx, cy, images...); err != nil {
+		c.log.Error("Failed to remove images", "error", err)
+		if isFatal(err) {
+			return err
+		}
+	}
+
+	if err := c.sweepVolumes(ctx, cy); isFatal(err) {
+		return err
+	}
+
+	if err := c.sweepNetworks(ctx, cy); isFatal(err) {
+		return err
+	}
+
+	if err := c.pruneBuildCache(ctx, cy); isFatal(err) {
+		return err
+	}
+
+	return nil
+}
+
+// sweepContainers lists the containers allowed for removal and removes them,
+// recording every removal attempt in the given cycle. Only listing errors
+// and fatal daemon errors are returned.
+func (c *cleaner) sweepContainers(ctx context.Context, cy *cycle) error {
+	containers, err := c.listAllowedContainersToRemove(ctx)
+	if err != nil {
+		c.log.Error("Failed to list removable containers", "error", err)
+		return err
+	}
+
+	c.log.Info("Removing containers", "count", len(containers))
+	if err := c.removeContainers(ctx, cy, containers...); err != nil {
+		c.log.Error("Failed to remove containers", "error", err)
+		if isFatal(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// record stores the given removal attempt in the cycle and the audit trail,
+// and updates the removal metrics and the deletion history. Removals recorded
+// in dry-run mode did not happen, so they are left out of both.
+func (c *cleaner) record(cy *cycle, r removal) {
+	cy.record(r)
+	c.auditRemoval(r)
+
+	if c.config.DryRun {
+		return
+	}
+
+	if r.err != nil {
+		c.metrics.RemovalFailed(string(r.kind), string(docker.ClassifyError(r.err)))
+		return
+	}
+
+	c.metrics.ResourceRemoved(string(r.kind), string(r.rule), r.size)
+	c.state.RecordDeletion(state.Deletion{
+		Kind:      string(r.kind),
+		ID:        r.id,
+		Names:     r.names,
+		Rule:      string(r.rule),
+		Size:      r.size,
+		DeletedAt: time.Now(),
+	})
+}
+
+// finishCycle is called once a cleanup cycle is over, recording its
+// duration and persisting the state. In dry-run mode, it also prints the
+// plan of the resources that would have been removed.
+func (c *cleaner) finishCycle(cy *cycle) {
+	c.metrics.CycleFinished(cy.name, time.Since(cy.startedAt))
+	c.flushState()
+
+	if c.config.DryRun {
+		c.printPlan(cy)
+	}
+}
+
+// flushState persists the state, logging the failure instead of interrupting
+// the cleanup, since the state is kept in memory anyway.
+func (c *cleaner) flushState() {
+	if err := c.state.Flush(); err != nil {
+		c.log.Warn("Failed to persist state", "error", err)
+	}
+}
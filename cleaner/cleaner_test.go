@@ -1,19 +1,37 @@
 package cleaner_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/errdefs"
+	"github.com/lucasmendesl/beerus/audit"
 	"github.com/lucasmendesl/beerus/cleaner"
 	"github.com/lucasmendesl/beerus/config"
 	"github.com/lucasmendesl/beerus/docker"
 	mock "github.com/lucasmendesl/beerus/docker/mocks"
+	"github.com/lucasmendesl/beerus/executor"
+	"github.com/lucasmendesl/beerus/notifier"
+	"github.com/lucasmendesl/beerus/policy"
+	"github.com/lucasmendesl/beerus/schedule"
+	"github.com/lucasmendesl/beerus/selector"
+	"github.com/lucasmendesl/beerus/state"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
@@ -43,7 +61,7 @@ func TestCleaner_Run(t *testing.T) {
 	tests := []struct {
 		name      string
 		args      args
-		setupMock func(dockerAPI *mock.MockBeerusContainerAPI)
+		setupMock func(dockerAPI *mock.MockBeerusContainerAPI, cancel context.CancelFunc)
 		wantErr   wantErr
 	}{
 		{
@@ -51,13 +69,12 @@ func TestCleaner_Run(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 			},
-			setupMock: func(dockerAPI *mock.MockBeerusContainerAPI) {
+			setupMock: func(dockerAPI *mock.MockBeerusContainerAPI, _ context.CancelFunc) {
 				dockerAPI.
 					EXPECT().
 					ListContainers(
 						gomock.Any(),
 						gomock.Any(),
-						gomock.Any(),
 					).
 					Return(nil, errors.New("error listing containers")).
 					AnyTimes()
@@ -68,17 +85,16 @@ func TestCleaner_Run(t *testing.T) {
 			},
 		},
 		{
-			name: "error removing containers",
+			name: "daemon error removing containers",
 			args: args{
 				ctx: context.Background(),
 			},
-			setupMock: func(dockerAPI *mock.MockBeerusContainerAPI) {
+			setupMock: func(dockerAPI *mock.MockBeerusContainerAPI, _ context.CancelFunc) {
 				dockerAPI.
 					EXPECT().
 					ListContainers(
 						gomock.Any(),
 						gomock.Any(),
-						gomock.Any(),
 					).
 					Return([]docker.Container{
 						{
@@ -103,7 +119,7 @@ func TestCleaner_Run(t *testing.T) {
 						gomock.Any(),
 						gomock.Any(),
 					).
-					Return(errors.New("error removing containers")).
+					Return(errdefs.System(errors.New("error removing containers"))).
 					AnyTimes()
 			},
 			wantErr: func(t *testing.T, err error) bool {
@@ -116,13 +132,12 @@ func TestCleaner_Run(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 			},
-			setupMock: func(dockerAPI *mock.MockBeerusContainerAPI) {
+			setupMock: func(dockerAPI *mock.MockBeerusContainerAPI, _ context.CancelFunc) {
 				dockerAPI.
 					EXPECT().
 					ListContainers(
 						gomock.Any(),
 						gomock.Any(),
-						gomock.Any(),
 					).
 					Return([]docker.Container{
 						{
@@ -155,7 +170,7 @@ func TestCleaner_Run(t *testing.T) {
 						gomock.Any(),
 						gomock.Any(),
 					).
-					Return(nil, errors.New("error listing images")).
+					Return(nil, nil, errors.New("error listing images")).
 					AnyTimes()
 			},
 			wantErr: func(t *testing.T, err error) bool {
@@ -164,17 +179,16 @@ func TestCleaner_Run(t *testing.T) {
 			},
 		},
 		{
-			name: "error removing images",
+			name: "daemon error removing images",
 			args: args{
 				ctx: context.Background(),
 			},
-			setupMock: func(dockerAPI *mock.MockBeerusContainerAPI) {
+			setupMock: func(dockerAPI *mock.MockBeerusContainerAPI, _ context.CancelFunc) {
 				dockerAPI.
 					EXPECT().
 					ListContainers(
 						gomock.Any(),
 						gomock.Any(),
-						gomock.Any(),
 					).
 					Return([]docker.Container{
 						{
@@ -213,7 +227,7 @@ func TestCleaner_Run(t *testing.T) {
 							Labels: map[string]string{},
 							Tags:   []string{"docker:stable"},
 						},
-					}, nil).
+					}, []string{"sha256:b4ef436c698b07", "b0757c55a1fd"}, nil).
 					AnyTimes()
 
 				dockerAPI.
@@ -222,7 +236,7 @@ func TestCleaner_Run(t *testing.T) {
 						gomock.Any(),
 						gomock.Any(),
 					).
-					Return(errors.New("error removing images")).
+					Return(errdefs.Unavailable(errors.New("error removing images"))).
 					AnyTimes()
 			},
 			wantErr: func(t *testing.T, err error) bool {
@@ -235,13 +249,12 @@ func TestCleaner_Run(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 			},
-			setupMock: func(dockerAPI *mock.MockBeerusContainerAPI) {
+			setupMock: func(dockerAPI *mock.MockBeerusContainerAPI, cancel context.CancelFunc) {
 				dockerAPI.
 					EXPECT().
 					ListContainers(
 						gomock.Any(),
 						gomock.Any(),
-						gomock.Any(),
 					).
 					Return([]docker.Container{
 						{
@@ -280,7 +293,7 @@ func TestCleaner_Run(t *testing.T) {
 							Labels: map[string]string{},
 							Tags:   []string{"docker:stable"},
 						},
-					}, nil).
+					}, []string{"sha256:b4ef436c698b07", "b0757c55a1fd"}, nil).
 					AnyTimes()
 
 				dockerAPI.
@@ -289,11 +302,36 @@ func TestCleaner_Run(t *testing.T) {
 						gomock.Any(),
 						gomock.Any(),
 					).
-					Return(errors.New("error removing images")).
+					Return(errdefs.Conflict(errors.New("image is being used by stopped container cadc6990a82e"))).
 					AnyTimes()
+
+				dockerAPI.
+					EXPECT().
+					FromEvents(
+						gomock.Any(),
+						gomock.Any(),
+						gomock.Any(),
+						gomock.Any(),
+						gomock.Any(),
+						gomock.Any(),
+					).
+					DoAndReturn(func(context.Context, time.Time, ...events.Action) <-chan docker.EventResult {
+						cancel()
+
+						eventCh := make(chan docker.EventResult)
+						close(eventCh)
+						return eventCh
+					}).
+					Times(1)
+
+				dockerAPI.
+					EXPECT().
+					Close().
+					Times(1)
 			},
 			wantErr: func(t *testing.T, err error) bool {
-				require.EqualError(t, err, "error removing image with id b0757c55a1fd: error removing images")
+				// the image in use does not stop the cleaner
+				require.ErrorIs(t, err, context.Canceled)
 				return true
 			},
 		},
@@ -302,12 +340,12 @@ func TestCleaner_Run(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			dockerAPI := mock.NewMockBeerusContainerAPI(ctrl)
 
-			tt.setupMock(dockerAPI)
-			cleaner := cleaner.New(dockerAPI, config, logger)
-
 			ctx, cancel := context.WithCancel(tt.args.ctx)
 			defer cancel()
 
+			tt.setupMock(dockerAPI, cancel)
+			cleaner := cleaner.New(dockerAPI, config, logger)
+
 			var wg sync.WaitGroup
 			wg.Add(1)
 
@@ -325,3 +363,2822 @@ func TestCleaner_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestCleaner_DryRun(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+		config = &config.Beerus{
+			ConcurrencyLevel:        1,
+			ExpirePollCheckInterval: 1,
+			DryRun:                  true,
+			Images: config.Image{
+				LifetimeThreshold: 1,
+			},
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+		out    = &bytes.Buffer{}
+	)
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{
+			{
+				ID:      "cadc6990a82e",
+				Names:   []string{"/web"},
+				Image:   "nginx:1.27.3-alpine",
+				ImageID: "sha256:b4ef436c698b07",
+				Status:  docker.ContainerStatusExited,
+				RestartPolicy: container.RestartPolicy{
+					Name: "no",
+				},
+				Size: 2048,
+			},
+		}, nil).
+		AnyTimes()
+
+	dockerAPI.
+		EXPECT().
+		ListExpiredImages(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Image{
+			{
+				ID:       "sha256:b0757c55a1fd",
+				Tags:     []string{"<none>:<none>"},
+				Dangling: true,
+				Size:     1024,
+			},
+		}, []string{"sha256:b4ef436c698b07", "sha256:b0757c55a1fd"}, nil).
+		Times(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dockerAPI.
+		EXPECT().
+		FromEvents(
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+		).
+		DoAndReturn(func(context.Context, time.Time, ...events.Action) <-chan docker.EventResult {
+			cancel()
+
+			eventCh := make(chan docker.EventResult)
+			close(eventCh)
+			return eventCh
+		}).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	err := cleaner.New(dockerAPI, config, logger, cleaner.WithOutput(out)).Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	plan := out.String()
+	require.Contains(t, plan, "Dry-run plan for initial sweep: 2 resource(s), 3.072kB reclaimable")
+	require.Regexp(t, `container\s+cadc6990a82e\s+web\s+restart-policy\s+2.048kB`, plan)
+	require.Regexp(t, `image\s+b0757c55a1fd\s+<none>:<none>\s+dangling\s+1.024kB`, plan)
+}
+
+func TestCleaner_DryRunVolumeSizes(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+		config = &config.Beerus{
+			ConcurrencyLevel: 1,
+			DryRun:           true,
+			Volumes: config.Volume{
+				Enabled: true,
+			},
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+		out    = &bytes.Buffer{}
+	)
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{}, nil).
+		AnyTimes()
+
+	dockerAPI.
+		EXPECT().
+		ListExpiredImages(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Image{}, nil, nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		ListExpiredVolumes(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Volume{
+			{Name: "f3a9c1d2e0b4", Anonymous: true, Size: 4096},
+			{Name: "pgdata", Size: -1},
+		}, nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	summary, err := cleaner.New(dockerAPI, config, logger, cleaner.WithOutput(out)).RunOnce(context.Background())
+	require.NoError(t, err)
+
+	// the volume of unknown size is left out of the reclaimed bytes
+	require.Equal(t, cleaner.Summary{VolumesRemoved: 2, ReclaimedBytes: 4096}, summary)
+
+	plan := out.String()
+	require.Contains(t, plan, "Dry-run plan for single pass: 2 resource(s), 4.096kB reclaimable")
+	require.Regexp(t, `volume\s+f3a9c1d2e0b4\s+-\s+expired\s+4.096kB`, plan)
+	require.Regexp(t, `volume\s+pgdata\s+-\s+expired\s+unknown`, plan)
+}
+
+func TestCleaner_DryRunBuildCache(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+		config = &config.Beerus{
+			ConcurrencyLevel: 1,
+			DryRun:           true,
+			BuildCache: config.BuildCache{
+				Enabled:     true,
+				KeepStorage: 5 << 30,
+			},
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+		out    = &bytes.Buffer{}
+	)
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{}, nil).
+		AnyTimes()
+
+	dockerAPI.
+		EXPECT().
+		ListExpiredImages(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Image{}, nil, nil).
+		Times(1)
+
+	// the records the prune would delete are reported instead of pruned
+	dockerAPI.
+		EXPECT().
+		PlanBuildCachePrune(gomock.Any(), docker.BuildCachePruneOptions{KeepStorage: 5 << 30}).
+		Return(docker.BuildCachePruneReport{
+			CachesDeleted:  []string{"m0x2s8kqlvzc", "p3yd7w1nfhtb"},
+			SpaceReclaimed: 4096,
+		}, nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	summary, err := cleaner.New(dockerAPI, config, logger, cleaner.WithOutput(out)).RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, cleaner.Summary{BuildCachePruned: 2, ReclaimedBytes: 4096}, summary)
+
+	plan := out.String()
+	require.Contains(t, plan, "Dry-run plan for single pass: 1 resource(s), 4.096kB reclaimable")
+	require.Regexp(t, `build-cache\s+build-cache\s+m0x2s8kqlvzc,p3yd7w1nfhtb\s+storage-budget\s+4.096kB`, plan)
+}
+
+func TestCleaner_EventStreamReconnect(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+		config = &config.Beerus{
+			ConcurrencyLevel:        1,
+			ExpirePollCheckInterval: 1,
+			Images: config.Image{
+				LifetimeThreshold: 1,
+			},
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		now       = time.Now()
+		firstDie  = events.Message{Action: events.ActionDie, ID: "cadc6990a82e", Actor: events.Actor{ID: "cadc6990a82e"}, TimeNano: now.Add(time.Second).UnixNano()}
+		outageDie = events.Message{Action: events.ActionDie, ID: "f1a3d2c0b9e8", Actor: events.Actor{ID: "f1a3d2c0b9e8"}, TimeNano: now.Add(5 * time.Second).UnixNano()}
+
+		// a distinct event emitted within the same nanosecond as the first one
+		twinDie = events.Message{Action: events.ActionDie, ID: "b0757c55a1fd", Actor: events.Actor{ID: "b0757c55a1fd"}, TimeNano: firstDie.TimeNano}
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{}, nil).
+		Times(2)
+
+	dockerAPI.
+		EXPECT().
+		ListExpiredImages(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Image{}, nil, nil).
+		Times(1)
+
+	brokenCh := make(chan docker.EventResult, 2)
+	brokenCh <- docker.EventResult{Message: firstDie}
+	brokenCh <- docker.EventResult{Err: errors.New("unexpected EOF")}
+	close(brokenCh)
+
+	// the reconnected stream replays the last processed event along with
+	// the ones emitted during the outage
+	replayCh := make(chan docker.EventResult, 3)
+	replayCh <- docker.EventResult{Message: firstDie}
+	replayCh <- docker.EventResult{Message: twinDie}
+	replayCh <- docker.EventResult{Message: outageDie}
+	close(replayCh)
+
+	gomock.InOrder(
+		dockerAPI.
+			EXPECT().
+			FromEvents(
+				gomock.Any(),
+				gomock.Cond(func(since time.Time) bool {
+					return !since.Before(now) && !since.After(time.Now())
+				}),
+				events.ActionDie,
+				events.ActionUnTag,
+				events.ActionCreate,
+				events.ActionStart,
+			).
+			Return(brokenCh).
+			Times(1),
+		dockerAPI.
+			EXPECT().
+			FromEvents(
+				gomock.Any(),
+				time.Unix(0, firstDie.TimeNano),
+				events.ActionDie,
+				events.ActionUnTag,
+				events.ActionCreate,
+				events.ActionStart,
+			).
+			Return(replayCh).
+			Times(1),
+	)
+
+	for _, id := range []string{firstDie.ID, twinDie.ID, outageDie.ID} {
+		dockerAPI.
+			EXPECT().
+			Inspect(
+				gomock.Any(),
+				id,
+				false,
+			).
+			Return(docker.Container{
+				ID:     id,
+				Names:  []string{"/" + id},
+				Status: docker.ContainerStatusExited,
+				RestartPolicy: container.RestartPolicy{
+					Name: "no",
+				},
+			}, nil).
+			Times(1)
+	}
+
+	dockerAPI.
+		EXPECT().
+		RemoveContainer(
+			gomock.Any(),
+			gomock.Cond(func(options docker.RemoveContainerOptions) bool {
+				return options.ContainerID == firstDie.ID || options.ContainerID == twinDie.ID
+			}),
+		).
+		Return(nil).
+		Times(2)
+
+	dockerAPI.
+		EXPECT().
+		RemoveContainer(
+			gomock.Any(),
+			gomock.Cond(func(options docker.RemoveContainerOptions) bool {
+				return options.ContainerID == outageDie.ID
+			}),
+		).
+		DoAndReturn(func(context.Context, docker.RemoveContainerOptions) error {
+			cancel()
+			return nil
+		}).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	err := cleaner.New(dockerAPI, config, logger).Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCleaner_EventStreamLiveness(t *testing.T) {
+	tests := []struct {
+		name    string
+		pingErr error
+		wantErr bool
+	}{
+		{
+			name: "idle stream answered by the daemon stays alive",
+		},
+		{
+			name:    "idle stream of an unreachable daemon is reported dead",
+			pingErr: errors.New("connection refused"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctrl      = gomock.NewController(t)
+				dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+				config = &config.Beerus{
+					ConcurrencyLevel:        1,
+					ExpirePollCheckInterval: 1,
+					Images: config.Image{
+						LifetimeThreshold: 1,
+					},
+					HTTP: config.HTTP{
+						LivenessThreshold: 1,
+					},
+				}
+
+				logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+				opened atomic.Int32
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			dockerAPI.
+				EXPECT().
+				ListContainers(
+					gomock.Any(),
+					gomock.Any(),
+				).
+				Return([]docker.Container{}, nil).
+				Times(2)
+
+			dockerAPI.
+				EXPECT().
+				ListExpiredImages(
+					gomock.Any(),
+					gomock.Any(),
+				).
+				Return([]docker.Image{}, nil, nil).
+				Times(1)
+
+			// the stream stays open without delivering any event
+			dockerAPI.
+				EXPECT().
+				FromEvents(
+					gomock.Any(),
+					gomock.Any(),
+					gomock.Any(),
+				).
+				DoAndReturn(func(context.Context, time.Time, ...events.Action) <-chan docker.EventResult {
+					opened.Add(1)
+					return make(chan docker.EventResult)
+				}).
+				MinTimes(1)
+
+			dockerAPI.
+				EXPECT().
+				Ping(gomock.Any()).
+				Return(tt.pingErr).
+				MinTimes(1)
+
+			dockerAPI.
+				EXPECT().
+				Close().
+				Times(1)
+
+			c := cleaner.New(dockerAPI, config, logger)
+
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- c.Run(ctx)
+			}()
+
+			if tt.wantErr {
+				require.Eventually(t, func() bool {
+					return c.Live() != nil
+				}, 3*time.Second, 50*time.Millisecond)
+			} else {
+				// past the threshold, the idle stream was opened again on
+				// every heartbeat
+				time.Sleep(1600 * time.Millisecond)
+				require.NoError(t, c.Live())
+				require.GreaterOrEqual(t, opened.Load(), int32(3))
+			}
+
+			cancel()
+			require.ErrorIs(t, <-errCh, context.Canceled)
+		})
+	}
+}
+
+func TestCleaner_EventContainerTTL(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+		config = &config.Beerus{
+			ConcurrencyLevel:        1,
+			ExpirePollCheckInterval: 1,
+			Images: config.Image{
+				LifetimeThreshold: 1,
+			},
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		createdAt = time.Now()
+		die       = events.Message{Action: events.ActionDie, ID: "cadc6990a82e", Actor: events.Actor{ID: "cadc6990a82e"}, TimeNano: createdAt.UnixNano()}
+		labels    = map[string]string{docker.TTLLabel: "1s"}
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dockerAPI.
+		EXPECT().
+		ListExpiredImages(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Image{}, nil, nil).
+		Times(1)
+
+	eventCh := make(chan docker.EventResult, 1)
+	eventCh <- docker.EventResult{Message: die}
+
+	dockerAPI.
+		EXPECT().
+		FromEvents(
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return(eventCh).
+		Times(1)
+
+	// the container exits before its ttl expires, so it is kept
+	dockerAPI.
+		EXPECT().
+		Inspect(
+			gomock.Any(),
+			die.ID,
+			false,
+		).
+		Return(docker.Container{
+			ID:        die.ID,
+			Names:     []string{"/preview"},
+			Labels:    labels,
+			CreatedAt: createdAt,
+			Status:    docker.ContainerStatusExited,
+			RestartPolicy: container.RestartPolicy{
+				Name: "no",
+			},
+			TTL: time.Second,
+		}, nil).
+		Times(1)
+
+	// and it is removed by the sweep run once the ttl expires
+	gomock.InOrder(
+		dockerAPI.
+			EXPECT().
+			ListContainers(
+				gomock.Any(),
+				gomock.Any(),
+			).
+			Return([]docker.Container{}, nil).
+			Times(2),
+		dockerAPI.
+			EXPECT().
+			ListContainers(
+				gomock.Any(),
+				gomock.Any(),
+			).
+			Return([]docker.Container{
+				{
+					ID:        die.ID,
+					Names:     []string{"/preview"},
+					Status:    docker.ContainerStatusExited,
+					CreatedAt: createdAt,
+					Labels:    labels,
+					TTL:       time.Second,
+				},
+			}, nil).
+			Times(1),
+	)
+
+	dockerAPI.
+		EXPECT().
+		RemoveContainer(
+			gomock.Any(),
+			gomock.Cond(func(options docker.RemoveContainerOptions) bool {
+				return options.ContainerID == die.ID
+			}),
+		).
+		DoAndReturn(func(context.Context, docker.RemoveContainerOptions) error {
+			require.GreaterOrEqual(t, time.Since(createdAt), time.Second)
+			cancel()
+			return nil
+		}).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	err := cleaner.New(dockerAPI, config, logger).Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCleaner_EventLabelSelectors(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+		config = &config.Beerus{
+			ConcurrencyLevel:        1,
+			ExpirePollCheckInterval: 1,
+			Containers: config.Container{
+				IgnoreLabels: []selector.Selector{selector.MustParse("env=prod")},
+				TargetLabels: []selector.Selector{selector.MustParse("team in (ci, qa)")},
+			},
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		prodDie     = events.Message{Action: events.ActionDie, ID: "cadc6990a82e", Actor: events.Actor{ID: "cadc6990a82e"}, TimeNano: 1736294400000000000}
+		untargetDie = events.Message{Action: events.ActionDie, ID: "b0757c55a1fd", Actor: events.Actor{ID: "b0757c55a1fd"}, TimeNano: 1736294401000000000}
+		ciDie       = events.Message{Action: events.ActionDie, ID: "f1a3d2c0b9e8", Actor: events.Actor{ID: "f1a3d2c0b9e8"}, TimeNano: 1736294402000000000}
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{}, nil).
+		Times(2)
+
+	dockerAPI.
+		EXPECT().
+		ListExpiredImages(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Image{}, nil, nil).
+		Times(1)
+
+	eventsCh := make(chan docker.EventResult, 3)
+	eventsCh <- docker.EventResult{Message: prodDie}
+	eventsCh <- docker.EventResult{Message: untargetDie}
+	eventsCh <- docker.EventResult{Message: ciDie}
+	close(eventsCh)
+
+	dockerAPI.
+		EXPECT().
+		FromEvents(
+			gomock.Any(),
+			gomock.Any(),
+			events.ActionDie,
+			events.ActionUnTag,
+			events.ActionCreate,
+			events.ActionStart,
+		).
+		Return(eventsCh).
+		Times(1)
+
+	labels := map[string]map[string]string{
+		prodDie.ID:     {"team": "ci", "env": "prod"},
+		untargetDie.ID: {"team": "web"},
+		ciDie.ID:       {"team": "ci", "env": "dev"},
+	}
+
+	for id, containerLabels := range labels {
+		dockerAPI.
+			EXPECT().
+			Inspect(
+				gomock.Any(),
+				id,
+				false,
+			).
+			Return(docker.Container{
+				ID:     id,
+				Names:  []string{"/" + id},
+				Labels: containerLabels,
+				Status: docker.ContainerStatusExited,
+				RestartPolicy: container.RestartPolicy{
+					Name: "no",
+				},
+			}, nil).
+			Times(1)
+	}
+
+	// only the container matching the target selectors and none of the
+	// ignored ones is removed
+	dockerAPI.
+		EXPECT().
+		RemoveContainer(
+			gomock.Any(),
+			docker.RemoveContainerOptions{ContainerID: ciDie.ID},
+		).
+		DoAndReturn(func(context.Context, docker.RemoveContainerOptions) error {
+			cancel()
+			return nil
+		}).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	err := cleaner.New(dockerAPI, config, logger).Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCleaner_EventUntagLabelSelectors(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+		config = &config.Beerus{
+			ConcurrencyLevel:        1,
+			ExpirePollCheckInterval: 1,
+			Images: config.Image{
+				LifetimeThreshold: 30,
+				IgnoreLabels:      []selector.Selector{selector.MustParse("env=prod")},
+			},
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		prodUntag = events.Message{Action: events.ActionUnTag, ID: "sha256:c81e728d9d4c", Actor: events.Actor{ID: "sha256:c81e728d9d4c"}, TimeNano: 1736294400000000000}
+		ciUntag   = events.Message{Action: events.ActionUnTag, ID: "sha256:eccbc87e4b5c", Actor: events.Actor{ID: "sha256:eccbc87e4b5c"}, TimeNano: 1736294401000000000}
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{}, nil).
+		Times(2)
+
+	dockerAPI.
+		EXPECT().
+		ListExpiredImages(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Image{}, nil, nil).
+		Times(1)
+
+	eventsCh := make(chan docker.EventResult, 2)
+	eventsCh <- docker.EventResult{Message: prodUntag}
+	eventsCh <- docker.EventResult{Message: ciUntag}
+	close(eventsCh)
+
+	dockerAPI.
+		EXPECT().
+		FromEvents(
+			gomock.Any(),
+			gomock.Any(),
+			events.ActionDie,
+			events.ActionUnTag,
+			events.ActionCreate,
+			events.ActionStart,
+		).
+		Return(eventsCh).
+		Times(1)
+
+	labels := map[string]map[string]string{
+		prodUntag.ID: {"team": "ci", "env": "prod"},
+		ciUntag.ID:   {"team": "ci", "env": "dev"},
+	}
+
+	for id, imageLabels := range labels {
+		dockerAPI.
+			EXPECT().
+			InspectImage(
+				gomock.Any(),
+				id,
+			).
+			Return(docker.Image{
+				ID:       id,
+				Labels:   imageLabels,
+				Dangling: true,
+			}, nil).
+			Times(1)
+	}
+
+	// the untagged image with an ignored label is kept
+	dockerAPI.
+		EXPECT().
+		RemoveImage(
+			gomock.Any(),
+			docker.RemoveImageOptions{ImageID: ciUntag.ID},
+		).
+		DoAndReturn(func(context.Context, docker.RemoveImageOptions) error {
+			cancel()
+			return nil
+		}).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	err := cleaner.New(dockerAPI, config, logger).Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCleaner_EventUntagNameMatchers(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+		config = &config.Beerus{
+			ConcurrencyLevel:        1,
+			ExpirePollCheckInterval: 1,
+			Images: config.Image{
+				LifetimeThreshold: 30,
+			},
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		webUntag = events.Message{Action: events.ActionUnTag, ID: "sha256:c81e728d9d4c", Actor: events.Actor{ID: "sha256:c81e728d9d4c"}, TimeNano: 1736294400000000000}
+		ciUntag  = events.Message{Action: events.ActionUnTag, ID: "sha256:eccbc87e4b5c", Actor: events.Actor{ID: "sha256:eccbc87e4b5c"}, TimeNano: 1736294401000000000}
+	)
+
+	repositories, err := docker.NameFilter{Include: []string{"ci/*"}}.Compile()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{}, nil).
+		Times(2)
+
+	dockerAPI.
+		EXPECT().
+		ListExpiredImages(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Image{}, nil, nil).
+		Times(1)
+
+	eventsCh := make(chan docker.EventResult, 2)
+	eventsCh <- docker.EventResult{Message: webUntag}
+	eventsCh <- docker.EventResult{Message: ciUntag}
+	close(eventsCh)
+
+	dockerAPI.
+		EXPECT().
+		FromEvents(
+			gomock.Any(),
+			gomock.Any(),
+			events.ActionDie,
+			events.ActionUnTag,
+			events.ActionCreate,
+			events.ActionStart,
+		).
+		Return(eventsCh).
+		Times(1)
+
+	// both images still have a tag left
+	tags := map[string][]string{
+		webUntag.ID: {"web/app:latest"},
+		ciUntag.ID:  {"ci/app:latest"},
+	}
+
+	for id, imageTags := range tags {
+		dockerAPI.
+			EXPECT().
+			InspectImage(
+				gomock.Any(),
+				id,
+			).
+			Return(docker.Image{
+				ID:   id,
+				Tags: imageTags,
+			}, nil).
+			Times(1)
+	}
+
+	// the image out of the selected repositories is kept
+	dockerAPI.
+		EXPECT().
+		RemoveImage(
+			gomock.Any(),
+			docker.RemoveImageOptions{ImageID: ciUntag.ID},
+		).
+		DoAndReturn(func(context.Context, docker.RemoveImageOptions) error {
+			cancel()
+			return nil
+		}).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	err = cleaner.New(dockerAPI, config, logger, cleaner.WithNameMatchers(cleaner.NameMatchers{Repositories: repositories})).Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCleaner_EventNameMatchers(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+		config = &config.Beerus{
+			ConcurrencyLevel:        1,
+			ExpirePollCheckInterval: 1,
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		webDie = events.Message{Action: events.ActionDie, ID: "b0757c55a1fd", Actor: events.Actor{ID: "b0757c55a1fd"}, TimeNano: 1736294400000000000}
+		ciDie  = events.Message{Action: events.ActionDie, ID: "f1a3d2c0b9e8", Actor: events.Actor{ID: "f1a3d2c0b9e8"}, TimeNano: 1736294401000000000}
+	)
+
+	matcher, err := docker.NameFilter{Include: []string{"ci-*"}}.Compile()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the compiled matcher is handed to the listing of the stopped containers
+	var namesFiltered atomic.Bool
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		DoAndReturn(func(_ context.Context, options ...docker.ListContainersOptions) ([]docker.Container, error) {
+			var params docker.ListContainersParams
+			for _, option := range options {
+				option(&params)
+			}
+
+			if params.Names.Selects("ci-1") && !params.Names.Selects("web") {
+				namesFiltered.Store(true)
+			}
+
+			return []docker.Container{}, nil
+		}).
+		Times(2)
+
+	dockerAPI.
+		EXPECT().
+		ListExpiredImages(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Image{}, nil, nil).
+		Times(1)
+
+	eventsCh := make(chan docker.EventResult, 2)
+	eventsCh <- docker.EventResult{Message: webDie}
+	eventsCh <- docker.EventResult{Message: ciDie}
+	close(eventsCh)
+
+	dockerAPI.
+		EXPECT().
+		FromEvents(
+			gomock.Any(),
+			gomock.Any(),
+			events.ActionDie,
+			events.ActionUnTag,
+			events.ActionCreate,
+			events.ActionStart,
+		).
+		Return(eventsCh).
+		Times(1)
+
+	names := map[string]string{
+		webDie.ID: "/web",
+		ciDie.ID:  "/ci-1",
+	}
+
+	for id, name := range names {
+		dockerAPI.
+			EXPECT().
+			Inspect(
+				gomock.Any(),
+				id,
+				false,
+			).
+			Return(docker.Container{
+				ID:     id,
+				Names:  []string{name},
+				Status: docker.ContainerStatusExited,
+				RestartPolicy: container.RestartPolicy{
+					Name: "no",
+				},
+			}, nil).
+			Times(1)
+	}
+
+	// only the container selected by the name matcher is removed
+	dockerAPI.
+		EXPECT().
+		RemoveContainer(
+			gomock.Any(),
+			docker.RemoveContainerOptions{ContainerID: ciDie.ID},
+		).
+		DoAndReturn(func(context.Context, docker.RemoveContainerOptions) error {
+			cancel()
+			return nil
+		}).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	err = cleaner.New(dockerAPI, config, logger, cleaner.WithNameMatchers(cleaner.NameMatchers{Containers: matcher})).Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	require.True(t, namesFiltered.Load())
+}
+
+// closedCalendar returns a calendar whose single maintenance window opens
+// for a minute two days from now, so it is closed during the tests.
+func closedCalendar(t *testing.T, eventsBypassWindows bool) *schedule.Calendar {
+	t.Helper()
+
+	day := strings.ToLower(time.Now().AddDate(0, 0, 2).Weekday().String()[:3])
+	calendar, err := schedule.New(config.Schedule{
+		MaintenanceWindows:  []config.MaintenanceWindow{{Days: []string{day}, Start: "00:00", End: "00:01"}},
+		EventsBypassWindows: eventsBypassWindows,
+	})
+	require.NoError(t, err)
+
+	return calendar
+}
+
+func TestCleaner_RunOnceOutsideMaintenanceWindows(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+		calendar  = closedCalendar(t, false)
+
+		config = &config.Beerus{ConcurrencyLevel: 1}
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+	)
+
+	// nothing is listed nor removed outside of the maintenance windows
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	summary, err := cleaner.New(dockerAPI, config, logger,
+		cleaner.WithOutput(io.Discard),
+		cleaner.WithSchedule(calendar),
+	).RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, cleaner.Summary{}, summary)
+}
+
+func TestCleaner_RunDefersInitialSweep(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+		calendar  = closedCalendar(t, false)
+
+		config = &config.Beerus{ConcurrencyLevel: 1, ExpirePollCheckInterval: 1}
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the initial sweep is deferred, so the event watchers are set up
+	// without listing anything
+	dockerAPI.
+		EXPECT().
+		FromEvents(
+			gomock.Any(),
+			gomock.Any(),
+			events.ActionDie,
+			events.ActionUnTag,
+			events.ActionCreate,
+			events.ActionStart,
+		).
+		DoAndReturn(func(context.Context, time.Time, ...events.Action) <-chan docker.EventResult {
+			cancel()
+
+			ch := make(chan docker.EventResult)
+			close(ch)
+			return ch
+		}).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	err := cleaner.New(dockerAPI, config, logger, cleaner.WithSchedule(calendar)).Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCleaner_EventMaintenanceWindows(t *testing.T) {
+	tests := []struct {
+		name                string
+		eventsBypassWindows bool
+		removals            int
+	}{
+		{
+			name:     "events respecting the windows",
+			removals: 0,
+		},
+		{
+			name:                "events bypassing the windows",
+			eventsBypassWindows: true,
+			removals:            1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctrl      = gomock.NewController(t)
+				dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+				calendar  = closedCalendar(t, tt.eventsBypassWindows)
+
+				config = &config.Beerus{ConcurrencyLevel: 1, ExpirePollCheckInterval: 1}
+				logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+				die   = events.Message{Action: events.ActionDie, ID: "cadc6990a82e", Actor: events.Actor{ID: "cadc6990a82e"}, TimeNano: 1736294400000000000}
+				start = events.Message{Action: events.ActionStart, ID: "f1a3d2c0b9e8", Actor: events.Actor{ID: "f1a3d2c0b9e8"}, TimeNano: 1736294401000000000}
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			eventsCh := make(chan docker.EventResult, 2)
+			eventsCh <- docker.EventResult{Message: die}
+			eventsCh <- docker.EventResult{Message: start}
+			close(eventsCh)
+
+			dockerAPI.
+				EXPECT().
+				FromEvents(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(eventsCh).
+				Times(1)
+
+			dockerAPI.
+				EXPECT().
+				Inspect(gomock.Any(), die.ID, false).
+				Return(docker.Container{
+					ID:     die.ID,
+					Names:  []string{"/" + die.ID},
+					Status: docker.ContainerStatusExited,
+					RestartPolicy: container.RestartPolicy{
+						Name: "no",
+					},
+				}, nil).
+				Times(1)
+
+			dockerAPI.
+				EXPECT().
+				RemoveContainer(gomock.Any(), docker.RemoveContainerOptions{ContainerID: die.ID}).
+				Return(nil).
+				Times(tt.removals)
+
+			// the start event comes after the die event, so the test ends
+			// once the die event was handled
+			dockerAPI.
+				EXPECT().
+				Inspect(gomock.Any(), start.ID, false).
+				DoAndReturn(func(context.Context, string, bool) (docker.Container, error) {
+					cancel()
+					return docker.Container{}, context.Canceled
+				}).
+				Times(1)
+
+			dockerAPI.
+				EXPECT().
+				Close().
+				Times(1)
+
+			err := cleaner.New(dockerAPI, config, logger, cleaner.WithSchedule(calendar)).Run(ctx)
+			require.ErrorIs(t, err, context.Canceled)
+		})
+	}
+}
+
+func TestCleaner_RunOnce(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+		config = &config.Beerus{
+			ConcurrencyLevel: 1,
+			Images: config.Image{
+				LifetimeThreshold: 1,
+			},
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+		out    = &bytes.Buffer{}
+	)
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{
+			{
+				ID:     "cadc6990a82e",
+				Status: docker.ContainerStatusExited,
+				RestartPolicy: container.RestartPolicy{
+					Name: "no",
+				},
+			},
+		}, nil).
+		Times(2)
+
+	dockerAPI.
+		EXPECT().
+		RemoveContainer(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return(nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		ListExpiredImages(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Image{
+			{
+				ID:   "b0757c55a1fd",
+				Tags: []string{"docker:stable"},
+				Size: 1024,
+			},
+		}, nil, nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		RemoveImage(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return(errors.New("image is being used by stopped container")).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	summary, err := cleaner.New(dockerAPI, config, logger, cleaner.WithOutput(out)).RunOnce(context.Background())
+	require.EqualError(t, err, "error removing image with id b0757c55a1fd: image is being used by stopped container")
+
+	require.Equal(t, cleaner.Summary{
+		ContainersRemoved: 1,
+		ImagesFailed:      1,
+	}, summary)
+	require.Contains(t, out.String(), "Cleanup summary for single pass")
+	require.Regexp(t, `image\s+0\s+1`, out.String())
+}
+
+func TestCleaner_RunOnceContainerTTL(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+		config = &config.Beerus{
+			ConcurrencyLevel: 1,
+			Images: config.Image{
+				LifetimeThreshold: 1,
+			},
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+		out    = &bytes.Buffer{}
+	)
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{
+			{
+				ID:        "cadc6990a82e",
+				Status:    docker.ContainerStatusExited,
+				CreatedAt: time.Now().Add(-2 * time.Hour),
+				RestartPolicy: container.RestartPolicy{
+					Name: "always",
+				},
+				TTL: time.Hour,
+			},
+			{
+				ID:        "f1a3d2c0b9e8",
+				Status:    docker.ContainerStatusExited,
+				CreatedAt: time.Now().Add(-2 * time.Hour),
+				RestartPolicy: container.RestartPolicy{
+					Name: "no",
+				},
+				TTL: 24 * time.Hour,
+			},
+		}, nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		RemoveContainer(
+			gomock.Any(),
+			gomock.Cond(func(options docker.RemoveContainerOptions) bool {
+				return options.ContainerID == "cadc6990a82e"
+			}),
+		).
+		Return(nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{}, nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		ListExpiredImages(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Image{}, nil, nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	summary, err := cleaner.New(dockerAPI, config, logger, cleaner.WithOutput(out)).RunOnce(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, cleaner.Summary{
+		ContainersRemoved: 1,
+	}, summary)
+}
+
+func TestCleaner_RunOnceCreatedTimeout(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+		config = &config.Beerus{
+			ConcurrencyLevel: 1,
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+		out    = &bytes.Buffer{}
+	)
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{
+			{
+				ID:        "cadc6990a82e",
+				Status:    docker.ContainerStatusCreated,
+				CreatedAt: time.Now().Add(-10 * time.Minute),
+				RestartPolicy: container.RestartPolicy{
+					Name: "always",
+				},
+			},
+			{
+				ID:        "f1a3d2c0b9e8",
+				Status:    docker.ContainerStatusCreated,
+				CreatedAt: time.Now().Add(-30 * time.Second),
+				RestartPolicy: container.RestartPolicy{
+					Name: "always",
+				},
+			},
+		}, nil).
+		Times(1)
+
+	// only the container created before the timeout is removed
+	dockerAPI.
+		EXPECT().
+		RemoveContainer(
+			gomock.Any(),
+			gomock.Cond(func(options docker.RemoveContainerOptions) bool {
+				return options.ContainerID == "cadc6990a82e"
+			}),
+		).
+		Return(nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{}, nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		ListExpiredImages(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Image{}, nil, nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	summary, err := cleaner.New(dockerAPI, config, logger, cleaner.WithOutput(out)).RunOnce(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, cleaner.Summary{
+		ContainersRemoved: 1,
+	}, summary)
+}
+
+func TestCleaner_RunOncePolicies(t *testing.T) {
+	rules, err := policy.New([]config.Policy{
+		{
+			Name:       "ci-success",
+			Resource:   "container",
+			Expression: `status == "exited" && glob("ci/*", repository) && exitCode == 0 && age > duration("30m")`,
+		},
+		{
+			Name:       "preview",
+			Resource:   "image",
+			Expression: `repository matches "^preview/" && age > duration("24h")`,
+		},
+	})
+	require.NoError(t, err)
+
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := audit.New(config.Audit{Path: auditPath, MaxSize: 1 << 20})
+	require.NoError(t, err)
+
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+		config = &config.Beerus{
+			ConcurrencyLevel: 1,
+			Images: config.Image{
+				LifetimeThreshold: 90,
+			},
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+	)
+
+	gomock.InOrder(
+		dockerAPI.
+			EXPECT().
+			ListContainers(
+				gomock.Any(),
+				gomock.Any(),
+			).
+			Return([]docker.Container{
+				{
+					ID:        "cadc6990a82e",
+					Image:     "ci/app:1.2",
+					Status:    docker.ContainerStatusExited,
+					CreatedAt: time.Now().Add(-time.Hour),
+				},
+				{
+					ID:        "f1a3d2c0b9e8",
+					Image:     "ci/app:1.2",
+					Status:    docker.ContainerStatusExited,
+					ExitCode:  1,
+					CreatedAt: time.Now().Add(-time.Hour),
+				},
+				// removed by the restart policy rule without policies
+				{
+					ID:        "8e4d1b6c0a2f",
+					Image:     "postgres:16",
+					Status:    docker.ContainerStatusExited,
+					CreatedAt: time.Now().Add(-time.Hour),
+					RestartPolicy: container.RestartPolicy{
+						Name: "no",
+					},
+				},
+			}, nil).
+			Times(1),
+		dockerAPI.
+			EXPECT().
+			RemoveContainer(
+				gomock.Any(),
+				docker.RemoveContainerOptions{ContainerID: "cadc6990a82e"},
+			).
+			Return(nil).
+			Times(1),
+		dockerAPI.
+			EXPECT().
+			ListContainers(
+				gomock.Any(),
+				gomock.Any(),
+			).
+			Return([]docker.Container{}, nil).
+			Times(1),
+		// every image is listed, whatever the lifetime threshold
+		dockerAPI.
+			EXPECT().
+			ListExpiredImages(
+				gomock.Any(),
+				gomock.Cond(func(options docker.ExpiredImageListOptions) bool {
+					return options.All
+				}),
+			).
+			Return([]docker.Image{
+				{
+					ID:         "3f9a6b2c1d0e",
+					Tags:       []string{"preview/checkout:pr-42"},
+					LastUsedAt: time.Now().Add(-48 * time.Hour),
+				},
+				{
+					ID:         "a76d6a1f0270",
+					Tags:       []string{"nginx:1.23"},
+					LastUsedAt: time.Now().Add(-48 * time.Hour),
+				},
+			}, nil, nil).
+			Times(1),
+		dockerAPI.
+			EXPECT().
+			RemoveImage(
+				gomock.Any(),
+				docker.RemoveImageOptions{ImageID: "3f9a6b2c1d0e", Tags: []string{"preview/checkout:pr-42"}},
+			).
+			Return(nil).
+			Times(1),
+		dockerAPI.
+			EXPECT().
+			Close().
+			Times(1),
+	)
+
+	summary, err := cleaner.New(dockerAPI, config, logger,
+		cleaner.WithOutput(io.Discard),
+		cleaner.WithAudit(auditLog),
+		cleaner.WithPolicy(rules),
+	).RunOnce(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, auditLog.Close())
+
+	require.Equal(t, cleaner.Summary{
+		ContainersRemoved: 1,
+		ImagesRemoved:     1,
+	}, summary)
+
+	content, err := os.ReadFile(auditPath)
+	require.NoError(t, err)
+
+	got := make(map[string]audit.Entry)
+	for _, line := range bytes.Split(bytes.TrimSpace(content), []byte("\n")) {
+		var entry audit.Entry
+		require.NoError(t, json.Unmarshal(line, &entry))
+		got[entry.ID] = entry
+	}
+
+	require.Len(t, got, 5)
+
+	require.Equal(t, audit.ResultRemoved, got["cadc6990a82e"].Result)
+	require.Equal(t, "policy:ci-success", got["cadc6990a82e"].Rule)
+
+	require.Equal(t, audit.ResultKept, got["f1a3d2c0b9e8"].Result)
+	require.Equal(t, "no-policy", got["f1a3d2c0b9e8"].Rule)
+
+	require.Equal(t, audit.ResultKept, got["8e4d1b6c0a2f"].Result)
+	require.Equal(t, "no-policy", got["8e4d1b6c0a2f"].Rule)
+
+	require.Equal(t, audit.ResultRemoved, got["3f9a6b2c1d0e"].Result)
+	require.Equal(t, "policy:preview", got["3f9a6b2c1d0e"].Rule)
+
+	require.Equal(t, audit.ResultKept, got["a76d6a1f0270"].Result)
+	require.Equal(t, "no-policy", got["a76d6a1f0270"].Rule)
+}
+
+func TestCleaner_RunOncePolicyContainerSize(t *testing.T) {
+	rules, err := policy.New([]config.Policy{
+		{
+			Name:       "large",
+			Resource:   "container",
+			Expression: `status == "exited" && size > bytes("1MB")`,
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		dryRun   bool
+		removals int
+	}{
+		{name: "dry-run", dryRun: true},
+		{name: "real run", removals: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctrl      = gomock.NewController(t)
+				dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+				config = &config.Beerus{
+					ConcurrencyLevel: 1,
+					DryRun:           tt.dryRun,
+					Images: config.Image{
+						LifetimeThreshold: 90,
+					},
+				}
+
+				logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+			)
+
+			// the daemon only computes the size when it is requested
+			listStopped := func(_ context.Context, options ...docker.ListContainersOptions) ([]docker.Container, error) {
+				var params docker.ListContainersParams
+				for _, option := range options {
+					option(&params)
+				}
+
+				ctr := docker.Container{
+					ID:        "cadc6990a82e",
+					Image:     "ci/app:1.2",
+					Status:    docker.ContainerStatusExited,
+					CreatedAt: time.Now().Add(-time.Hour),
+				}
+
+				if params.Size {
+					ctr.Size = 2 << 20
+				}
+
+				return []docker.Container{ctr}, nil
+			}
+
+			gomock.InOrder(
+				dockerAPI.
+					EXPECT().
+					ListContainers(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(listStopped).
+					Times(1),
+				dockerAPI.
+					EXPECT().
+					ListContainers(gomock.Any(), gomock.Any()).
+					Return([]docker.Container{}, nil).
+					Times(1),
+			)
+
+			dockerAPI.
+				EXPECT().
+				RemoveContainer(
+					gomock.Any(),
+					docker.RemoveContainerOptions{ContainerID: "cadc6990a82e"},
+				).
+				Return(nil).
+				Times(tt.removals)
+
+			dockerAPI.
+				EXPECT().
+				ListExpiredImages(
+					gomock.Any(),
+					gomock.Any(),
+				).
+				Return([]docker.Image{}, nil, nil).
+				Times(1)
+
+			dockerAPI.
+				EXPECT().
+				Close().
+				Times(1)
+
+			summary, err := cleaner.New(dockerAPI, config, logger,
+				cleaner.WithOutput(io.Discard),
+				cleaner.WithPolicy(rules),
+			).RunOnce(context.Background())
+			require.NoError(t, err)
+
+			// the same container is selected whether the run is a dry one
+			// or not
+			require.Equal(t, cleaner.Summary{
+				ContainersRemoved: 1,
+				ReclaimedBytes:    2 << 20,
+			}, summary)
+		})
+	}
+}
+
+func TestCleaner_DiskUsageEscalation(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+		config = &config.Beerus{
+			ConcurrencyLevel:        1,
+			ExpirePollCheckInterval: 1,
+			Images: config.Image{
+				LifetimeThreshold: 30,
+			},
+			DiskUsage: config.DiskUsage{
+				Enabled:       true,
+				HighWatermark: 80 << 30,
+				LowWatermark:  60 << 30,
+				CheckInterval: 5,
+			},
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gib := func(n int64) int64 { return n * 1024 * 1024 * 1024 }
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{}, nil).
+		AnyTimes()
+
+	dockerAPI.
+		EXPECT().
+		FromEvents(
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return(make(chan docker.EventResult)).
+		Times(1)
+
+	danglingOnly := gomock.Cond(func(options docker.ExpiredImageListOptions) bool {
+		return options.DanglingOnly
+	})
+	expiredOnly := gomock.Cond(func(options docker.ExpiredImageListOptions) bool {
+		return !options.DanglingOnly
+	})
+
+	gomock.InOrder(
+		// initial sweep
+		dockerAPI.
+			EXPECT().
+			ListExpiredImages(gomock.Any(), expiredOnly).
+			Return([]docker.Image{}, nil, nil).
+			Times(1),
+		dockerAPI.
+			EXPECT().
+			DiskUsage(gomock.Any()).
+			Return(docker.DiskUsage{Images: gib(90), BuildCache: gib(10)}, nil).
+			Times(1),
+		// dangling images step
+		dockerAPI.
+			EXPECT().
+			ListExpiredImages(gomock.Any(), danglingOnly).
+			Return([]docker.Image{{ID: "9897f4c66b5e", Tags: []string{"<none>:<none>"}, Dangling: true}}, nil, nil).
+			Times(1),
+		dockerAPI.
+			EXPECT().
+			RemoveImage(gomock.Any(), docker.RemoveImageOptions{ImageID: "9897f4c66b5e", Tags: []string{"<none>:<none>"}}).
+			Return(nil).
+			Times(1),
+		dockerAPI.
+			EXPECT().
+			DiskUsage(gomock.Any()).
+			Return(docker.DiskUsage{Images: gib(60), BuildCache: gib(10)}, nil).
+			Times(1),
+		// expired images step
+		dockerAPI.
+			EXPECT().
+			ListExpiredImages(gomock.Any(), expiredOnly).
+			Return([]docker.Image{{ID: "a76d6a1f0270", Tags: []string{"nginx:1.23"}}}, nil, nil).
+			Times(1),
+		dockerAPI.
+			EXPECT().
+			RemoveImage(gomock.Any(), docker.RemoveImageOptions{ImageID: "a76d6a1f0270", Tags: []string{"nginx:1.23"}}).
+			Return(nil).
+			Times(1),
+		dockerAPI.
+			EXPECT().
+			DiskUsage(gomock.Any()).
+			Return(docker.DiskUsage{Images: gib(55), BuildCache: gib(10)}, nil).
+			Times(1),
+		// build cache step, pruned by the 5GB exceeding the low watermark
+		dockerAPI.
+			EXPECT().
+			PruneBuildCache(gomock.Any(), docker.BuildCachePruneOptions{KeepStorage: gib(5)}).
+			Return(docker.BuildCachePruneReport{SpaceReclaimed: uint64(gib(5))}, nil).
+			Times(1),
+		dockerAPI.
+			EXPECT().
+			DiskUsage(gomock.Any()).
+			DoAndReturn(func(context.Context) (docker.DiskUsage, error) {
+				cancel()
+				return docker.DiskUsage{Images: gib(55), BuildCache: gib(4)}, nil
+			}).
+			Times(1),
+	)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	err := cleaner.New(dockerAPI, config, logger).Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCleaner_ImageLastUsed(t *testing.T) {
+	var (
+		ctrl   = gomock.NewController(t)
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+		out    = &bytes.Buffer{}
+
+		config = &config.Beerus{
+			ConcurrencyLevel: 1,
+			DataDir:          t.TempDir(),
+			Images: config.Image{
+				LifetimeThreshold: 30,
+			},
+		}
+
+		imageID = "sha256:6512bd43d9ca"
+	)
+
+	runOnce := func(running []docker.Container, existing []string, expect func(options docker.ExpiredImageListOptions) bool) {
+		dockerAPI := mock.NewMockBeerusContainerAPI(ctrl)
+
+		gomock.InOrder(
+			dockerAPI.
+				EXPECT().
+				ListContainers(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return([]docker.Container{}, nil).
+				Times(1),
+			dockerAPI.
+				EXPECT().
+				ListContainers(gomock.Any(), gomock.Any()).
+				Return(running, nil).
+				Times(1),
+		)
+
+		dockerAPI.
+			EXPECT().
+			ListExpiredImages(
+				gomock.Any(),
+				gomock.Cond(func(options docker.ExpiredImageListOptions) bool {
+					return expect(options)
+				}),
+			).
+			Return([]docker.Image{}, existing, nil).
+			Times(1)
+
+		dockerAPI.
+			EXPECT().
+			Close().
+			Times(1)
+
+		store, err := state.Open(config.DataDir)
+		require.NoError(t, err)
+
+		_, err = cleaner.New(dockerAPI, config, logger, cleaner.WithOutput(out), cleaner.WithState(store)).RunOnce(context.Background())
+		require.NoError(t, err)
+	}
+
+	// the image of a running container is used right now, and it is seen
+	// for the first time
+	runOnce([]docker.Container{{ID: "cadc6990a82e", ImageID: imageID, Status: docker.ContainerStatusRunning}}, []string{imageID}, func(options docker.ExpiredImageListOptions) bool {
+		return time.Since(options.LastUsed[imageID]) < time.Minute && len(options.FirstSeen) == 0
+	})
+
+	// the last use and the first time the image was seen are persisted, so
+	// a new cleaner still knows about them, until the image is found removed
+	// outside of the cleaner
+	runOnce([]docker.Container{}, []string{}, func(options docker.ExpiredImageListOptions) bool {
+		usedAt, used := options.LastUsed[imageID]
+		seenAt, seen := options.FirstSeen[imageID]
+		return used && seen && time.Since(usedAt) < time.Minute && !seenAt.After(usedAt)
+	})
+
+	// the removed image is not tracked anymore
+	runOnce([]docker.Container{}, []string{}, func(options docker.ExpiredImageListOptions) bool {
+		return len(options.LastUsed) == 0 && len(options.FirstSeen) == 0
+	})
+}
+
+func TestCleaner_EventCursorReplay(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+		dataDir   = t.TempDir()
+
+		config = &config.Beerus{
+			ConcurrencyLevel:        1,
+			ExpirePollCheckInterval: 1,
+			Images: config.Image{
+				LifetimeThreshold: 1,
+			},
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		handledDie = events.Message{Action: events.ActionDie, ID: "cadc6990a82e", Actor: events.Actor{ID: "cadc6990a82e"}, TimeNano: 1736294400000000000}
+		missedDie  = events.Message{Action: events.ActionDie, ID: "f1a3d2c0b9e8", Actor: events.Actor{ID: "f1a3d2c0b9e8"}, TimeNano: 1736294405000000000}
+	)
+
+	// the cursor persisted by a previous run
+	store, err := state.Open(dataDir)
+	require.NoError(t, err)
+	store.SetEventCursor(time.Unix(0, handledDie.TimeNano))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{}, nil).
+		Times(2)
+
+	dockerAPI.
+		EXPECT().
+		ListExpiredImages(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Image{}, nil, nil).
+		Times(1)
+
+	replayCh := make(chan docker.EventResult, 2)
+	replayCh <- docker.EventResult{Message: handledDie}
+	replayCh <- docker.EventResult{Message: missedDie}
+	close(replayCh)
+
+	gomock.InOrder(
+		dockerAPI.
+			EXPECT().
+			FromEvents(
+				gomock.Any(),
+				time.Unix(0, handledDie.TimeNano),
+				gomock.Any(),
+				gomock.Any(),
+				gomock.Any(),
+				gomock.Any(),
+			).
+			Return(replayCh).
+			Times(1),
+		dockerAPI.
+			EXPECT().
+			FromEvents(
+				gomock.Any(),
+				time.Unix(0, missedDie.TimeNano),
+				gomock.Any(),
+				gomock.Any(),
+				gomock.Any(),
+				gomock.Any(),
+			).
+			DoAndReturn(func(context.Context, time.Time, ...events.Action) <-chan docker.EventResult {
+				cancel()
+
+				eventCh := make(chan docker.EventResult)
+				close(eventCh)
+				return eventCh
+			}).
+			Times(1),
+	)
+
+	dockerAPI.
+		EXPECT().
+		Inspect(
+			gomock.Any(),
+			missedDie.ID,
+			false,
+		).
+		Return(docker.Container{
+			ID:     missedDie.ID,
+			Names:  []string{"/worker"},
+			Status: docker.ContainerStatusExited,
+			RestartPolicy: container.RestartPolicy{
+				Name: "no",
+			},
+		}, nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		RemoveContainer(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return(nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	err = cleaner.New(dockerAPI, config, logger, cleaner.WithState(store)).Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// the deletion history is persisted along with the event cursor
+	data, err := os.ReadFile(filepath.Join(dataDir, "state.json"))
+	require.NoError(t, err)
+
+	var persisted struct {
+		Deletions []state.Deletion `json:"deletions"`
+	}
+	require.NoError(t, json.Unmarshal(data, &persisted))
+
+	deletions := persisted.Deletions
+	require.Len(t, deletions, 1)
+	require.Equal(t, "container", deletions[0].Kind)
+	require.Equal(t, missedDie.ID, deletions[0].ID)
+	require.Equal(t, "restart-policy", deletions[0].Rule)
+	require.Equal(t, []string{"/worker"}, deletions[0].Names)
+}
+
+func TestCleaner_AuditTrail(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := audit.New(config.Audit{Path: auditPath, MaxSize: 1 << 20})
+	require.NoError(t, err)
+
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+		config = &config.Beerus{
+			ConcurrencyLevel: 1,
+			Images: config.Image{
+				LifetimeThreshold: 1,
+			},
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+	)
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{
+			{
+				ID:     "cadc6990a82e",
+				Names:  []string{"/web"},
+				Labels: map[string]string{"app": "web"},
+				Status: docker.ContainerStatusExited,
+				RestartPolicy: container.RestartPolicy{
+					Name: "no",
+				},
+			},
+			{
+				ID:        "f1a3d2c0b9e8",
+				Status:    docker.ContainerStatusExited,
+				CreatedAt: time.Now(),
+				TTL:       24 * time.Hour,
+			},
+		}, nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		RemoveContainer(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return(nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{}, nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		ListExpiredImages(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Image{
+			{
+				ID:   "b0757c55a1fd",
+				Tags: []string{"docker:stable"},
+			},
+			{
+				ID:   "9a2c01e4f8b7",
+				Tags: []string{"alpine:3.20", "alpine:latest"},
+			},
+		}, nil, nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		RemoveImage(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return(errors.New("image is being used by stopped container")).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	_, err = cleaner.New(dockerAPI, config, logger, cleaner.WithOutput(io.Discard), cleaner.WithAudit(auditLog), cleaner.WithEndpoint("build-01")).RunOnce(context.Background())
+	require.Error(t, err)
+	require.NoError(t, auditLog.Close())
+
+	content, err := os.ReadFile(auditPath)
+	require.NoError(t, err)
+
+	got := make(map[string]audit.Entry)
+	for _, line := range bytes.Split(bytes.TrimSpace(content), []byte("\n")) {
+		var entry audit.Entry
+		require.NoError(t, json.Unmarshal(line, &entry))
+		got[entry.ID] = entry
+	}
+
+	require.Len(t, got, 4)
+
+	require.Equal(t, audit.ResultRemoved, got["cadc6990a82e"].Result)
+	require.Equal(t, "container", got["cadc6990a82e"].Resource)
+	require.Equal(t, []string{"/web"}, got["cadc6990a82e"].Names)
+	require.Equal(t, map[string]string{"app": "web"}, got["cadc6990a82e"].Labels)
+	require.False(t, got["cadc6990a82e"].DryRun)
+	require.Equal(t, "build-01", got["cadc6990a82e"].Endpoint)
+
+	require.Equal(t, audit.ResultKept, got["f1a3d2c0b9e8"].Result)
+	require.Equal(t, "ttl", got["f1a3d2c0b9e8"].Rule)
+
+	require.Equal(t, audit.ResultFailed, got["b0757c55a1fd"].Result)
+	require.Equal(t, "image is being used by stopped container", got["b0757c55a1fd"].Error)
+
+	require.Equal(t, audit.ResultKept, got["9a2c01e4f8b7"].Result)
+	require.Equal(t, "multiple-tags", got["9a2c01e4f8b7"].Rule)
+}
+
+type recordingNotifier struct {
+	mu        sync.Mutex
+	summaries []notifier.Summary
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, s notifier.Summary) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.summaries = append(n.summaries, s)
+	return nil
+}
+
+func TestCleaner_RunNotifiesInitialSweep(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+		notify    = &recordingNotifier{}
+
+		config = &config.Beerus{
+			ConcurrencyLevel:        1,
+			ExpirePollCheckInterval: 1,
+			Images: config.Image{
+				LifetimeThreshold: 1,
+			},
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{
+			{
+				ID:     "cadc6990a82e",
+				Status: docker.ContainerStatusExited,
+				RestartPolicy: container.RestartPolicy{
+					Name: "no",
+				},
+			},
+		}, nil).
+		Times(2)
+
+	dockerAPI.
+		EXPECT().
+		RemoveContainer(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return(nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		ListExpiredImages(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Image{
+			{
+				ID:   "b0757c55a1fd",
+				Tags: []string{"docker:stable"},
+				Size: 1024,
+			},
+		}, nil, nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		RemoveImage(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return(nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		FromEvents(
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+			gomock.Any(),
+		).
+		DoAndReturn(func(context.Context, time.Time, ...events.Action) <-chan docker.EventResult {
+			cancel()
+
+			eventCh := make(chan docker.EventResult)
+			close(eventCh)
+			return eventCh
+		}).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	err := cleaner.New(dockerAPI, config, logger, cleaner.WithNotifier(notify)).Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	notify.mu.Lock()
+	defer notify.mu.Unlock()
+
+	require.Len(t, notify.summaries, 1)
+	require.Equal(t, "initial sweep", notify.summaries[0].Cycle)
+	require.Equal(t, notifier.Counts{Containers: 1, Images: 1}, notify.summaries[0].Removed)
+	require.Zero(t, notify.summaries[0].Failed.Total())
+	require.Equal(t, int64(1024), notify.summaries[0].ReclaimedBytes)
+	require.False(t, notify.summaries[0].FinishedAt.Before(notify.summaries[0].StartedAt))
+}
+
+func TestCleaner_RemovalConcurrencyCap(t *testing.T) {
+	const containerCount = 25
+
+	tests := []struct {
+		name      string
+		level     uint8
+		options   func() []cleaner.Option
+		wantLimit int32
+	}{
+		{
+			name:      "concurrency level of the configuration",
+			level:     3,
+			options:   func() []cleaner.Option { return nil },
+			wantLimit: 3,
+		},
+		{
+			name:  "shared executor",
+			level: 10,
+			options: func() []cleaner.Option {
+				return []cleaner.Option{cleaner.WithExecutor(executor.New(2))}
+			},
+			wantLimit: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctrl      = gomock.NewController(t)
+				dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+				config = &config.Beerus{
+					ConcurrencyLevel: tt.level,
+					Images: config.Image{
+						LifetimeThreshold: 1,
+					},
+				}
+
+				logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+				inFlight atomic.Int32
+				peak     atomic.Int32
+			)
+
+			containers := make([]docker.Container, 0, containerCount)
+			for i := range containerCount {
+				containers = append(containers, docker.Container{
+					ID:     fmt.Sprintf("container-%02d", i),
+					Status: docker.ContainerStatusExited,
+					RestartPolicy: container.RestartPolicy{
+						Name: "no",
+					},
+				})
+			}
+
+			gomock.InOrder(
+				dockerAPI.
+					EXPECT().
+					ListContainers(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return(containers, nil).
+					Times(1),
+				dockerAPI.
+					EXPECT().
+					ListContainers(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return([]docker.Container{}, nil).
+					Times(1),
+			)
+
+			dockerAPI.
+				EXPECT().
+				RemoveContainer(
+					gomock.Any(),
+					gomock.Any(),
+				).
+				DoAndReturn(func(context.Context, docker.RemoveContainerOptions) error {
+					current := inFlight.Add(1)
+					defer inFlight.Add(-1)
+
+					for {
+						highest := peak.Load()
+						if current <= highest || peak.CompareAndSwap(highest, current) {
+							break
+						}
+					}
+
+					time.Sleep(5 * time.Millisecond)
+					return nil
+				}).
+				Times(containerCount)
+
+			dockerAPI.
+				EXPECT().
+				ListExpiredImages(
+					gomock.Any(),
+					gomock.Any(),
+				).
+				Return([]docker.Image{}, nil, nil).
+				Times(1)
+
+			dockerAPI.
+				EXPECT().
+				Close().
+				Times(1)
+
+			options := append(tt.options(), cleaner.WithOutput(io.Discard))
+			summary, err := cleaner.New(dockerAPI, config, logger, options...).RunOnce(context.Background())
+			require.NoError(t, err)
+
+			require.Equal(t, containerCount, summary.ContainersRemoved)
+			require.LessOrEqual(t, peak.Load(), tt.wantLimit)
+			require.Positive(t, peak.Load())
+		})
+	}
+}
+
+func TestCleaner_RunOnceContinueOnError(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+		config = &config.Beerus{
+			ConcurrencyLevel: 2,
+			Images: config.Image{
+				LifetimeThreshold: 1,
+			},
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		removeErrors = map[string]error{
+			"cadc6990a82e": errdefs.NotFound(errors.New("no such container")),
+			"f1a3d2c0b9e8": errdefs.Conflict(errors.New("container is running: stop the container before removing or force remove")),
+			"9a2c01e4f8b7": nil,
+		}
+	)
+
+	containers := make([]docker.Container, 0, len(removeErrors))
+	for _, id := range slices.Sorted(maps.Keys(removeErrors)) {
+		containers = append(containers, docker.Container{
+			ID:     id,
+			Status: docker.ContainerStatusExited,
+			RestartPolicy: container.RestartPolicy{
+				Name: "no",
+			},
+		})
+	}
+
+	gomock.InOrder(
+		dockerAPI.
+			EXPECT().
+			ListContainers(
+				gomock.Any(),
+				gomock.Any(),
+			).
+			Return(containers, nil).
+			Times(1),
+		dockerAPI.
+			EXPECT().
+			ListContainers(
+				gomock.Any(),
+				gomock.Any(),
+			).
+			Return([]docker.Container{}, nil).
+			Times(1),
+	)
+
+	// every container is attempted, whatever the failures of the others
+	dockerAPI.
+		EXPECT().
+		RemoveContainer(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		DoAndReturn(func(_ context.Context, options docker.RemoveContainerOptions) error {
+			return removeErrors[options.ContainerID]
+		}).
+		Times(len(removeErrors))
+
+	dockerAPI.
+		EXPECT().
+		ListExpiredImages(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Image{
+			{
+				ID:   "b0757c55a1fd",
+				Tags: []string{"docker:stable"},
+			},
+			{
+				ID:   "6512bd43d9ca",
+				Tags: []string{"alpine:3.20"},
+				Size: 2048,
+			},
+		}, nil, nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		RemoveImage(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		DoAndReturn(func(_ context.Context, options docker.RemoveImageOptions) error {
+			if options.ImageID == "b0757c55a1fd" {
+				return errdefs.Conflict(errors.New("image is being used by stopped container cadc6990a82e"))
+			}
+
+			return nil
+		}).
+		Times(2)
+
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	summary, err := cleaner.New(dockerAPI, config, logger, cleaner.WithOutput(io.Discard)).RunOnce(context.Background())
+
+	require.Equal(t, cleaner.Summary{
+		ContainersRemoved: 1,
+		ContainersFailed:  2,
+		ImagesRemoved:     1,
+		ImagesFailed:      1,
+		ReclaimedBytes:    2048,
+	}, summary)
+
+	var removalErrs cleaner.RemovalErrors
+	require.ErrorAs(t, err, &removalErrs)
+	require.Len(t, removalErrs, 3)
+	require.False(t, removalErrs.Fatal())
+	require.Equal(t, map[docker.ErrorClass]int{
+		docker.ErrorClassNotFound: 1,
+		docker.ErrorClassInUse:    2,
+	}, removalErrs.Classes())
+	require.Contains(t, err.Error(), "3 removals failed (in-use: 2, not-found: 1)")
+	require.Contains(t, err.Error(), "error removing image with id b0757c55a1fd: image is being used by stopped container cadc6990a82e")
+}
+
+func TestCleaner_RunOnceVolumes(t *testing.T) {
+	ignoreLabels := []selector.Selector{selector.MustParse("env=prod")}
+
+	tests := []struct {
+		name        string
+		dryRun      bool
+		removeErr   error
+		wantSummary cleaner.Summary
+		wantClasses map[docker.ErrorClass]int
+		wantPlan    string
+	}{
+		{
+			name:        "removes the expired volumes",
+			wantSummary: cleaner.Summary{VolumesRemoved: 1, ReclaimedBytes: 4096},
+		},
+		{
+			name:        "volume in use",
+			removeErr:   errdefs.Conflict(errors.New("remove f3a9c1d2e0b4: volume is in use - [cadc6990a82e]")),
+			wantSummary: cleaner.Summary{VolumesFailed: 1},
+			wantClasses: map[docker.ErrorClass]int{docker.ErrorClassInUse: 1},
+		},
+		{
+			name:        "dry-run",
+			dryRun:      true,
+			wantSummary: cleaner.Summary{VolumesRemoved: 1, ReclaimedBytes: 4096},
+			wantPlan:    `volume\s+f3a9c1d2e0b4\s+-\s+expired\s+4.096kB`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctrl      = gomock.NewController(t)
+				dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+				config = &config.Beerus{
+					ConcurrencyLevel: 1,
+					DryRun:           tt.dryRun,
+					Volumes: config.Volume{
+						Enabled:           true,
+						LifetimeThreshold: 7,
+						IgnoreLabels:      ignoreLabels,
+					},
+				}
+
+				logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+				out    = &bytes.Buffer{}
+			)
+
+			dockerAPI.
+				EXPECT().
+				ListContainers(
+					gomock.Any(),
+					gomock.Any(),
+				).
+				Return([]docker.Container{}, nil).
+				AnyTimes()
+
+			dockerAPI.
+				EXPECT().
+				ListExpiredImages(
+					gomock.Any(),
+					gomock.Any(),
+				).
+				Return([]docker.Image{}, nil, nil).
+				Times(1)
+
+			// the volumes carrying an ignored label are left out by the listing
+			dockerAPI.
+				EXPECT().
+				ListExpiredVolumes(
+					gomock.Any(),
+					gomock.Cond(func(options docker.ExpiredVolumeListOptions) bool {
+						return options.LifetimeThresholdInDays == 7 &&
+							len(options.IgnoreLabels) == 1 && options.IgnoreLabels[0].String() == "env=prod"
+					}),
+				).
+				Return([]docker.Volume{
+					{Name: "f3a9c1d2e0b4", Anonymous: true, Size: 4096},
+				}, nil).
+				Times(1)
+
+			if !tt.dryRun {
+				dockerAPI.
+					EXPECT().
+					RemoveVolume(
+						gomock.Any(),
+						docker.RemoveVolumeOptions{Name: "f3a9c1d2e0b4"},
+					).
+					Return(tt.removeErr).
+					Times(1)
+			}
+
+			dockerAPI.
+				EXPECT().
+				Close().
+				Times(1)
+
+			summary, err := cleaner.New(dockerAPI, config, logger, cleaner.WithOutput(out)).RunOnce(context.Background())
+			require.Equal(t, tt.wantSummary, summary)
+
+			if tt.wantClasses == nil {
+				require.NoError(t, err)
+			} else {
+				var removalErrs cleaner.RemovalErrors
+				require.ErrorAs(t, err, &removalErrs)
+				require.False(t, removalErrs.Fatal())
+				require.Equal(t, tt.wantClasses, removalErrs.Classes())
+			}
+
+			if tt.wantPlan != "" {
+				require.Regexp(t, tt.wantPlan, out.String())
+			}
+		})
+	}
+}
+
+func TestCleaner_RunOnceNetworks(t *testing.T) {
+	ignoreLabels := []selector.Selector{selector.MustParse("env=prod")}
+
+	tests := []struct {
+		name        string
+		dryRun      bool
+		removeErr   error
+		wantSummary cleaner.Summary
+		wantClasses map[docker.ErrorClass]int
+		wantPlan    string
+	}{
+		{
+			name:        "removes the orphaned networks",
+			wantSummary: cleaner.Summary{NetworksRemoved: 1},
+		},
+		{
+			name:        "network in use",
+			removeErr:   errdefs.Conflict(errors.New("error while removing network: network ci_default id 8b1e4f2a9c3d has active endpoints")),
+			wantSummary: cleaner.Summary{NetworksFailed: 1},
+			wantClasses: map[docker.ErrorClass]int{docker.ErrorClassInUse: 1},
+		},
+		{
+			name:        "dry-run",
+			dryRun:      true,
+			wantSummary: cleaner.Summary{NetworksRemoved: 1},
+			wantPlan:    `network\s+8b1e4f2a9c3d\s+ci_default\s+orphaned`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctrl      = gomock.NewController(t)
+				dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+				config = &config.Beerus{
+					ConcurrencyLevel: 1,
+					DryRun:           tt.dryRun,
+					Networks: config.Network{
+						Enabled:           true,
+						LifetimeThreshold: 24,
+						IgnoreLabels:      ignoreLabels,
+					},
+				}
+
+				logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+				out    = &bytes.Buffer{}
+			)
+
+			dockerAPI.
+				EXPECT().
+				ListContainers(
+					gomock.Any(),
+					gomock.Any(),
+				).
+				Return([]docker.Container{}, nil).
+				AnyTimes()
+
+			dockerAPI.
+				EXPECT().
+				ListExpiredImages(
+					gomock.Any(),
+					gomock.Any(),
+				).
+				Return([]docker.Image{}, nil, nil).
+				Times(1)
+
+			// the networks carrying an ignored label are left out by the listing
+			dockerAPI.
+				EXPECT().
+				ListOrphanedNetworks(
+					gomock.Any(),
+					gomock.Cond(func(options docker.OrphanedNetworkListOptions) bool {
+						return options.LifetimeThresholdInHours == 24 &&
+							len(options.IgnoreLabels) == 1 && options.IgnoreLabels[0].String() == "env=prod"
+					}),
+				).
+				Return([]docker.Network{
+					{ID: "8b1e4f2a9c3d", Name: "ci_default"},
+				}, nil).
+				Times(1)
+
+			if !tt.dryRun {
+				dockerAPI.
+					EXPECT().
+					RemoveNetwork(
+						gomock.Any(),
+						docker.RemoveNetworkOptions{NetworkID: "8b1e4f2a9c3d"},
+					).
+					Return(tt.removeErr).
+					Times(1)
+			}
+
+			dockerAPI.
+				EXPECT().
+				Close().
+				Times(1)
+
+			summary, err := cleaner.New(dockerAPI, config, logger, cleaner.WithOutput(out)).RunOnce(context.Background())
+			require.Equal(t, tt.wantSummary, summary)
+
+			if tt.wantClasses == nil {
+				require.NoError(t, err)
+			} else {
+				var removalErrs cleaner.RemovalErrors
+				require.ErrorAs(t, err, &removalErrs)
+				require.False(t, removalErrs.Fatal())
+				require.Equal(t, tt.wantClasses, removalErrs.Classes())
+			}
+
+			if tt.wantPlan != "" {
+				require.Regexp(t, tt.wantPlan, out.String())
+			}
+		})
+	}
+}
+
+func TestCleaner_RunOnceFatalDaemonError(t *testing.T) {
+	var (
+		ctrl      = gomock.NewController(t)
+		dockerAPI = mock.NewMockBeerusContainerAPI(ctrl)
+
+		config = &config.Beerus{
+			ConcurrencyLevel: 1,
+			Images: config.Image{
+				LifetimeThreshold: 1,
+			},
+		}
+
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+	)
+
+	dockerAPI.
+		EXPECT().
+		ListContainers(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return([]docker.Container{
+			{
+				ID:     "cadc6990a82e",
+				Status: docker.ContainerStatusExited,
+				RestartPolicy: container.RestartPolicy{
+					Name: "no",
+				},
+			},
+		}, nil).
+		Times(1)
+
+	dockerAPI.
+		EXPECT().
+		RemoveContainer(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return(errdefs.Unavailable(errors.New("daemon is shutting down"))).
+		Times(1)
+
+	// the images are never listed, since the daemon can not handle them
+	dockerAPI.
+		EXPECT().
+		Close().
+		Times(1)
+
+	summary, err := cleaner.New(dockerAPI, config, logger, cleaner.WithOutput(io.Discard)).RunOnce(context.Background())
+	require.EqualError(t, err, "error removing container with id cadc6990a82e: daemon is shutting down")
+
+	var removalErrs cleaner.RemovalErrors
+	require.ErrorAs(t, err, &removalErrs)
+	require.True(t, removalErrs.Fatal())
+	require.Equal(t, cleaner.Summary{ContainersFailed: 1}, summary)
+}
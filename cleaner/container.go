@@ -2,74 +2,138 @@ package cleaner
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	"github.com/lucasmendesl/beerus/docker"
-	"golang.org/x/sync/errgroup"
+	"github.com/lucasmendesl/beerus/policy"
 )
 
-const createdTimeout float64 = 2 * 60 // 2 minutes
+// createdTimeout is the time after which a container created but never
+// started is removed.
+const createdTimeout = 2 * time.Minute
 
-// listAllowedContainersToRemove returns a list of Docker container IDs that are
-// considered for removal based on the container's status and restart policy.
-// Containers that are in created status and have timed out will also be
-// considered for removal.
+// removableContainer is a container selected for removal, along with the
+// rule that selected it.
+type removableContainer struct {
+	docker.Container
+	rule removalRule
+}
+
+// listAllowedContainersToRemove returns a list of Docker containers that are
+// considered for removal based on the container's status and restart policy,
+// or on the container policies when they are configured. Containers that are
+// in created status and have timed out will also be considered for removal.
 //
 // Parameters:
 //   - ctx: The context for managing request lifetime and cancellation.
 //
 // Returns:
-//   - A slice of container IDs that are removable.
+//   - A slice of removable containers, with the rule that selected each one.
 //   - An error if there is an issue fetching or inspecting the containers.
-func (c *cleaner) listAllowedContainersToRemove(ctx context.Context) ([]string, error) {
-	// Fetch the containers that are either dead or exited and have no restart policy.
-	// The containers that are in created status are also considered for removal.
-	containers, err := c.d.ListContainers(
-		ctx,
-		c.config.ConcurrencyLevel,
+func (c *cleaner) listAllowedContainersToRemove(ctx context.Context) ([]removableContainer, error) {
+	listOptions := []docker.ListContainersOptions{
 		docker.WithContainerStatus(
 			docker.ContainerStatusDead,
 			docker.ContainerStatusExited,
 			docker.ContainerStatusCreated,
 		),
 		docker.WithContainerLabel(c.config.Containers.IgnoreLabels...),
-	)
+		docker.WithContainerTargetLabel(c.config.Containers.TargetLabels...),
+		docker.WithContainerNames(c.names.Containers),
+	}
+
+	if c.containerSizeNeeded() {
+		listOptions = append(listOptions, docker.WithContainerSize())
+	}
 
+	// Fetch the containers that are either dead or exited and have no restart policy.
+	// The containers that are in created status are also considered for removal.
+	containers, err := c.d.ListContainers(ctx, listOptions...)
 	if err != nil {
 		return nil, err
 	}
 
 	// Filter the containers that are removable.
-	removableContainers := make([]string, 0, len(containers))
+	removableContainers := make([]removableContainer, 0, len(containers))
 	for _, ctr := range containers {
-		createdTimedOutReached := ctr.Status == docker.ContainerStatusCreated &&
-			time.Until(ctr.CreatedAt).Minutes() > createdTimeout
+		// a stopped container used its image at least when it was created
+		c.usage.touch(ctr.ImageID, ctr.CreatedAt)
 
-		canRemoveContainer := docker.CanRemoveContainer(
-			ctr,
-			c.config.Containers.MaxAlwaysRestartPolicyCount,
-		)
-
-		if canRemoveContainer || createdTimedOutReached {
-			removableContainers = append(removableContainers, ctr.ID)
+		rule, ok := c.containerRemovalRule(ctr)
+		if !ok {
+			c.keepContainer(ctr, rule)
+			continue
 		}
+
+		removableContainers = append(removableContainers, removableContainer{Container: ctr, rule: rule})
 	}
 
 	return removableContainers, nil
 }
 
-// removeContainers removes the specified Docker containers concurrently.
+// containerSizeNeeded reports whether the size of the containers is needed,
+// which it is by the dry-run plan and by the container policies, so the cost
+// of computing it is avoided otherwise.
+func (c *cleaner) containerSizeNeeded() bool {
+	return c.config.DryRun || c.policy.Applies(policy.KindContainer)
+}
+
+// containerRemovalRule returns the rule that selects the given stopped
+// container for removal, reporting false when the container must be kept.
+// When container policies are configured, they decide in place of the fixed
+// rules. Otherwise, containers created but never started are removed once
+// the created timeout is reached, and a container declaring a TTL is only
+// removed once the TTL has elapsed since its creation, in place of the
+// restart policy rules.
+func (c *cleaner) containerRemovalRule(ctr docker.Container) (removalRule, bool) {
+	if c.policy.Applies(policy.KindContainer) {
+		return c.policyRule(policy.KindContainer, policy.ContainerResource(ctr, time.Now()))
+	}
+
+	createdTimedOutReached := ctr.Status == docker.ContainerStatusCreated &&
+		time.Since(ctr.CreatedAt) > createdTimeout
+
+	if createdTimedOutReached {
+		return ruleCreatedTimeout, true
+	}
+
+	if ctr.TTL > 0 {
+		return ruleTTL, time.Since(ctr.CreatedAt) >= ctr.TTL
+	}
+
+	canRemoveContainer := docker.CanRemoveContainer(
+		ctr,
+		c.config.Containers.MaxAlwaysRestartPolicyCount,
+	)
+
+	return ruleRestartPolicy, canRemoveContainer
+}
+
+// keepContainer records that the given stopped container is kept by the
+// rule, scheduling a sweep for when its TTL expires when it is kept for it.
+func (c *cleaner) keepContainer(ctr docker.Container, rule removalRule) {
+	c.auditKept(resourceContainer, ctr.ID, ctr.Names, ctr.Labels, rule)
+
+	if rule == ruleTTL {
+		c.scheduleTTLSweep(ctr.CreatedAt.Add(ctr.TTL))
+	}
+}
+
+// removeContainers removes the specified Docker containers concurrently,
+// running the removals on the executor of the cleaner, so the number of
+// removals in flight never exceeds its limit.
 // It logs the start of the removal process and attempts to remove each
 // container by calling the Docker API.
 // If an error occurs during the removal of any container, it continues the
 // operation and logs the error. The function blocks until all containers
-// have been processed or the context is canceled.
+// have been processed or the context is canceled, then returns every failed
+// removal together as RemovalErrors.
 //
 // Parameters:
 // - ctx: The context for managing request lifetime and cancellation.
-// - containers: A slice of strings containing the IDs of the containers to be removed.
-func (c *cleaner) removeContainers(ctx context.Context, containers ...string) error {
+// - cy: The cleanup cycle where every removal attempt is recorded.
+// - containers: The containers to be removed.
+func (c *cleaner) removeContainers(ctx context.Context, cy *cycle, containers ...removableContainer) error {
 	containersLen := len(containers)
 
 	if containersLen == 0 {
@@ -78,22 +142,36 @@ func (c *cleaner) removeContainers(ctx context.Context, containers ...string) er
 	}
 
 	c.log.Debug("Starting to remove containers...", "count", containersLen)
-	g, ctx := errgroup.WithContext(ctx)
+	var failures removalFailures
+	g, ctx := c.exec.Group(ctx)
 
 	for _, container := range containers {
 		g.Go(func() error {
-			c.log.Debug("Attempting to remove container", "containerID", container)
+			c.log.Debug("Attempting to remove container", "containerID", container.ID, "rule", container.rule)
 			removeOptions := docker.RemoveContainerOptions{
-				ContainerID:   container,
+				ContainerID:   container.ID,
 				RemoveVolumes: c.config.Containers.ForceVolumeCleanup,
 				RemoveLinks:   c.config.Containers.ForceLinkCleanup,
 			}
 
-			if err := c.d.RemoveContainer(ctx, removeOptions); err != nil {
-				return fmt.Errorf("error removing container with id %s: %w", container, err)
+			err := c.d.RemoveContainer(ctx, removeOptions)
+			c.record(cy, removal{
+				kind:   resourceContainer,
+				id:     container.ID,
+				names:  container.Names,
+				labels: container.Labels,
+				rule:   container.rule,
+				size:   container.Size,
+				err:    err,
+			})
+
+			if err != nil {
+				c.log.Error("Failed to remove container", "id", container.ID, "class", docker.ClassifyError(err), "error", err)
+				failures.add(resourceContainer, container.ID, err)
+				return nil
 			}
 
-			c.log.Debug("Successfully removed container", "containerID", container)
+			c.log.Debug("Successfully removed container", "containerID", container.ID)
 			return nil
 		})
 	}
@@ -102,6 +180,10 @@ func (c *cleaner) removeContainers(ctx context.Context, containers ...string) er
 		return err
 	}
 
+	if err := failures.err(); err != nil {
+		return err
+	}
+
 	c.log.Debug("Successfully removed all containers")
 	return nil
 }
@@ -0,0 +1,219 @@
+package cleaner
+
+import (
+	"sync"
+	"time"
+)
+
+// removalRule identifies the rule that selected a resource for removal, or
+// the rule that kept it.
+type removalRule string
+
+const (
+	// ruleDangling selects images that have no tag pointing to them.
+	ruleDangling removalRule = "dangling"
+
+	// ruleExpired selects images older than the configured lifetime threshold.
+	ruleExpired removalRule = "expired"
+
+	// ruleRestartPolicy selects stopped containers whose restart policy allows
+	// them to be removed.
+	ruleRestartPolicy removalRule = "restart-policy"
+
+	// ruleCreatedTimeout selects containers that were created but never
+	// started within the created timeout.
+	ruleCreatedTimeout removalRule = "created-timeout"
+
+	// ruleUntagged selects images that were untagged, as reported by the
+	// event stream.
+	ruleUntagged removalRule = "untagged"
+
+	// ruleOrphaned selects user-defined networks without any attached
+	// container and older than the configured lifetime threshold.
+	ruleOrphaned removalRule = "orphaned"
+
+	// ruleTTL selects containers and images older than the TTL they declare
+	// through the docker.TTLLabel.
+	ruleTTL removalRule = "ttl"
+
+	// ruleStorageBudget selects build cache records exceeding the configured
+	// storage budget.
+	ruleStorageBudget removalRule = "storage-budget"
+
+	// ruleInUse keeps images used by running containers.
+	ruleInUse removalRule = "in-use"
+
+	// ruleMultipleTags keeps images with more than one tag, unless their
+	// removal is forced on conflict.
+	ruleMultipleTags removalRule = "multiple-tags"
+
+	// ruleDiskWatermark selects build cache records pruned to bring the disk
+	// usage back under the low watermark.
+	ruleDiskWatermark removalRule = "disk-watermark"
+
+	// ruleNoPolicy keeps containers and images matching none of the policies
+	// of their kind. The ones matching a policy are reported under the name
+	// of the policy, prefixed by policyRulePrefix.
+	ruleNoPolicy removalRule = "no-policy"
+
+	// rulePolicyError keeps containers and images whose policies failed to
+	// be evaluated.
+	rulePolicyError removalRule = "policy-error"
+)
+
+// resourceKind identifies the kind of Docker resource handled by the cleaner.
+type resourceKind string
+
+const (
+	resourceContainer  resourceKind = "container"
+	resourceImage      resourceKind = "image"
+	resourceVolume     resourceKind = "volume"
+	resourceNetwork    resourceKind = "network"
+	resourceBuildCache resourceKind = "build-cache"
+)
+
+// removal describes a single removal attempt made during a cleanup cycle,
+// holding the resource identification, the rule that selected it, its size
+// in bytes, negative when unknown, and the error returned by the removal, if
+// any. For the build cache, which is pruned as a whole, names holds the
+// deleted record IDs.
+type removal struct {
+	kind   resourceKind
+	id     string
+	names  []string
+	labels map[string]string
+	rule   removalRule
+	size   int64
+	err    error
+}
+
+// cycle collects the removals attempted during a single cleanup pass, such
+// as the initial sweep, a poller tick or the handling of a watcher event.
+// It is safe for concurrent use.
+type cycle struct {
+	name      string
+	startedAt time.Time
+
+	mu       sync.Mutex
+	removals []removal
+}
+
+// newCycle returns an empty cycle identified by the given name.
+func newCycle(name string) *cycle {
+	return &cycle{name: name, startedAt: time.Now()}
+}
+
+// record appends the given removal to the cycle.
+func (cy *cycle) record(r removal) {
+	cy.mu.Lock()
+	defer cy.mu.Unlock()
+
+	cy.removals = append(cy.removals, r)
+}
+
+// entries returns a copy of the removals recorded so far, in the order they
+// were recorded.
+func (cy *cycle) entries() []removal {
+	cy.mu.Lock()
+	defer cy.mu.Unlock()
+
+	entries := make([]removal, len(cy.removals))
+	copy(entries, cy.removals)
+
+	return entries
+}
+
+// Summary aggregates the outcome of a cleanup cycle, counting the resources
+// removed and the removals that failed for each kind of resource, along
+// with the amount of bytes reclaimed by the successful removals. For the
+// build cache, the number of deleted records and failed prunes are counted.
+type Summary struct {
+	ContainersRemoved int
+	ContainersFailed  int
+	ImagesRemoved     int
+	ImagesFailed      int
+	VolumesRemoved    int
+	VolumesFailed     int
+	NetworksRemoved   int
+	NetworksFailed    int
+	BuildCachePruned  int
+	BuildCacheFailed  int
+	ReclaimedBytes    int64
+}
+
+// Failed returns the total number of removals that failed.
+func (s Summary) Failed() int {
+	return s.ContainersFailed + s.ImagesFailed + s.VolumesFailed + s.NetworksFailed + s.BuildCacheFailed
+}
+
+// Removed returns the total number of resources removed.
+func (s Summary) Removed() int {
+	return s.ContainersRemoved + s.ImagesRemoved + s.VolumesRemoved + s.NetworksRemoved + s.BuildCachePruned
+}
+
+// Add returns the sum of s and the given summary, such as the outcome of the
+// cycles of several cleaners.
+func (s Summary) Add(o Summary) Summary {
+	return Summary{
+		ContainersRemoved: s.ContainersRemoved + o.ContainersRemoved,
+		ContainersFailed:  s.ContainersFailed + o.ContainersFailed,
+		ImagesRemoved:     s.ImagesRemoved + o.ImagesRemoved,
+		ImagesFailed:      s.ImagesFailed + o.ImagesFailed,
+		VolumesRemoved:    s.VolumesRemoved + o.VolumesRemoved,
+		VolumesFailed:     s.VolumesFailed + o.VolumesFailed,
+		NetworksRemoved:   s.NetworksRemoved + o.NetworksRemoved,
+		NetworksFailed:    s.NetworksFailed + o.NetworksFailed,
+		BuildCachePruned:  s.BuildCachePruned + o.BuildCachePruned,
+		BuildCacheFailed:  s.BuildCacheFailed + o.BuildCacheFailed,
+		ReclaimedBytes:    s.ReclaimedBytes + o.ReclaimedBytes,
+	}
+}
+
+// summary aggregates the removals recorded in the cycle.
+func (cy *cycle) summary() Summary {
+	var s Summary
+
+	for _, r := range cy.entries() {
+		failed := r.err != nil
+
+		switch r.kind {
+		case resourceContainer:
+			if failed {
+				s.ContainersFailed++
+			} else {
+				s.ContainersRemoved++
+			}
+		case resourceImage:
+			if failed {
+				s.ImagesFailed++
+			} else {
+				s.ImagesRemoved++
+			}
+		case resourceVolume:
+			if failed {
+				s.VolumesFailed++
+			} else {
+				s.VolumesRemoved++
+			}
+		case resourceNetwork:
+			if failed {
+				s.NetworksFailed++
+			} else {
+				s.NetworksRemoved++
+			}
+		case resourceBuildCache:
+			if failed {
+				s.BuildCacheFailed++
+			} else {
+				s.BuildCachePruned += len(r.names)
+			}
+		}
+
+		// the resources of unknown size are left out of the reclaimed bytes
+		if !failed && r.size > 0 {
+			s.ReclaimedBytes += r.size
+		}
+	}
+
+	return s
+}
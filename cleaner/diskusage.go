@@ -0,0 +1,155 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/lucasmendesl/beerus/config"
+	"github.com/lucasmendesl/beerus/docker"
+)
+
+// diskWatermarks holds the disk usage watermarks, in bytes. The cleanup is
+// escalated when the usage goes above high, until it drops under low.
+type diskWatermarks struct {
+	high int64
+	low  int64
+}
+
+// escalationStep is a single step of the cleanup escalated by the disk
+// usage, run in order until the usage drops under the low watermark.
+type escalationStep struct {
+	name string
+	run  func(ctx context.Context, cy *cycle, usage docker.DiskUsage, w diskWatermarks) error
+}
+
+// newDiskWatermarks returns the watermarks of the given disk usage
+// configuration, which are validated on startup.
+func newDiskWatermarks(settings config.DiskUsage) diskWatermarks {
+	return diskWatermarks{
+		high: int64(settings.HighWatermark),
+		low:  int64(settings.LowWatermark),
+	}
+}
+
+// watchDiskUsage checks the disk space used by the Docker data-root right
+// away and then on every configured interval, escalating the cleanup when it
+// goes above the high watermark, when the disk usage cleanup is enabled. If
+// an error occurs during the cleanup process, the function sends the error on
+// the error channel and returns.
+func (c *cleaner) watchDiskUsage(ctx context.Context, errCh chan<- error) {
+	if !c.config.DiskUsage.Enabled {
+		return
+	}
+
+	watermarks := newDiskWatermarks(c.config.DiskUsage)
+	interval := time.Minute * time.Duration(max(c.config.DiskUsage.CheckInterval, 1))
+	c.log.Info("Starting disk usage watcher", "interval", interval, "highWatermark", units.BytesSize(float64(watermarks.high)), "lowWatermark", units.BytesSize(float64(watermarks.low)), "context", "Disk Usage")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.enforceDiskWatermarks(ctx, watermarks); err != nil {
+			errCh <- fmt.Errorf("disk usage watcher error: %w", err)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// enforceDiskWatermarks checks the disk usage and, when it is above the high
+// watermark, runs the escalation steps in order: dangling images, expired
+// images and build cache. The disk usage is checked again after every step,
+// stopping the escalation as soon as it drops under the low watermark.
+// Failed removals do not stop the escalation, only fatal daemon errors do.
+// Outside of the maintenance windows, the escalation waits for the first
+// check of the next window.
+func (c *cleaner) enforceDiskWatermarks(ctx context.Context, w diskWatermarks) error {
+	usage, err := c.d.DiskUsage(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.log.Debug("Disk usage checked", "usage", units.BytesSize(float64(usage.Total())), "context", "Disk Usage")
+	if usage.Total() <= w.high {
+		return nil
+	}
+
+	if now := time.Now(); !c.removalsAllowed(now) {
+		c.log.Warn("Disk usage above the high watermark outside of the maintenance windows, deferring cleanup", "usage", units.BytesSize(float64(usage.Total())), "until", c.schedule.NextOpen(now), "context", "Disk Usage")
+		return nil
+	}
+
+	c.log.Warn("Disk usage above the high watermark, escalating cleanup", "usage", units.BytesSize(float64(usage.Total())), "highWatermark", units.BytesSize(float64(w.high)), "context", "Disk Usage")
+
+	cy := newCycle("disk usage")
+	defer c.finishCycle(cy)
+
+	steps := []escalationStep{
+		{name: "dangling images", run: c.removeDanglingImages},
+		{name: "expired images", run: c.removeExpiredImages},
+		{name: "build cache", run: c.pruneBuildCacheOverWatermark},
+	}
+
+	for i, step := range steps {
+		c.log.Info("Escalating disk usage cleanup", "step", i+1, "name", step.name, "usage", units.BytesSize(float64(usage.Total())), "context", "Disk Usage")
+		if err := step.run(ctx, cy, usage, w); isFatal(err) {
+			return fmt.Errorf("%s escalation step error: %w", step.name, err)
+		} else if err != nil {
+			c.log.Warn("Escalation step finished with failed removals", "step", i+1, "name", step.name, "error", err, "context", "Disk Usage")
+		}
+
+		if usage, err = c.d.DiskUsage(ctx); err != nil {
+			return err
+		}
+
+		if usage.Total() < w.low {
+			c.log.Info("Disk usage under the low watermark, stopping escalation", "step", i+1, "name", step.name, "usage", units.BytesSize(float64(usage.Total())), "lowWatermark", units.BytesSize(float64(w.low)), "context", "Disk Usage")
+			return nil
+		}
+	}
+
+	c.log.Warn("Disk usage still above the low watermark after every escalation step", "usage", units.BytesSize(float64(usage.Total())), "lowWatermark", units.BytesSize(float64(w.low)), "context", "Disk Usage")
+	return nil
+}
+
+// removeDanglingImages is the first escalation step, removing every dangling
+// image, whatever its age.
+func (c *cleaner) removeDanglingImages(ctx context.Context, cy *cycle, _ docker.DiskUsage, _ diskWatermarks) error {
+	options := c.expiredImageListOptions()
+	options.DanglingOnly = true
+
+	images, err := c.listImagesToRemove(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	return c.removeImages(ctx, cy, images...)
+}
+
+// removeExpiredImages is the second escalation step, removing the images
+// older than the configured lifetime threshold.
+func (c *cleaner) removeExpiredImages(ctx context.Context, cy *cycle, _ docker.DiskUsage, _ diskWatermarks) error {
+	images, err := c.listAllowedImagesToRemove(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.removeImages(ctx, cy, images...)
+}
+
+// pruneBuildCacheOverWatermark is the last escalation step, pruning the build
+// cache by the amount of bytes the disk usage exceeds the low watermark.
+func (c *cleaner) pruneBuildCacheOverWatermark(ctx context.Context, cy *cycle, usage docker.DiskUsage, w diskWatermarks) error {
+	excess := usage.Total() - w.low
+	keepStorage := max(usage.BuildCache-excess, 0)
+
+	return c.pruneBuildCacheTo(ctx, cy, keepStorage, ruleDiskWatermark)
+}
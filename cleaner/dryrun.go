@@ -0,0 +1,114 @@
+package cleaner
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/lucasmendesl/beerus/docker"
+)
+
+var _ docker.BeerusContainerAPI = (*dryRunRecorder)(nil)
+
+// dryRunRecorder wraps a docker.BeerusContainerAPI, recording the removal
+// calls instead of forwarding them to the Docker API. Every other call is
+// delegated unchanged, so the cleaner goes through the exact same listing
+// and decision path without deleting anything. The API is wrapped method by
+// method rather than embedded, so a method added to the interface does not
+// reach the Docker API until it is explicitly delegated or recorded here.
+type dryRunRecorder struct {
+	api docker.BeerusContainerAPI
+	log *slog.Logger
+}
+
+// Inspect delegates to the wrapped API.
+func (r *dryRunRecorder) Inspect(ctx context.Context, containerID string, size bool) (docker.Container, error) {
+	return r.api.Inspect(ctx, containerID, size)
+}
+
+// ListContainers delegates to the wrapped API.
+func (r *dryRunRecorder) ListContainers(ctx context.Context, options ...docker.ListContainersOptions) ([]docker.Container, error) {
+	return r.api.ListContainers(ctx, options...)
+}
+
+// InspectImage delegates to the wrapped API.
+func (r *dryRunRecorder) InspectImage(ctx context.Context, imageID string) (docker.Image, error) {
+	return r.api.InspectImage(ctx, imageID)
+}
+
+// ListExpiredImages delegates to the wrapped API.
+func (r *dryRunRecorder) ListExpiredImages(ctx context.Context, options docker.ExpiredImageListOptions) ([]docker.Image, []string, error) {
+	return r.api.ListExpiredImages(ctx, options)
+}
+
+// ListExpiredVolumes delegates to the wrapped API.
+func (r *dryRunRecorder) ListExpiredVolumes(ctx context.Context, options docker.ExpiredVolumeListOptions) ([]docker.Volume, error) {
+	return r.api.ListExpiredVolumes(ctx, options)
+}
+
+// ListOrphanedNetworks delegates to the wrapped API.
+func (r *dryRunRecorder) ListOrphanedNetworks(ctx context.Context, options docker.OrphanedNetworkListOptions) ([]docker.Network, error) {
+	return r.api.ListOrphanedNetworks(ctx, options)
+}
+
+// PlanBuildCachePrune delegates to the wrapped API.
+func (r *dryRunRecorder) PlanBuildCachePrune(ctx context.Context, options docker.BuildCachePruneOptions) (docker.BuildCachePruneReport, error) {
+	return r.api.PlanBuildCachePrune(ctx, options)
+}
+
+// DiskUsage delegates to the wrapped API.
+func (r *dryRunRecorder) DiskUsage(ctx context.Context) (docker.DiskUsage, error) {
+	return r.api.DiskUsage(ctx)
+}
+
+// FromEvents delegates to the wrapped API.
+func (r *dryRunRecorder) FromEvents(ctx context.Context, since time.Time, actions ...events.Action) <-chan docker.EventResult {
+	return r.api.FromEvents(ctx, since, actions...)
+}
+
+// Ping delegates to the wrapped API.
+func (r *dryRunRecorder) Ping(ctx context.Context) error {
+	return r.api.Ping(ctx)
+}
+
+// Close delegates to the wrapped API.
+func (r *dryRunRecorder) Close() error {
+	return r.api.Close()
+}
+
+// RemoveContainer records the removal of the container without calling the
+// Docker API.
+func (r *dryRunRecorder) RemoveContainer(_ context.Context, options docker.RemoveContainerOptions) error {
+	r.log.Debug("Dry-run: skipping container removal", "containerID", options.ContainerID)
+	return nil
+}
+
+// RemoveImage records the removal of the image without calling the Docker
+// API.
+func (r *dryRunRecorder) RemoveImage(_ context.Context, options docker.RemoveImageOptions) error {
+	r.log.Debug("Dry-run: skipping image removal", "imageID", options.ImageID)
+	return nil
+}
+
+// RemoveVolume records the removal of the volume without calling the Docker
+// API.
+func (r *dryRunRecorder) RemoveVolume(_ context.Context, options docker.RemoveVolumeOptions) error {
+	r.log.Debug("Dry-run: skipping volume removal", "volume", options.Name)
+	return nil
+}
+
+// RemoveNetwork records the removal of the network without calling the
+// Docker API.
+func (r *dryRunRecorder) RemoveNetwork(_ context.Context, options docker.RemoveNetworkOptions) error {
+	r.log.Debug("Dry-run: skipping network removal", "networkID", options.NetworkID)
+	return nil
+}
+
+// PruneBuildCache records the prune of the build cache without calling the
+// Docker API, reporting the records the prune would delete and the bytes it
+// would reclaim.
+func (r *dryRunRecorder) PruneBuildCache(ctx context.Context, options docker.BuildCachePruneOptions) (docker.BuildCachePruneReport, error) {
+	r.log.Debug("Dry-run: skipping build cache prune", "keepStorage", options.KeepStorage)
+	return r.api.PlanBuildCachePrune(ctx, options)
+}
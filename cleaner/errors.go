@@ -0,0 +1,151 @@
+package cleaner
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/lucasmendesl/beerus/docker"
+)
+
+// RemovalError describes a failed removal attempt, classified by the nature
+// of the error returned by the Docker API.
+type RemovalError struct {
+	Resource string
+	ID       string
+	Class    docker.ErrorClass
+	Err      error
+}
+
+// newRemovalError returns the RemovalError of a removal of the given kind of
+// resource that failed with the given error.
+func newRemovalError(kind resourceKind, id string, err error) *RemovalError {
+	return &RemovalError{
+		Resource: string(kind),
+		ID:       id,
+		Class:    docker.ClassifyError(err),
+		Err:      err,
+	}
+}
+
+func (e *RemovalError) Error() string {
+	if e.Resource == string(resourceBuildCache) {
+		return fmt.Sprintf("error pruning build cache: %v", e.Err)
+	}
+
+	return fmt.Sprintf("error removing %s with id %s: %v", e.Resource, e.ID, e.Err)
+}
+
+func (e *RemovalError) Unwrap() error {
+	return e.Err
+}
+
+// RemovalErrors aggregates the failed removal attempts of a cleanup pass.
+// Every candidate is attempted before it is returned, so it holds every
+// failure instead of only the first one.
+type RemovalErrors []*RemovalError
+
+func (e RemovalErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	classes := e.Classes()
+	counts := make([]string, 0, len(classes))
+	for _, class := range slices.Sorted(maps.Keys(classes)) {
+		counts = append(counts, fmt.Sprintf("%s: %d", class, classes[class]))
+	}
+
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return fmt.Sprintf("%d removals failed (%s): %s", len(e), strings.Join(counts, ", "), strings.Join(messages, "; "))
+}
+
+func (e RemovalErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// Classes returns the number of failed removals for each error class.
+func (e RemovalErrors) Classes() map[docker.ErrorClass]int {
+	classes := make(map[docker.ErrorClass]int)
+	for _, err := range e {
+		classes[err.Class]++
+	}
+
+	return classes
+}
+
+// Fatal reports whether any removal failed because of the daemon, in which
+// case there is no point in going on with the cleanup.
+func (e RemovalErrors) Fatal() bool {
+	return slices.ContainsFunc(e, func(err *RemovalError) bool {
+		return err.Class.Fatal()
+	})
+}
+
+// isFatal reports whether the given error must stop the cleanup. Failed
+// removals only stop it when the daemon itself failed, while any other
+// error, such as a listing error or a canceled context, stops it.
+func isFatal(err error) bool {
+	var removalErrs RemovalErrors
+	if errors.As(err, &removalErrs) {
+		return removalErrs.Fatal()
+	}
+
+	return err != nil
+}
+
+// removalFailures collects the failed attempts of concurrent removals. It is
+// safe for concurrent use.
+type removalFailures struct {
+	mu   sync.Mutex
+	errs RemovalErrors
+}
+
+// add collects the failed removal of the given resource.
+func (f *removalFailures) add(kind resourceKind, id string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.errs = append(f.errs, newRemovalError(kind, id, err))
+}
+
+// err returns the collected failures, or nil when every removal succeeded.
+func (f *removalFailures) err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.errs) == 0 {
+		return nil
+	}
+
+	return slices.Clone(f.errs)
+}
+
+// failures returns the failed removals recorded in the cycle, or nil when
+// every removal succeeded.
+func (cy *cycle) failures() error {
+	var errs RemovalErrors
+	for _, r := range cy.entries() {
+		if r.err != nil {
+			errs = append(errs, newRemovalError(r.kind, r.id, r.err))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
@@ -3,22 +3,51 @@ package cleaner
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/lucasmendesl/beerus/docker"
-	"golang.org/x/sync/errgroup"
+	"github.com/lucasmendesl/beerus/policy"
 )
 
+// removableImage is an image selected for removal, along with the rule that
+// selected it.
+type removableImage struct {
+	docker.Image
+	rule removalRule
+}
+
 // listAllowedImagesToRemove returns a list of Docker images that are considered
 // removable based on specific criteria. It fetches all images and filters
 // them to identify those that are either dangling or expired according to
 // the provided lifetime threshold. It then removes images that are currently
 // running from the list of removable images. The function takes a context.Context
-// and returns a slice of docker.Image containing removable images and an error
+// and returns a slice of removableImage containing removable images and an error
 // if any occurs during the cleanup process.
-func (c *cleaner) listAllowedImagesToRemove(ctx context.Context) ([]docker.Image, error) {
+func (c *cleaner) listAllowedImagesToRemove(ctx context.Context) ([]removableImage, error) {
+	return c.listImagesToRemove(ctx, c.expiredImageListOptions())
+}
+
+// expiredImageListOptions returns the criteria for removable images, following
+// the image configuration. Every image is listed when image policies are
+// configured, so they decide whatever the age of the images.
+func (c *cleaner) expiredImageListOptions() docker.ExpiredImageListOptions {
+	return docker.ExpiredImageListOptions{
+		LifetimeThresholdInDays: c.config.Images.LifetimeThreshold,
+		IgnoreLabels:            c.config.Images.IgnoreLabels,
+		TargetLabels:            c.config.Images.TargetLabels,
+		Repositories:            c.names.Repositories,
+		Tags:                    c.names.Tags,
+		KeepLastTags:            c.config.Images.KeepLastTags,
+		All:                     c.policy.Applies(policy.KindImage),
+	}
+}
+
+// listImagesToRemove returns the images matching the given criteria that are
+// allowed to be removed, leaving out the images used by running containers
+// and, unless forced, the images with more than one tag.
+func (c *cleaner) listImagesToRemove(ctx context.Context, options docker.ExpiredImageListOptions) ([]removableImage, error) {
 	c.log.Debug("Listing allowed images for removal")
 	containers, err := c.d.ListContainers(ctx,
-		c.config.ConcurrencyLevel,
 		docker.WithContainerStatus(docker.ContainerStatusRunning),
 	)
 
@@ -28,47 +57,87 @@ func (c *cleaner) listAllowedImagesToRemove(ctx context.Context) ([]docker.Image
 
 	c.log.Debug("Removing running images, even if they are expired")
 	runningImages := make(map[string]struct{})
+	now := time.Now()
 	for _, container := range containers {
 		runningImages[container.ImageID] = struct{}{}
+		c.usage.touch(container.ImageID, now)
 	}
 
+	options.LastUsed = c.usage.snapshot()
+	options.FirstSeen = c.usage.firstSeen()
+
 	c.log.Debug("Getting expired images")
-	expiredImgs, err := c.d.ListExpiredImages(ctx, docker.ExpiredImageListOptions{
-		LifetimeThresholdInDays: c.config.Images.LifetimeThreshold,
-		IgnoreLabels:            c.config.Images.IgnoreLabels,
-	})
+	expiredImgs, imageIDs, err := c.d.ListExpiredImages(ctx, options)
 
 	if err != nil {
 		return nil, fmt.Errorf("error getting expired images: %w", err)
 	}
 
+	// the new images are seen for the first time, while the images removed
+	// outside of the cleaner are not tracked anymore
+	c.usage.see(imageIDs, now)
+
 	c.log.Debug("Filtering running images from expired images")
-	removableImgs := make([]docker.Image, 0, len(expiredImgs))
+	removableImgs := make([]removableImage, 0, len(expiredImgs))
 	for _, img := range expiredImgs {
 		if len(img.Tags) > 1 && !c.config.Images.ForceRemovalOnConflict {
+			c.auditKept(resourceImage, img.ID, img.Tags, img.Labels, ruleMultipleTags)
+			continue
+		}
+
+		if _, ok := runningImages[img.ID]; ok {
+			c.auditKept(resourceImage, img.ID, img.Tags, img.Labels, ruleInUse)
 			continue
 		}
 
-		if _, ok := runningImages[img.ID]; !ok {
-			removableImgs = append(removableImgs, img)
+		rule, ok := c.imageRemovalRule(img, now)
+		if !ok {
+			c.auditKept(resourceImage, img.ID, img.Tags, img.Labels, rule)
+			continue
 		}
+
+		removableImgs = append(removableImgs, removableImage{Image: img, rule: rule})
 	}
 
 	c.log.Debug("Returning removable images", "count", len(removableImgs))
 	return removableImgs, nil
 }
 
-// removeImages removes the specified Docker images concurrently.
+// imageRemovalRule returns the rule that selects the given image for
+// removal, reporting false when the image must be kept. When image policies
+// are configured, they decide in place of the fixed rules. Otherwise, the
+// image was listed for being dangling, or older than its TTL or than the
+// lifetime threshold.
+func (c *cleaner) imageRemovalRule(img docker.Image, now time.Time) (removalRule, bool) {
+	if c.policy.Applies(policy.KindImage) {
+		return c.policyRule(policy.KindImage, policy.ImageResource(img, now))
+	}
+
+	switch {
+	case img.Dangling:
+		return ruleDangling, true
+	case img.TTL > 0:
+		return ruleTTL, true
+	default:
+		return ruleExpired, true
+	}
+}
+
+// removeImages removes the specified Docker images concurrently,
+// running the removals on the executor of the cleaner, so the number of
+// removals in flight never exceeds its limit.
 // It logs the start of the removal process and attempts to remove each
 // image by calling the Docker API.
 // If an error occurs during the removal of any image, it continues the
 // operation and logs the error. The function blocks until all images
-// have been processed or the context is canceled.
+// have been processed or the context is canceled, then returns every failed
+// removal together as RemovalErrors.
 //
 // Parameters:
 // - ctx: The context for managing request lifetime and cancellation.
-// - removableImgs: A slice of docker.Image containing the images to be removed.
-func (c *cleaner) removeImages(ctx context.Context, removableImgs ...docker.Image) error {
+// - cy: The cleanup cycle where every removal attempt is recorded.
+// - removableImgs: The images to be removed.
+func (c *cleaner) removeImages(ctx context.Context, cy *cycle, removableImgs ...removableImage) error {
 	imagesLen := len(removableImgs)
 	c.log.Debug("Removing images", "count", imagesLen)
 
@@ -77,19 +146,35 @@ func (c *cleaner) removeImages(ctx context.Context, removableImgs ...docker.Imag
 		return nil
 	}
 
-	g, ctx := errgroup.WithContext(ctx)
+	var failures removalFailures
+	g, ctx := c.exec.Group(ctx)
 
 	for _, img := range removableImgs {
 		g.Go(func() error {
-			c.log.Debug("Attempting to remove image", "imageID", img.ID)
+			c.log.Debug("Attempting to remove image", "imageID", img.ID, "rule", img.rule)
 			options := docker.RemoveImageOptions{
 				ImageID: img.ID,
+				Tags:    img.Tags,
 				Force:   len(img.Tags) > 1 && c.config.Images.ForceRemovalOnConflict,
 			}
 
-			if err := c.d.RemoveImage(ctx, options); err != nil {
-				return fmt.Errorf("error removing image with id %s: %w", img.ID, err)
+			err := c.d.RemoveImage(ctx, options)
+			c.record(cy, removal{
+				kind:   resourceImage,
+				id:     img.ID,
+				names:  img.Tags,
+				labels: img.Labels,
+				rule:   img.rule,
+				size:   img.Size,
+				err:    err,
+			})
+
+			if err != nil {
+				c.log.Error("Failed to remove image", "id", img.ID, "class", docker.ClassifyError(err), "error", err)
+				failures.add(resourceImage, img.ID, err)
+				return nil
 			}
+
 			c.log.Debug("Successfully removed image", "imageID", img.ID)
 			return nil
 		})
@@ -99,5 +184,5 @@ func (c *cleaner) removeImages(ctx context.Context, removableImgs ...docker.Imag
 		return err
 	}
 
-	return nil
+	return failures.err()
 }
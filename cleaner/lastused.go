@@ -0,0 +1,44 @@
+package cleaner
+
+import (
+	"time"
+
+	"github.com/lucasmendesl/beerus/state"
+)
+
+// imageUsage tracks the first time each image was seen and the last time it
+// was used by a container, keyed by image ID. The tracked times are kept in
+// the state store of the cleaner, so they survive restarts when it is
+// persisted, and an image is forgotten once its deletion is recorded there.
+// It is safe for concurrent use.
+type imageUsage struct {
+	store state.Store
+}
+
+// newImageUsage returns the imageUsage kept in the given state store.
+func newImageUsage(store state.Store) imageUsage {
+	return imageUsage{store: store}
+}
+
+// touch records that the given image was used at the given time, unless a
+// later use is already known.
+func (u imageUsage) touch(imageID string, usedAt time.Time) {
+	u.store.TouchImage(imageID, usedAt)
+}
+
+// snapshot returns a copy of the tracked last used times.
+func (u imageUsage) snapshot() map[string]time.Time {
+	return u.store.ImageLastUsed()
+}
+
+// firstSeen returns a copy of the tracked first seen times.
+func (u imageUsage) firstSeen() map[string]time.Time {
+	return u.store.ImageFirstSeen()
+}
+
+// see records that the given images, which are every existing image, were
+// seen at the given time, unless they were seen before, and forgets the
+// images missing from them, which no longer exist.
+func (u imageUsage) see(imageIDs []string, seenAt time.Time) {
+	u.store.SeeImages(imageIDs, seenAt)
+}
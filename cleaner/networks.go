@@ -0,0 +1,51 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lucasmendesl/beerus/docker"
+)
+
+// listAllowedNetworksToRemove returns a list of user-defined Docker networks
+// that are considered removable, which are the networks without any attached
+// container and older than the configured lifetime threshold.
+func (c *cleaner) listAllowedNetworksToRemove(ctx context.Context) ([]docker.Network, error) {
+	c.log.Debug("Getting orphaned networks")
+	networks, err := c.d.ListOrphanedNetworks(ctx, docker.OrphanedNetworkListOptions{
+		LifetimeThresholdInHours: c.config.Networks.LifetimeThreshold,
+		IgnoreLabels:             c.config.Networks.IgnoreLabels,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting orphaned networks: %w", err)
+	}
+
+	c.log.Debug("Returning removable networks", "count", len(networks))
+	return networks, nil
+}
+
+// removeNetwork removes the specified Docker network, describing the attempt
+// as the removal of an orphaned network.
+func (c *cleaner) removeNetwork(ctx context.Context, n docker.Network) removal {
+	c.log.Debug("Attempting to remove network", "networkID", n.ID, "name", n.Name)
+
+	return removal{
+		kind:   resourceNetwork,
+		id:     n.ID,
+		names:  []string{n.Name},
+		labels: n.Labels,
+		rule:   ruleOrphaned,
+		err:    c.d.RemoveNetwork(ctx, docker.RemoveNetworkOptions{NetworkID: n.ID}),
+	}
+}
+
+// sweepNetworks lists the networks allowed for removal and removes them,
+// when the network cleanup is enabled.
+func (c *cleaner) sweepNetworks(ctx context.Context, cy *cycle) error {
+	if !c.config.Networks.Enabled {
+		return nil
+	}
+
+	return sweepResources(ctx, c, cy, resourceNetwork, c.listAllowedNetworksToRemove, c.removeNetwork)
+}
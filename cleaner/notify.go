@@ -0,0 +1,41 @@
+package cleaner
+
+import (
+	"context"
+	"time"
+
+	"github.com/lucasmendesl/beerus/notifier"
+)
+
+// notify sends the summary of the given cycle to the notifier, logging the
+// failure instead of interrupting the cleanup.
+func (c *cleaner) notify(ctx context.Context, cy *cycle) {
+	s := cy.summary()
+
+	err := c.notifier.Notify(ctx, notifier.Summary{
+		Endpoint:   c.endpoint,
+		Cycle:      cy.name,
+		StartedAt:  cy.startedAt,
+		FinishedAt: time.Now(),
+		DryRun:     c.config.DryRun,
+		Removed: notifier.Counts{
+			Containers: s.ContainersRemoved,
+			Images:     s.ImagesRemoved,
+			Volumes:    s.VolumesRemoved,
+			Networks:   s.NetworksRemoved,
+			BuildCache: s.BuildCachePruned,
+		},
+		Failed: notifier.Counts{
+			Containers: s.ContainersFailed,
+			Images:     s.ImagesFailed,
+			Volumes:    s.VolumesFailed,
+			Networks:   s.NetworksFailed,
+			BuildCache: s.BuildCacheFailed,
+		},
+		ReclaimedBytes: s.ReclaimedBytes,
+	})
+
+	if err != nil {
+		c.log.Warn("Failed to send cycle notification", "cycle", cy.name, "error", err)
+	}
+}
@@ -0,0 +1,26 @@
+package cleaner
+
+import (
+	"github.com/lucasmendesl/beerus/policy"
+)
+
+// policyRulePrefix prefixes the name of the policy selecting or keeping a
+// resource in the reported rule, so it cannot be mistaken for a fixed rule.
+const policyRulePrefix = "policy:"
+
+// policyRule returns the rule selecting the given resource for removal
+// following the policies of its kind, reporting false when it must be kept.
+// A resource matching no policy, or whose evaluation fails, is kept.
+func (c *cleaner) policyRule(kind policy.Kind, r policy.Resource) (removalRule, bool) {
+	decision, err := c.policy.Evaluate(kind, r)
+	if err != nil {
+		c.log.Error("Failed to evaluate policies, keeping resource", "resource", kind, "id", r.ID, "error", err)
+		return rulePolicyError, false
+	}
+
+	if decision.Rule == "" {
+		return ruleNoPolicy, false
+	}
+
+	return removalRule(policyRulePrefix + decision.Rule), decision.Remove()
+}
@@ -0,0 +1,107 @@
+package cleaner
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/go-units"
+)
+
+// printPlan writes a table describing every resource that would have been
+// removed during the given cycle, along with the rule that selected it and
+// its size. The resources of unknown size are left out of the reclaimable
+// bytes.
+func (c *cleaner) printPlan(cy *cycle) {
+	entries := cy.entries()
+
+	var reclaimable int64
+	for _, entry := range entries {
+		reclaimable += max(entry.size, 0)
+	}
+
+	c.log.Info("Dry-run plan", "cycle", cy.name, "count", len(entries), "size", reclaimable)
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\nDry-run plan for %s: %d resource(s), %s reclaimable\n", c.reportTitle(cy), len(entries), units.HumanSize(float64(reclaimable)))
+
+	if len(entries) == 0 {
+		w.Flush()
+		return
+	}
+
+	fmt.Fprintln(w, "KIND\tID\tNAMES\tRULE\tSIZE")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			entry.kind,
+			stringid.TruncateID(entry.id),
+			formatNames(entry.names),
+			entry.rule,
+			formatSize(entry.size),
+		)
+	}
+
+	w.Flush()
+}
+
+// reportTitle returns the name of the given cycle as printed in the reports,
+// along with the Docker endpoint managed by the cleaner when it is set.
+func (c *cleaner) reportTitle(cy *cycle) string {
+	if c.endpoint == "" {
+		return cy.name
+	}
+
+	return fmt.Sprintf("%s on %s", cy.name, c.endpoint)
+}
+
+// formatNames joins container names or image tags for display, removing the
+// leading slash Docker adds to container names.
+func formatNames(names []string) string {
+	if len(names) == 0 {
+		return "-"
+	}
+
+	trimmed := make([]string, 0, len(names))
+	for _, name := range names {
+		trimmed = append(trimmed, strings.TrimPrefix(name, "/"))
+	}
+
+	return strings.Join(trimmed, ",")
+}
+
+// formatSize returns the given size in bytes for display, or "unknown" when
+// it is negative.
+func formatSize(size int64) string {
+	if size < 0 {
+		return "unknown"
+	}
+
+	return units.HumanSize(float64(size))
+}
+
+// printSummary writes the number of resources removed and the number of
+// failed removals for each kind of resource in the given cycle, followed by
+// the amount of bytes reclaimed.
+func (c *cleaner) printSummary(cy *cycle) {
+	summary := cy.summary()
+
+	c.log.Info("Cleanup summary",
+		"cycle", cy.name,
+		"removed", summary.Removed(),
+		"failed", summary.Failed(),
+		"reclaimed", summary.ReclaimedBytes,
+	)
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\nCleanup summary for %s\n", c.reportTitle(cy))
+	fmt.Fprintln(w, "KIND\tREMOVED\tFAILED")
+	fmt.Fprintf(w, "%s\t%d\t%d\n", resourceContainer, summary.ContainersRemoved, summary.ContainersFailed)
+	fmt.Fprintf(w, "%s\t%d\t%d\n", resourceImage, summary.ImagesRemoved, summary.ImagesFailed)
+	fmt.Fprintf(w, "%s\t%d\t%d\n", resourceVolume, summary.VolumesRemoved, summary.VolumesFailed)
+	fmt.Fprintf(w, "%s\t%d\t%d\n", resourceNetwork, summary.NetworksRemoved, summary.NetworksFailed)
+	fmt.Fprintf(w, "%s\t%d\t%d\n", resourceBuildCache, summary.BuildCachePruned, summary.BuildCacheFailed)
+	fmt.Fprintf(w, "Reclaimed space: %s\n", units.HumanSize(float64(summary.ReclaimedBytes)))
+
+	w.Flush()
+}
@@ -0,0 +1,75 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// defaultLivenessThreshold is the time the event stream may go without an
+// event nor a heartbeat when no threshold is configured.
+const defaultLivenessThreshold = 5 * time.Minute
+
+var (
+	errEventStreamStale    = errors.New("docker event stream stale")
+	errInitialSweepPending = errors.New("initial sweep not finished")
+)
+
+// status tracks the state of the cleaner reported by the health endpoints.
+type status struct {
+	sweepDone atomic.Bool
+	watching  atomic.Bool
+
+	// lastActivity is the time, in nanoseconds, of the last event received
+	// or heartbeat of the event stream
+	lastActivity atomic.Int64
+}
+
+// heartbeat records activity of the event stream at the given time.
+func (s *status) heartbeat(at time.Time) {
+	s.lastActivity.Store(at.UnixNano())
+}
+
+// livenessThreshold returns the time the event stream may go without an
+// event nor a heartbeat before the cleaner is considered dead.
+func (c *cleaner) livenessThreshold() time.Duration {
+	if c.config.HTTP.LivenessThreshold == 0 {
+		return defaultLivenessThreshold
+	}
+
+	return time.Duration(c.config.HTTP.LivenessThreshold) * time.Second
+}
+
+// Live reports whether the cleaner is alive. Once the event watchers are
+// set up, the cleaner is only considered alive while the event stream shows
+// activity, an event or a heartbeat, within the liveness threshold. The
+// reconnection of a failed stream is not reported as dead on its own, only
+// an outage outlasting the threshold is, so the process can be restarted.
+func (c *cleaner) Live() error {
+	if !c.status.watching.Load() {
+		return nil
+	}
+
+	idle := time.Since(time.Unix(0, c.status.lastActivity.Load()))
+	if threshold := c.livenessThreshold(); idle > threshold {
+		return fmt.Errorf("%w: no activity for %s", errEventStreamStale, idle.Round(time.Second))
+	}
+
+	return nil
+}
+
+// Ready reports whether the cleaner is ready, which means that the initial
+// sweep is finished and the Docker daemon is reachable.
+func (c *cleaner) Ready(ctx context.Context) error {
+	if !c.status.sweepDone.Load() {
+		return errInitialSweepPending
+	}
+
+	if err := c.d.Ping(ctx); err != nil {
+		return fmt.Errorf("docker daemon unreachable: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package cleaner
+
+import (
+	"context"
+
+	"github.com/lucasmendesl/beerus/docker"
+)
+
+// sweepResources lists the resources of the given kind allowed for removal
+// and removes them, logging the failures of either step.
+//
+// Parameters:
+// - ctx: The context for managing request lifetime and cancellation.
+// - c: The cleaner sweeping the resources.
+// - cy: The cleanup cycle where every removal attempt is recorded.
+// - kind: The kind of the swept resources.
+// - list: Lists the resources allowed for removal.
+// - remove: Removes a single resource, describing the removal attempt.
+func sweepResources[T any](
+	ctx context.Context,
+	c *cleaner,
+	cy *cycle,
+	kind resourceKind,
+	list func(context.Context) ([]T, error),
+	remove func(context.Context, T) removal,
+) error {
+	c.log.Info("Listing resources allowed for removal", "kind", kind)
+	resources, err := list(ctx)
+	if err != nil {
+		c.log.Error("Failed to list removable resources", "kind", kind, "error", err)
+		return err
+	}
+
+	c.log.Info("Removing resources", "kind", kind, "count", len(resources))
+	if err := removeResources(ctx, c, cy, kind, resources, remove); err != nil {
+		c.log.Error("Failed to remove resources", "kind", kind, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// removeResources removes the specified resources of the given kind
+// concurrently, running the removals on the executor of the cleaner, so the
+// number of removals in flight never exceeds its limit.
+// Every removal attempt described by remove is recorded on the cycle. If an
+// error occurs during the removal of any resource, it continues the
+// operation and logs the error. The function blocks until all resources
+// have been processed or the context is canceled, then returns every failed
+// removal together as RemovalErrors.
+func removeResources[T any](
+	ctx context.Context,
+	c *cleaner,
+	cy *cycle,
+	kind resourceKind,
+	resources []T,
+	remove func(context.Context, T) removal,
+) error {
+	resourcesLen := len(resources)
+	c.log.Debug("Removing resources", "kind", kind, "count", resourcesLen)
+
+	if resourcesLen == 0 {
+		c.log.Warn("No resources to remove", "kind", kind)
+		return nil
+	}
+
+	var failures removalFailures
+	g, ctx := c.exec.Group(ctx)
+
+	for _, resource := range resources {
+		g.Go(func() error {
+			r := remove(ctx, resource)
+			c.record(cy, r)
+
+			if r.err != nil {
+				c.log.Error("Failed to remove resource", "kind", kind, "id", r.id, "class", docker.ClassifyError(r.err), "error", r.err)
+				failures.add(kind, r.id, r.err)
+				return nil
+			}
+
+			c.log.Debug("Successfully removed resource", "kind", kind, "id", r.id)
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	return failures.err()
+}
@@ -0,0 +1,89 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// scheduleTTLSweep records that a stopped container was kept until its TTL
+// expires at the given time, waking the TTL watcher up when it moves the
+// earliest expiry forward.
+func (c *cleaner) scheduleTTLSweep(at time.Time) {
+	c.ttlMu.Lock()
+	if !c.ttlExpiry.IsZero() && !at.Before(c.ttlExpiry) {
+		c.ttlMu.Unlock()
+		return
+	}
+
+	c.ttlExpiry = at
+	c.ttlMu.Unlock()
+
+	select {
+	case c.ttlChanged <- struct{}{}:
+	default:
+	}
+}
+
+// watchContainerTTLs sweeps the stopped containers once the earliest TTL of
+// the ones kept meanwhile expires, so a container exiting before its TTL is
+// removed without waiting for another event. The sweep schedules the next
+// expiry from the containers it keeps. Outside of the maintenance windows,
+// the sweep is deferred to the opening of the next one. If a listing error
+// or a fatal daemon error occurs, the function sends the error on the error
+// channel and returns.
+func (c *cleaner) watchContainerTTLs(ctx context.Context, errCh chan<- error) {
+	for {
+		c.ttlMu.Lock()
+		expiry := c.ttlExpiry
+		c.ttlMu.Unlock()
+
+		// nothing to wait for until a container is kept for its TTL
+		var (
+			timer   *time.Timer
+			expired <-chan time.Time
+		)
+
+		if !expiry.IsZero() {
+			timer = time.NewTimer(time.Until(expiry))
+			expired = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			return
+		case <-c.ttlChanged:
+			// a container was kept until an earlier expiry meanwhile
+			stopTimer(timer)
+			continue
+		case <-expired:
+		}
+
+		c.ttlMu.Lock()
+		c.ttlExpiry = time.Time{}
+		c.ttlMu.Unlock()
+
+		if now := time.Now(); !c.removalsAllowed(now) {
+			c.log.Info("Container ttl expired outside of the maintenance windows, deferring the sweep", "until", c.schedule.NextOpen(now), "context", "Container TTL")
+			c.deferRemovals()
+			continue
+		}
+
+		c.log.Debug("Container ttl expired, sweeping the stopped containers", "context", "Container TTL")
+		cy := newCycle("container ttl")
+		if err := c.sweepContainers(ctx, cy); err != nil {
+			errCh <- fmt.Errorf("container ttl sweep error: %w", err)
+			return
+		}
+
+		c.finishCycle(cy)
+	}
+}
+
+// stopTimer stops the given timer, if any.
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
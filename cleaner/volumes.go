@@ -0,0 +1,53 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lucasmendesl/beerus/docker"
+)
+
+// listAllowedVolumesToRemove returns a list of Docker volumes that are
+// considered removable. Only dangling volumes older than the configured
+// lifetime threshold are returned, and named volumes are only included when
+// the configuration allows it.
+func (c *cleaner) listAllowedVolumesToRemove(ctx context.Context) ([]docker.Volume, error) {
+	c.log.Debug("Getting expired volumes")
+	volumes, err := c.d.ListExpiredVolumes(ctx, docker.ExpiredVolumeListOptions{
+		LifetimeThresholdInDays: c.config.Volumes.LifetimeThreshold,
+		IgnoreLabels:            c.config.Volumes.IgnoreLabels,
+		IncludeNamed:            c.config.Volumes.IncludeNamed,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting expired volumes: %w", err)
+	}
+
+	c.log.Debug("Returning removable volumes", "count", len(volumes))
+	return volumes, nil
+}
+
+// removeVolume removes the specified Docker volume, describing the attempt
+// as the removal of an expired volume.
+func (c *cleaner) removeVolume(ctx context.Context, vol docker.Volume) removal {
+	c.log.Debug("Attempting to remove volume", "volume", vol.Name, "anonymous", vol.Anonymous)
+
+	return removal{
+		kind:   resourceVolume,
+		id:     vol.Name,
+		labels: vol.Labels,
+		rule:   ruleExpired,
+		size:   vol.Size,
+		err:    c.d.RemoveVolume(ctx, docker.RemoveVolumeOptions{Name: vol.Name}),
+	}
+}
+
+// sweepVolumes lists the volumes allowed for removal and removes them, when
+// the volume cleanup is enabled.
+func (c *cleaner) sweepVolumes(ctx context.Context, cy *cycle) error {
+	if !c.config.Volumes.Enabled {
+		return nil
+	}
+
+	return sweepResources(ctx, c, cy, resourceVolume, c.listAllowedVolumesToRemove, c.removeVolume)
+}
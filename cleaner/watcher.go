@@ -2,55 +2,305 @@ package cleaner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types/events"
 	"github.com/lucasmendesl/beerus/docker"
+	"github.com/lucasmendesl/beerus/policy"
 )
 
+const (
+	// minReconnectBackoff is the delay before the first attempt to reconnect
+	// the event stream, doubled on every failed attempt.
+	minReconnectBackoff = time.Second
+
+	// maxReconnectBackoff caps the delay between the attempts to reconnect
+	// the event stream.
+	maxReconnectBackoff = time.Minute
+)
+
+var (
+	errEventStreamClosed = errors.New("docker event stream closed")
+	errEventStreamIdle   = errors.New("docker event stream idle")
+)
+
+// eventKey identifies an event among the ones sharing its time.
+type eventKey struct {
+	id     string
+	action events.Action
+}
+
+// handledEvents tracks the events handled at the time of the last one, so the
+// events replayed by a reconnected stream are skipped, while the distinct
+// events emitted within the same nanosecond are all handled.
+type handledEvents struct {
+	last int64
+
+	// keys holds the events handled at the last time, nil when they are not
+	// known, such as for the cursor kept in the state, where every event
+	// emitted at that time is considered handled
+	keys map[eventKey]struct{}
+}
+
+// newHandledEvents returns the handled events up to the given cursor.
+func newHandledEvents(cursor time.Time) *handledEvents {
+	if cursor.IsZero() {
+		return &handledEvents{keys: make(map[eventKey]struct{})}
+	}
+
+	return &handledEvents{last: cursor.UnixNano()}
+}
+
+// seen reports whether the event emitted at the given time was handled.
+// Events without time are never considered handled.
+func (h *handledEvents) seen(eventTime int64, message events.Message) bool {
+	if eventTime == 0 || eventTime > h.last {
+		return false
+	}
+
+	if eventTime < h.last || h.keys == nil {
+		return true
+	}
+
+	_, ok := h.keys[eventKey{id: message.Actor.ID, action: message.Action}]
+	return ok
+}
+
+// add records the event emitted at the given time as handled, reporting
+// whether the time of the last handled event moved forward.
+func (h *handledEvents) add(eventTime int64, message events.Message) bool {
+	if eventTime == 0 || eventTime < h.last {
+		return false
+	}
+
+	advanced := eventTime > h.last
+	if advanced || h.keys == nil {
+		h.last = eventTime
+		h.keys = make(map[eventKey]struct{})
+	}
+
+	h.keys[eventKey{id: message.Actor.ID, action: message.Action}] = struct{}{}
+	return advanced
+}
+
 // watch sets up event listeners for specific Docker events and logs them. It
 // takes a context.Context and a channel of error objects as parameters. The
 // function listens for specific Docker events, such as image untagging and
 // container exit events, and logs these events. It also runs a periodic task to
-// identify and log removable images based on certain criteria. The function
-// returns an error if any occurs during the cleanup process.
+// identify and log removable images based on certain criteria.
+//
+// When the event stream fails, for instance after a restart of the daemon,
+// it is reconnected with an exponential backoff, replaying the events
+// emitted since the last processed one, so no event is lost during the
+// outage. The position of the last processed event is kept in the state, so
+// the events emitted while the cleaner was not running are replayed as well.
+// Without any position yet, the time the stream is first opened is used, so
+// an outage before the first event does not lose the events either.
+// A stream idle for half of the liveness threshold is checked with a ping of
+// the daemon, the heartbeat, and opened again, so a stalled stream does not go
+// unnoticed. The function only returns when the context is canceled.
 func (c *cleaner) watch(ctx context.Context, errCh chan<- error) {
 	// run the image checker periodically, following the configuration
 	go c.pollImageChecker(ctx, errCh)
 
+	// check the disk usage against the watermarks, when enabled
+	go c.watchDiskUsage(ctx, errCh)
+
+	// sweep the containers kept for their TTL once it expires
+	go c.watchContainerTTLs(ctx, errCh)
+
 	c.log.Info("Starting watching docker events...", "context", "Event")
+	handled := newHandledEvents(c.state.EventCursor())
+	if c.state.EventCursor().IsZero() {
+		c.state.SetEventCursor(time.Now())
+		c.flushState()
+	}
+
+	c.status.heartbeat(time.Now())
+	c.status.watching.Store(true)
+
+	backoff := minReconnectBackoff
+
+	for {
+		received, err := c.streamEvents(ctx, handled)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		// a stream that delivered events was healthy, so the next failure
+		// starts over from the shortest delay
+		if received {
+			backoff = minReconnectBackoff
+		}
+
+		// an idle stream answered by the daemon is opened again right away
+		if errors.Is(err, errEventStreamIdle) {
+			c.log.Debug("event stream idle, opening it again", "context", "Event")
+			backoff = minReconnectBackoff
+			continue
+		}
+
+		c.log.Error("error receiving event, reconnecting", "error", err, "retry in", backoff, "context", "Event")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxReconnectBackoff)
+	}
+}
+
+// streamEvents consumes the Docker event stream until it fails, handling the
+// container exit and image untagging events. The stream replays the events
+// emitted since the event cursor kept in the state, skipping the ones already
+// handled, and the cursor is advanced as the events are processed. When no
+// event is received for half of the liveness threshold, the daemon is pinged
+// and the stream is ended with errEventStreamIdle, so it is opened again. It
+// reports whether any event was received along with the error that ended the
+// stream.
+func (c *cleaner) streamEvents(ctx context.Context, handled *handledEvents) (bool, error) {
+	since := c.state.EventCursor()
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	// listen for specific Docker events
 	// container exit events
 	// image untagging events
-	for result := range c.d.FromEvents(ctx,
+	// container create and start events, tracking the image usage
+	results := c.d.FromEvents(streamCtx, since,
 		events.ActionDie,
 		events.ActionUnTag,
-	) {
+		events.ActionCreate,
+		events.ActionStart,
+	)
+
+	idleTimeout := c.livenessThreshold() / 2
+	idle := time.NewTimer(idleTimeout)
+	defer idle.Stop()
+
+	received := false
+
+	for {
+		var (
+			result docker.EventResult
+			ok     bool
+		)
+
+		select {
+		case <-idle.C:
+			if err := c.d.Ping(ctx); err != nil {
+				return received, fmt.Errorf("event stream idle and docker daemon unreachable: %w", err)
+			}
+
+			c.status.heartbeat(time.Now())
+			return received, errEventStreamIdle
+		case result, ok = <-results:
+		}
+
+		if !ok {
+			return received, errEventStreamClosed
+		}
+
 		if result.Err != nil {
-			c.log.Error("error receiving event", "error", result.Err, "context", "Event")
-			errCh <- result.Err
-			return
+			return received, result.Err
+		}
+
+		received = true
+		c.status.heartbeat(time.Now())
+		idle.Reset(idleTimeout)
+
+		eventTime := eventTimeNano(result.Message)
+
+		if handled.seen(eventTime, result.Message) {
+			c.log.Debug("event already handled, skipping it", "action", result.Message.Action, "id", result.Message.ID, "context", "Event")
+			continue
 		}
 
 		c.log.Debug("event received", "action", result.Message.Action, "id", result.Message.ID, "context", "Event")
 		c.handleWatcherEvent(ctx, result.Message)
+
+		if handled.add(eventTime, result.Message) {
+			c.state.SetEventCursor(time.Unix(0, eventTime))
+			c.flushState()
+		}
+	}
+}
+
+// eventTimeNano returns the time of the event in nanoseconds, falling back
+// to the time in seconds for daemons that do not report it. It returns zero
+// when the event carries no time at all.
+func eventTimeNano(message events.Message) int64 {
+	if message.TimeNano != 0 || message.Time == 0 {
+		return message.TimeNano
 	}
+
+	return time.Unix(message.Time, 0).UnixNano()
 }
 
 // pollImageChecker is a goroutine that periodically checks for removable
-// images and removes them. It takes a context.Context, a cleaner object, and a
+// images and removes them, along with the removable volumes and networks
+// and the exceeding build cache when their cleanup is enabled. It takes a context.Context, a cleaner object, and a
 // channel of error objects as parameters. The function runs in an infinite
-// loop, checking for removable images once a minute. If an error occurs during
-// the cleanup process, the function sends the error on the error channel and
-// returns.
+// loop, checking for removable images on every poll interval, or following
+// the cron expression of the schedule when there is one. A check falling
+// outside of the maintenance windows is deferred to the opening of the next
+// one, where the containers left by the deferred removals are removed as
+// well. Failed removals are logged and reported in the cycle summary, while
+// listing errors and fatal daemon errors are sent on the error channel, and
+// the function returns.
 func (c *cleaner) pollImageChecker(ctx context.Context, errCh chan<- error) {
-	c.log.Info("Starting periodic image checker, checking for removable images every", "interval in hours", c.config.ExpirePollCheckInterval, "context", "Image Poller")
+	interval := time.Hour * time.Duration(max(c.config.ExpirePollCheckInterval, 1))
+	c.log.Info("Starting periodic image checker, checking for removable images every", "interval in hours", c.config.ExpirePollCheckInterval, "cron", c.config.Schedule.Cron, "context", "Image Poller")
+
+	// due is set when a check fell outside of the maintenance windows
+	due := false
+
+	for {
+		now := time.Now()
+		at := c.schedule.Next(now, interval)
+
+		// the deferred checks run as soon as the next window opens
+		if due || c.pendingSweep.Load() {
+			at = c.schedule.NextOpen(now)
+		}
+
+		timer := time.NewTimer(time.Until(at))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-c.deferred:
+			// removals were deferred meanwhile, so the wake up time is
+			// computed again
+			timer.Stop()
+			continue
+		case <-timer.C:
+		}
+
+		if now := time.Now(); !c.removalsAllowed(now) {
+			c.log.Info("Outside of the maintenance windows, deferring the check", "until", c.schedule.NextOpen(now), "context", "Image Poller")
+			due = true
+			continue
+		}
+
+		due = false
+		cy := newCycle("image poller")
 
-	ticker := time.NewTicker(time.Hour * time.Duration(c.config.ExpirePollCheckInterval))
-	defer ticker.Stop()
+		if c.pendingSweep.Swap(false) {
+			c.log.Debug("Removing the containers left by the deferred removals", "context", "Image Poller")
+			if err := c.sweepContainers(ctx, cy); err != nil {
+				errCh <- fmt.Errorf("container poller error: %w", err)
+				return
+			}
+		}
 
-	for range ticker.C {
 		c.log.Debug("Checking for removable images", "context", "Image Poller")
 		removableImgs, err := c.listAllowedImagesToRemove(ctx)
 
@@ -60,10 +310,32 @@ func (c *cleaner) pollImageChecker(ctx context.Context, errCh chan<- error) {
 		}
 
 		c.log.Debug("Found removable images", "count", len(removableImgs), "context", "Image Poller")
-		if err := c.removeImages(ctx, removableImgs...); err != nil {
+		if err := c.removeImages(ctx, cy, removableImgs...); isFatal(err) {
 			errCh <- fmt.Errorf("remove image poller error: %w", err)
 			return
 		}
+
+		if err := c.sweepVolumes(ctx, cy); isFatal(err) {
+			errCh <- fmt.Errorf("volume poller error: %w", err)
+			return
+		}
+
+		if err := c.sweepNetworks(ctx, cy); isFatal(err) {
+			errCh <- fmt.Errorf("network poller error: %w", err)
+			return
+		}
+
+		if err := c.pruneBuildCache(ctx, cy); isFatal(err) {
+			errCh <- fmt.Errorf("build cache poller error: %w", err)
+			return
+		}
+
+		if err := cy.failures(); err != nil {
+			c.log.Warn("Image poller finished with failed removals", "error", err, "context", "Image Poller")
+		}
+
+		c.finishCycle(cy)
+		c.notify(ctx, cy)
 	}
 }
 
@@ -72,39 +344,124 @@ func (c *cleaner) pollImageChecker(ctx context.Context, errCh chan<- error) {
 // If the action is "untag", the function removes the image if it is not used by any containers.
 // If the action is "die", the function inspects the container that exited and removes it if it does
 // not have a restart policy.
+// If the action is "create" or "start", the function records the time the image of the container
+// was used.
+// Outside of the maintenance windows, the removals are deferred to the next window, unless the
+// events are configured to bypass them.
 func (c *cleaner) handleWatcherEvent(ctx context.Context, message events.Message) {
+	c.metrics.EventReceived(string(message.Action))
+
 	switch message.Action {
+	case events.ActionCreate, events.ActionStart:
+		// record the image used by the container, so its age is counted from
+		// its last use
+		c.log.Debug("container event received, tracking image usage", "action", message.Action, "id", message.ID, "context", "Event")
+		container, err := c.d.Inspect(ctx, message.Actor.ID, false)
+		if err != nil {
+			c.log.Error("error inspecting container", "error", err, "context", "Event")
+			break
+		}
+
+		usedAt := time.Now()
+		if eventTime := eventTimeNano(message); eventTime != 0 {
+			usedAt = time.Unix(0, eventTime)
+		}
+
+		c.usage.touch(container.ImageID, usedAt)
 	case events.ActionUnTag:
+		// the image policies need the whole image, so the untagged images
+		// are left to the next poller tick
+		if c.policy.Applies(policy.KindImage) {
+			c.log.Debug("untag event received, leaving image to the policies", "id", message.ID, "context", "Event")
+			break
+		}
+
+		// the untagged image is dangling, so it is left to the sweep of the
+		// next maintenance window
+		if !c.eventRemovalsAllowed(time.Now()) {
+			c.log.Debug("untag event received outside of the maintenance windows, deferring image removal", "id", message.ID, "context", "Event")
+			c.deferRemovals()
+			break
+		}
+
+		c.log.Debug("untag event received, inspecting image", "id", message.ID, "context", "Event")
+		image, err := c.d.InspectImage(ctx, message.ID)
+		if err != nil {
+			c.log.Error("error inspecting image", "error", err, "context", "Event")
+			break
+		}
+
+		// the label selectors are applied by the listings, so the images
+		// they leave out must be left out here as well
+		if !docker.LabelsSelected(image, c.config.Images.IgnoreLabels, c.config.Images.TargetLabels) {
+			c.log.Debug("image not selected by the label selectors", "id", message.ID, "context", "Event")
+			break
+		}
+
+		// the name matchers are applied by the listings as well, to the tags
+		// the image still has
+		if !docker.ImageSelected(image, c.names.Repositories, c.names.Tags) {
+			c.log.Debug("image not selected by the name filters", "id", message.ID, "tags", image.Tags, "context", "Event")
+			break
+		}
+
 		// if an image is untagged, remove it if it is not used by any
-		// containers
+		// containers. The remaining tags are left out, so the removal is
+		// never forced.
 		c.log.Debug("untag event received, removing image", "id", message.ID, "context", "Event")
-		if err := c.removeImages(ctx, docker.Image{ID: message.ID}); err != nil {
+		cy := newCycle("untag event")
+		img := removableImage{
+			Image: docker.Image{ID: message.ID, Labels: image.Labels, Size: image.Size},
+			rule:  ruleUntagged,
+		}
+
+		if err := c.removeImages(ctx, cy, img); err != nil {
 			c.log.Error("error on removing image", "context", "Event", "err", err)
 		}
+		c.finishCycle(cy)
 	case events.ActionDie:
 		// if a container exits, remove it if it does not have a restart
 		// policy
 		c.log.Debug("die event received, inspecting container", "id", message.ID, "context", "Event")
-		containerDetails, err := c.d.Inspect(ctx, message.ID)
+		container, err := c.d.Inspect(ctx, message.ID, c.containerSizeNeeded())
 		if err != nil {
 			c.log.Error("error inspecting container", "error", err, "context", "Event")
 			break
 		}
 
-		c.log.Debug("container inspected", "id", message.ID, "restart-policy", containerDetails.HostConfig.RestartPolicy.Name, "context", "Event")
+		c.log.Debug("container inspected", "id", message.ID, "status", container.Status, "restart-policy", container.RestartPolicy.Name, "context", "Event")
 
-		container := docker.Container{
-			RestartCount:  containerDetails.RestartCount,
-			RestartPolicy: containerDetails.HostConfig.RestartPolicy,
+		// the name filter is applied by the listings, so the containers it
+		// leaves out must be left out here as well
+		if !c.names.Containers.Selects(strings.TrimPrefix(container.Names[0], "/")) {
+			c.log.Debug("container not selected by the name filter", "id", message.ID, "name", container.Names[0], "context", "Event")
+			break
 		}
-		if !docker.CanRemoveContainer(container, c.config.Containers.MaxAlwaysRestartPolicyCount) {
-			c.log.Debug("unavailable container to remove", "id", message.ID, "restart-policy", containerDetails.HostConfig.RestartPolicy.Name, "context", "Event")
+
+		// the label selectors are applied by the listings as well
+		if !docker.LabelsSelected(container, c.config.Containers.IgnoreLabels, c.config.Containers.TargetLabels) {
+			c.log.Debug("container not selected by the label selectors", "id", message.ID, "context", "Event")
+			break
+		}
+
+		rule, ok := c.containerRemovalRule(container)
+		if !ok {
+			c.keepContainer(container, rule)
+			c.log.Debug("unavailable container to remove", "id", message.ID, "restart-policy", container.RestartPolicy.Name, "ttl", container.TTL, "context", "Event")
+			break
+		}
+
+		if !c.eventRemovalsAllowed(time.Now()) {
+			c.log.Debug("container is removable outside of the maintenance windows, deferring its removal", "id", message.ID, "rule", rule, "context", "Event")
+			c.deferRemovals()
 			break
 		}
 
-		c.log.Debug("container is removable, removing it", "id", message.ID, "context", "Event")
-		if err := c.removeContainers(ctx, message.Actor.ID); err != nil {
+		c.log.Debug("container is removable, removing it", "id", message.ID, "rule", rule, "context", "Event")
+		cy := newCycle("die event")
+		if err := c.removeContainers(ctx, cy, removableContainer{Container: container, rule: rule}); err != nil {
 			c.log.Error("removing container", "context", "Event", "err", err)
 		}
+		c.finishCycle(cy)
 	}
 }
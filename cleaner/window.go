@@ -0,0 +1,29 @@
+package cleaner
+
+import "time"
+
+// removalsAllowed reports whether the resources may be removed at the given
+// time, following the maintenance windows. Nothing is removed in dry-run
+// mode, so the windows never defer anything there.
+func (c *cleaner) removalsAllowed(now time.Time) bool {
+	return c.config.DryRun || c.schedule.Open(now)
+}
+
+// eventRemovalsAllowed reports whether the removals triggered by the events
+// may happen at the given time, which they may outside of the maintenance
+// windows when the events are configured to bypass them.
+func (c *cleaner) eventRemovalsAllowed(now time.Time) bool {
+	return c.config.DryRun || c.schedule.EventsAllowed(now)
+}
+
+// deferRemovals records that removals were deferred to the next maintenance
+// window, waking the image poller up, so a whole sweep, containers included,
+// runs as soon as the window opens.
+func (c *cleaner) deferRemovals() {
+	c.pendingSweep.Store(true)
+
+	select {
+	case c.deferred <- struct{}{}:
+	default:
+	}
+}
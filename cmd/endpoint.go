@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/docker/cli/cli/connhelper"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/tlsconfig"
+	"github.com/lucasmendesl/beerus/config"
+	"github.com/lucasmendesl/beerus/metrics"
+)
+
+// endpointName restricts the endpoint names to the ones usable as metric
+// label values and as directory names under the data directory.
+var endpointName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// endpoints returns the Docker endpoints listed in the configuration, or the
+// default endpoint, configured by the docker settings, when none is listed. It
+// returns an error when an endpoint has no host or an invalid or duplicated
+// name.
+func endpoints(cfg *config.Beerus) ([]config.Endpoint, error) {
+	if len(cfg.Endpoints) == 0 {
+		return []config.Endpoint{{Name: metrics.DefaultEndpoint}}, nil
+	}
+
+	seen := make(map[string]bool, len(cfg.Endpoints))
+	for _, e := range cfg.Endpoints {
+		switch {
+		case !endpointName.MatchString(e.Name):
+			return nil, fmt.Errorf("invalid endpoint name %q", e.Name)
+		case seen[e.Name]:
+			return nil, fmt.Errorf("duplicated endpoint name %q", e.Name)
+		case e.Host == "":
+			return nil, fmt.Errorf("endpoint %q has no host", e.Name)
+		}
+
+		seen[e.Name] = true
+	}
+
+	return cfg.Endpoints, nil
+}
+
+// endpointConnection returns the connection settings of the given endpoint,
+// which are the docker settings with the host and the TLS certificates of the
+// endpoint, when it has a host, and with its runtime, when it has one.
+func endpointConnection(docker config.Docker, e config.Endpoint) config.Docker {
+	if e.Host != "" {
+		docker.Host = e.Host
+		docker.TLS = e.TLS
+	}
+
+	if e.Runtime != "" {
+		docker.Runtime = e.Runtime
+	}
+
+	return docker
+}
+
+// newDockerClient creates the Docker API client described by the given
+// connection settings. Unix socket and tcp daemons are reached directly,
+// using TLS when a certificate is configured, while ssh daemons are reached
+// through the docker binary of the remote host. When neither the host nor
+// TLS are configured, the client is configured by the environment.
+func newDockerClient(conn config.Docker) (*client.Client, error) {
+	options := []client.Opt{client.WithAPIVersionNegotiation()}
+
+	switch {
+	case conn.Host == "" && !conn.TLS.Enabled():
+		options = append(options, client.FromEnv)
+	case strings.HasPrefix(conn.Host, "ssh://"):
+		helper, err := connhelper.GetConnectionHelper(conn.Host)
+		if err != nil {
+			return nil, fmt.Errorf("invalid host %q: %w", conn.Host, err)
+		}
+
+		options = append(options,
+			client.WithHost(helper.Host),
+			client.WithDialContext(helper.Dialer),
+		)
+	case conn.TLS.Enabled():
+		tlsConfig, err := tlsconfig.Client(tlsconfig.Options{
+			CAFile:             conn.TLS.CACert,
+			CertFile:           conn.TLS.Cert,
+			KeyFile:            conn.TLS.Key,
+			InsecureSkipVerify: conn.TLS.SkipVerify(),
+			ExclusiveRootPools: true,
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid tls configuration: %w", err)
+		}
+
+		// the host is applied to the transport of the http client, so it
+		// must come after it
+		options = append(options,
+			client.WithHTTPClient(&http.Client{
+				Transport:     &http.Transport{TLSClientConfig: tlsConfig},
+				CheckRedirect: client.CheckRedirect,
+			}),
+			client.WithHost(cmp.Or(conn.Host, client.DefaultDockerHost)),
+		)
+	default:
+		options = append(options, client.WithHost(conn.Host))
+	}
+
+	// an empty version keeps the version negotiation
+	if conn.APIVersion != "" {
+		options = append(options, client.WithVersion(conn.APIVersion))
+	}
+
+	return client.NewClientWithOpts(options...)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api"
+	"github.com/lucasmendesl/beerus/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDockerClient_APIVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		conn        config.Docker
+		envVersion  string
+		wantVersion string
+	}{
+		{
+			name:        "no version set",
+			conn:        config.Docker{Host: "tcp://127.0.0.1:2375"},
+			wantVersion: api.DefaultVersion,
+		},
+		{
+			name:        "no version set keeps the version of the environment",
+			conn:        config.Docker{},
+			envVersion:  "1.41",
+			wantVersion: "1.41",
+		},
+		{
+			name:        "configured version",
+			conn:        config.Docker{Host: "tcp://127.0.0.1:2375", APIVersion: "1.43"},
+			wantVersion: "1.43",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DOCKER_HOST", "")
+			t.Setenv("DOCKER_API_VERSION", tt.envVersion)
+
+			cli, err := newDockerClient(tt.conn)
+			require.NoError(t, err)
+			t.Cleanup(func() { cli.Close() })
+
+			require.Equal(t, tt.wantVersion, cli.ClientVersion())
+		})
+	}
+}
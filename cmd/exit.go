@@ -0,0 +1,43 @@
+package cmd
+
+import "errors"
+
+const (
+	// exitCodeFailure is returned when the command could not complete.
+	exitCodeFailure = 1
+
+	// exitCodePartialFailure is returned when a single cleanup pass completed,
+	// but some of the resources could not be removed.
+	exitCodePartialFailure = 2
+)
+
+// exitError is an error carrying the exit code the process should
+// terminate with.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// ExitCode returns the exit code the process should terminate with for the
+// given error returned by the command. A nil error maps to zero, and errors
+// without a specific exit code map to a generic failure.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+
+	return exitCodeFailure
+}
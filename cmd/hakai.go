@@ -2,19 +2,32 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/docker/docker/client"
+	"github.com/lucasmendesl/beerus/audit"
 	"github.com/lucasmendesl/beerus/cleaner"
 	"github.com/lucasmendesl/beerus/config"
 	"github.com/lucasmendesl/beerus/docker"
+	"github.com/lucasmendesl/beerus/executor"
 	"github.com/lucasmendesl/beerus/logger"
+	"github.com/lucasmendesl/beerus/metrics"
+	"github.com/lucasmendesl/beerus/notifier"
+	"github.com/lucasmendesl/beerus/policy"
+	"github.com/lucasmendesl/beerus/schedule"
+	"github.com/lucasmendesl/beerus/server"
+	"github.com/lucasmendesl/beerus/state"
+	"github.com/lucasmendesl/beerus/supervisor"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"golang.org/x/sync/errgroup"
 )
 
 func newHakaiCmd() *cobra.Command {
@@ -25,6 +38,7 @@ func newHakaiCmd() *cobra.Command {
 		RunE:  cleanResources,
 	}
 
+	hakaiCmd.Flags().Bool("once", false, "run a single cleanup pass, print a summary and exit")
 	setupCommandFlags(hakaiCmd.Flags())
 	return hakaiCmd
 }
@@ -37,6 +51,40 @@ func setupCommandFlags(commandFlags *pflag.FlagSet) {
 	// general flags
 	commandFlags.Uint8("concurrency-level", 5, "number of concurrent workers")
 	commandFlags.Uint8("expiring-poll-check-interval", 1, "interval to check for expired resources in hours")
+	commandFlags.Bool("dry-run", false, "report the resources that would be removed without removing them")
+	commandFlags.String("schedule-cron", "", "cron expression scheduling the periodic sweeps in place of the poll interval")
+	commandFlags.String("schedule-time-zone", "", "time zone of the cron expression and of the maintenance windows (empty is the local one)")
+	commandFlags.Bool("events-bypass-maintenance-windows", false, "let the removals triggered by the events happen outside of the maintenance windows")
+	commandFlags.String("data-dir", "", "directory where the local state is persisted (empty keeps it in memory)")
+	commandFlags.String("http-address", "", "address of the HTTP listener exposing metrics and health checks (empty is disabled)")
+	commandFlags.Uint16("liveness-threshold", 300, "time in seconds without docker events nor heartbeats before the liveness endpoint fails")
+
+	// docker section flags
+	commandFlags.String("docker-host", "", "address of the docker daemon (empty uses the DOCKER_HOST environment variable)")
+	commandFlags.String("docker-api-version", "", "docker api version (empty negotiates it with the daemon)")
+	commandFlags.String("docker-tls-ca-cert", "", "certificate authority verifying the certificate of the docker daemon")
+	commandFlags.String("docker-tls-cert", "", "client certificate presented to the docker daemon")
+	commandFlags.String("docker-tls-key", "", "private key of the client certificate")
+	commandFlags.Bool("docker-tls-verify", true, "verify the certificate of the docker daemon")
+	commandFlags.Uint16("docker-timeout", 0, "docker api request timeout in seconds (0 is disabled)")
+	commandFlags.String("docker-runtime", "auto", "container engine serving the docker api (auto, docker or podman)")
+
+	// retry section flags
+	commandFlags.Uint8("retry-max-attempts", 3, "maximum number of attempts of a docker api call failing with a transient error (1 is disabled)")
+	commandFlags.Uint16("retry-base-delay", 500, "delay before the first retry in milliseconds, doubled on every attempt")
+	commandFlags.Float64("retry-jitter", 0.2, "fraction of the retry delay randomly added or removed (0 to 1)")
+
+	// audit section flags
+	commandFlags.String("audit-path", "", "file where the audit trail is written as JSON Lines (empty is disabled)")
+	commandFlags.String("audit-max-size", "100MB", "size after which the audit file is rotated")
+	commandFlags.Int("audit-max-backups", 5, "number of rotated audit files kept")
+
+	// notification section flags
+	commandFlags.String("notify-min-reclaimed", "", "reclaimed space under which cycles without failures are not notified (e.g. 1GB)")
+	commandFlags.String("notify-webhook-url", "", "webhook receiving the summary of each cleanup cycle (empty is disabled)")
+	commandFlags.String("notify-webhook-template", "", "Go template rendering the webhook body (empty sends the summary as JSON)")
+	commandFlags.Uint16("notify-webhook-timeout", 10, "webhook request timeout in seconds")
+	commandFlags.Uint8("notify-webhook-max-retries", 3, "number of retries of a failed webhook request")
 
 	// log section flags
 	commandFlags.String("log-level", "info", "log level (debug, info, warn, error)")
@@ -45,69 +93,205 @@ func setupCommandFlags(commandFlags *pflag.FlagSet) {
 	// image section flags
 	commandFlags.Uint16("lifetime-threshold", 10, "lifetime threshold in days")
 	commandFlags.Bool("force-removal-on-conflict", false, "force removal of resources when a conflict is detected (more than one tag per repository)")
-	commandFlags.StringArray("image-ignore-labels", []string{}, "ignore images with the specified label during cleanup")
+	commandFlags.StringArray("image-ignore-labels", []string{}, "ignore images matching the specified label selector during cleanup")
+	commandFlags.StringArray("image-target-labels", []string{}, "only clean up images matching one of the specified label selectors")
+	commandFlags.Uint16("keep-last-tags", 0, "number of most recent images kept for each repository (0 is disabled)")
+	commandFlags.StringArray("image-include-repositories", []string{}, "only clean up images whose repository matches one of the glob or /regex/ patterns")
+	commandFlags.StringArray("image-exclude-repositories", []string{}, "never clean up images whose repository matches one of the glob or /regex/ patterns")
+	commandFlags.StringArray("image-include-tags", []string{}, "only clean up images whose tag matches one of the glob or /regex/ patterns")
+	commandFlags.StringArray("image-exclude-tags", []string{}, "never clean up images whose tag matches one of the glob or /regex/ patterns")
 
 	// container section flags
 	commandFlags.Int("max-always-restart-policy-count", 0, "max always restart policy count (0 is disabled)")
-	commandFlags.StringArray("container-ignore-labels", []string{}, "ignore containers with the specified label during cleanup")
+	commandFlags.StringArray("container-ignore-labels", []string{}, "ignore containers matching the specified label selector during cleanup")
+	commandFlags.StringArray("container-target-labels", []string{}, "only clean up containers matching one of the specified label selectors")
+	commandFlags.StringArray("container-include-names", []string{}, "only clean up containers whose name matches one of the glob or /regex/ patterns")
+	commandFlags.StringArray("container-exclude-names", []string{}, "never clean up containers whose name matches one of the glob or /regex/ patterns")
 	commandFlags.Bool("force-volume-cleanup", false, "force volume cleanup")
 	commandFlags.Bool("force-link-cleanup", false, "force link cleanup")
 
+	// volume section flags
+	commandFlags.Bool("volume-cleanup", false, "enable the cleanup of dangling volumes")
+	commandFlags.Uint16("volume-lifetime-threshold", 10, "volume lifetime threshold in days")
+	commandFlags.StringArray("volume-ignore-labels", []string{}, "ignore volumes matching the specified label selector during cleanup")
+	commandFlags.Bool("include-named-volumes", false, "remove named volumes as well, not only anonymous ones")
+
+	// network section flags
+	commandFlags.Bool("network-cleanup", false, "enable the cleanup of networks without attached containers")
+	commandFlags.Uint16("network-lifetime-threshold", 24, "network lifetime threshold in hours")
+	commandFlags.StringArray("network-ignore-labels", []string{}, "ignore networks matching the specified label selector during cleanup")
+
+	// build cache section flags
+	commandFlags.Bool("build-cache-cleanup", false, "enable the pruning of the build cache")
+	commandFlags.String("build-cache-keep-storage", "", "amount of build cache to keep (e.g. 10GB)")
+	commandFlags.Uint16("build-cache-lifetime-threshold", 0, "build cache lifetime threshold in days (0 is disabled)")
+	commandFlags.Bool("build-cache-include-shared", false, "prune shared and internal build cache records as well")
+
+	// disk usage section flags
+	commandFlags.Bool("disk-usage-cleanup", false, "enable the cleanup triggered by the disk usage watermarks")
+	commandFlags.String("disk-usage-high-watermark", "", "disk usage above which the cleanup is escalated (e.g. 80GB)")
+	commandFlags.String("disk-usage-low-watermark", "", "disk usage under which the escalation stops (e.g. 60GB)")
+	commandFlags.Uint16("disk-usage-check-interval", 5, "disk usage check interval in minutes")
+
 	bindCommandFlags(commandFlags)
 	bindEnv()
 }
 
 func bindEnv() {
+	viper.BindEnv("docker.host", "BEERUS_DOCKER_HOST")
+	viper.BindEnv("docker.apiVersion", "BEERUS_DOCKER_API_VERSION")
+	viper.BindEnv("docker.tls.caCert", "BEERUS_DOCKER_TLS_CA_CERT")
+	viper.BindEnv("docker.tls.cert", "BEERUS_DOCKER_TLS_CERT")
+	viper.BindEnv("docker.tls.key", "BEERUS_DOCKER_TLS_KEY")
+	viper.BindEnv("docker.tls.verify", "BEERUS_DOCKER_TLS_VERIFY")
+	viper.BindEnv("docker.timeout", "BEERUS_DOCKER_TIMEOUT")
+	viper.BindEnv("docker.runtime", "BEERUS_DOCKER_RUNTIME")
+
 	viper.BindEnv("beerus.concurrencyLevel", "BEERUS_CONCURRENCY_LEVEL")
 	viper.BindEnv("beerus.expiringPollCheckInterval", "BEERUS_EXPIRING_POLL_CHECK_INTERVAL")
+	viper.BindEnv("beerus.dryRun", "BEERUS_DRY_RUN")
+	viper.BindEnv("beerus.schedule.cron", "BEERUS_SCHEDULE_CRON")
+	viper.BindEnv("beerus.schedule.timeZone", "BEERUS_SCHEDULE_TIME_ZONE")
+	viper.BindEnv("beerus.schedule.eventsBypassWindows", "BEERUS_SCHEDULE_EVENTS_BYPASS_WINDOWS")
+	viper.BindEnv("beerus.dataDir", "BEERUS_DATA_DIR")
+	viper.BindEnv("beerus.http.address", "BEERUS_HTTP_ADDRESS")
+	viper.BindEnv("beerus.http.livenessThreshold", "BEERUS_HTTP_LIVENESS_THRESHOLD")
+	viper.BindEnv("beerus.retry.maxAttempts", "BEERUS_RETRY_MAX_ATTEMPTS")
+	viper.BindEnv("beerus.retry.baseDelay", "BEERUS_RETRY_BASE_DELAY")
+	viper.BindEnv("beerus.retry.jitter", "BEERUS_RETRY_JITTER")
+	viper.BindEnv("beerus.audit.path", "BEERUS_AUDIT_PATH")
+	viper.BindEnv("beerus.audit.maxSize", "BEERUS_AUDIT_MAX_SIZE")
+	viper.BindEnv("beerus.audit.maxBackups", "BEERUS_AUDIT_MAX_BACKUPS")
+	viper.BindEnv("beerus.notifications.minReclaimed", "BEERUS_NOTIFICATIONS_MIN_RECLAIMED")
+	viper.BindEnv("beerus.notifications.webhook.url", "BEERUS_NOTIFICATIONS_WEBHOOK_URL")
+	viper.BindEnv("beerus.notifications.webhook.template", "BEERUS_NOTIFICATIONS_WEBHOOK_TEMPLATE")
+	viper.BindEnv("beerus.notifications.webhook.timeout", "BEERUS_NOTIFICATIONS_WEBHOOK_TIMEOUT")
+	viper.BindEnv("beerus.notifications.webhook.maxRetries", "BEERUS_NOTIFICATIONS_WEBHOOK_MAX_RETRIES")
 
 	viper.BindEnv("beerus.logging.level", "BEERUS_LOG_LEVEL")
 	viper.BindEnv("beerus.logging.format", "BEERUS_LOG_FORMAT")
 
 	viper.BindEnv("beerus.images.lifetimeThreshold", "BEERUS_IMAGES_LIFETIME_THRESHOLD")
 	viper.BindEnv("beerus.images.ignoreLabels", "BEERUS_IMAGES_IGNORE_LABELS")
+	viper.BindEnv("beerus.images.targetLabels", "BEERUS_IMAGES_TARGET_LABELS")
 	viper.BindEnv("beerus.images.forceRemovalOnConflict", "BEERUS_IMAGES_FORCE_REMOVAL_ON_CONFLICT")
+	viper.BindEnv("beerus.images.keepLastTags", "BEERUS_IMAGES_KEEP_LAST_TAGS")
+	viper.BindEnv("beerus.images.repositories.include", "BEERUS_IMAGES_REPOSITORIES_INCLUDE")
+	viper.BindEnv("beerus.images.repositories.exclude", "BEERUS_IMAGES_REPOSITORIES_EXCLUDE")
+	viper.BindEnv("beerus.images.tags.include", "BEERUS_IMAGES_TAGS_INCLUDE")
+	viper.BindEnv("beerus.images.tags.exclude", "BEERUS_IMAGES_TAGS_EXCLUDE")
 
 	viper.BindEnv("beerus.containers.maxAlwaysRestartPolicyCount", "BEERUS_CONTAINERS_MAX_ALWAYS_RESTART_POLICY_COUNT")
 	viper.BindEnv("beerus.containers.ignoreLabels", "BEERUS_CONTAINERS_IGNORE_LABELS")
+	viper.BindEnv("beerus.containers.targetLabels", "BEERUS_CONTAINERS_TARGET_LABELS")
+	viper.BindEnv("beerus.containers.names.include", "BEERUS_CONTAINERS_NAMES_INCLUDE")
+	viper.BindEnv("beerus.containers.names.exclude", "BEERUS_CONTAINERS_NAMES_EXCLUDE")
 	viper.BindEnv("beerus.containers.forceVolumeCleanup", "BEERUS_CONTAINERS_FORCE_VOLUME_CLEANUP")
 	viper.BindEnv("beerus.containers.forceLinkCleanup", "BEERUS_CONTAINERS_FORCE_LINK_CLEANUP")
+
+	viper.BindEnv("beerus.volumes.enabled", "BEERUS_VOLUMES_ENABLED")
+	viper.BindEnv("beerus.volumes.lifetimeThreshold", "BEERUS_VOLUMES_LIFETIME_THRESHOLD")
+	viper.BindEnv("beerus.volumes.ignoreLabels", "BEERUS_VOLUMES_IGNORE_LABELS")
+	viper.BindEnv("beerus.volumes.includeNamed", "BEERUS_VOLUMES_INCLUDE_NAMED")
+
+	viper.BindEnv("beerus.networks.enabled", "BEERUS_NETWORKS_ENABLED")
+	viper.BindEnv("beerus.networks.lifetimeThreshold", "BEERUS_NETWORKS_LIFETIME_THRESHOLD")
+	viper.BindEnv("beerus.networks.ignoreLabels", "BEERUS_NETWORKS_IGNORE_LABELS")
+
+	viper.BindEnv("beerus.buildCache.enabled", "BEERUS_BUILD_CACHE_ENABLED")
+	viper.BindEnv("beerus.buildCache.keepStorage", "BEERUS_BUILD_CACHE_KEEP_STORAGE")
+	viper.BindEnv("beerus.buildCache.lifetimeThreshold", "BEERUS_BUILD_CACHE_LIFETIME_THRESHOLD")
+	viper.BindEnv("beerus.buildCache.includeShared", "BEERUS_BUILD_CACHE_INCLUDE_SHARED")
+	viper.BindEnv("beerus.diskUsage.enabled", "BEERUS_DISK_USAGE_ENABLED")
+	viper.BindEnv("beerus.diskUsage.highWatermark", "BEERUS_DISK_USAGE_HIGH_WATERMARK")
+	viper.BindEnv("beerus.diskUsage.lowWatermark", "BEERUS_DISK_USAGE_LOW_WATERMARK")
+	viper.BindEnv("beerus.diskUsage.checkInterval", "BEERUS_DISK_USAGE_CHECK_INTERVAL")
 }
 
 func bindCommandFlags(commandFlags *pflag.FlagSet) {
+	viper.BindPFlag("docker.host", commandFlags.Lookup("docker-host"))
+	viper.BindPFlag("docker.apiVersion", commandFlags.Lookup("docker-api-version"))
+	viper.BindPFlag("docker.tls.caCert", commandFlags.Lookup("docker-tls-ca-cert"))
+	viper.BindPFlag("docker.tls.cert", commandFlags.Lookup("docker-tls-cert"))
+	viper.BindPFlag("docker.tls.key", commandFlags.Lookup("docker-tls-key"))
+	viper.BindPFlag("docker.tls.verify", commandFlags.Lookup("docker-tls-verify"))
+	viper.BindPFlag("docker.timeout", commandFlags.Lookup("docker-timeout"))
+	viper.BindPFlag("docker.runtime", commandFlags.Lookup("docker-runtime"))
+
 	viper.BindPFlag("beerus.concurrencyLevel", commandFlags.Lookup("concurrency-level"))
 	viper.BindPFlag("beerus.expiringPollCheckInterval", commandFlags.Lookup("expiring-poll-check-interval"))
+	viper.BindPFlag("beerus.dryRun", commandFlags.Lookup("dry-run"))
+	viper.BindPFlag("beerus.schedule.cron", commandFlags.Lookup("schedule-cron"))
+	viper.BindPFlag("beerus.schedule.timeZone", commandFlags.Lookup("schedule-time-zone"))
+	viper.BindPFlag("beerus.schedule.eventsBypassWindows", commandFlags.Lookup("events-bypass-maintenance-windows"))
+	viper.BindPFlag("beerus.dataDir", commandFlags.Lookup("data-dir"))
+	viper.BindPFlag("beerus.http.address", commandFlags.Lookup("http-address"))
+	viper.BindPFlag("beerus.http.livenessThreshold", commandFlags.Lookup("liveness-threshold"))
+	viper.BindPFlag("beerus.retry.maxAttempts", commandFlags.Lookup("retry-max-attempts"))
+	viper.BindPFlag("beerus.retry.baseDelay", commandFlags.Lookup("retry-base-delay"))
+	viper.BindPFlag("beerus.retry.jitter", commandFlags.Lookup("retry-jitter"))
+	viper.BindPFlag("beerus.audit.path", commandFlags.Lookup("audit-path"))
+	viper.BindPFlag("beerus.audit.maxSize", commandFlags.Lookup("audit-max-size"))
+	viper.BindPFlag("beerus.audit.maxBackups", commandFlags.Lookup("audit-max-backups"))
+	viper.BindPFlag("beerus.notifications.minReclaimed", commandFlags.Lookup("notify-min-reclaimed"))
+	viper.BindPFlag("beerus.notifications.webhook.url", commandFlags.Lookup("notify-webhook-url"))
+	viper.BindPFlag("beerus.notifications.webhook.template", commandFlags.Lookup("notify-webhook-template"))
+	viper.BindPFlag("beerus.notifications.webhook.timeout", commandFlags.Lookup("notify-webhook-timeout"))
+	viper.BindPFlag("beerus.notifications.webhook.maxRetries", commandFlags.Lookup("notify-webhook-max-retries"))
 
 	viper.BindPFlag("beerus.logging.level", commandFlags.Lookup("log-level"))
 	viper.BindPFlag("beerus.logging.format", commandFlags.Lookup("log-format"))
 
 	viper.BindPFlag("beerus.images.lifetimeThreshold", commandFlags.Lookup("lifetime-threshold"))
 	viper.BindPFlag("beerus.images.ignoreLabels", commandFlags.Lookup("image-ignore-labels"))
+	viper.BindPFlag("beerus.images.targetLabels", commandFlags.Lookup("image-target-labels"))
 	viper.BindPFlag("beerus.images.forceRemovalOnConflict", commandFlags.Lookup("force-removal-on-conflict"))
+	viper.BindPFlag("beerus.images.keepLastTags", commandFlags.Lookup("keep-last-tags"))
+	viper.BindPFlag("beerus.images.repositories.include", commandFlags.Lookup("image-include-repositories"))
+	viper.BindPFlag("beerus.images.repositories.exclude", commandFlags.Lookup("image-exclude-repositories"))
+	viper.BindPFlag("beerus.images.tags.include", commandFlags.Lookup("image-include-tags"))
+	viper.BindPFlag("beerus.images.tags.exclude", commandFlags.Lookup("image-exclude-tags"))
 
 	viper.BindPFlag("beerus.containers.maxAlwaysRestartPolicyCount", commandFlags.Lookup("max-always-restart-policy-count"))
 	viper.BindPFlag("beerus.containers.ignoreLabels", commandFlags.Lookup("container-ignore-labels"))
+	viper.BindPFlag("beerus.containers.targetLabels", commandFlags.Lookup("container-target-labels"))
+	viper.BindPFlag("beerus.containers.names.include", commandFlags.Lookup("container-include-names"))
+	viper.BindPFlag("beerus.containers.names.exclude", commandFlags.Lookup("container-exclude-names"))
 	viper.BindPFlag("beerus.containers.forceVolumeCleanup", commandFlags.Lookup("force-volume-cleanup"))
 	viper.BindPFlag("beerus.containers.forceLinkCleanup", commandFlags.Lookup("force-link-cleanup"))
-}
 
-// cleanResources creates a docker client and a logger based on the configuration
-// file specified in the command line flag --config-file. It then creates a
-// cleaner object with the docker client and logger, and runs the cleaner with
-// the context created from the command context. The function also sets up a
-// signal handler to cancel the context when a SIGTERM or SIGINT signal is
-// received. If any error occurs during the cleanup process, the function returns
-// the error.
-func cleanResources(cmd *cobra.Command, _ []string) error {
-	cli, err := client.NewClientWithOpts(
-		client.FromEnv,
-		client.WithAPIVersionNegotiation(),
-	)
+	viper.BindPFlag("beerus.volumes.enabled", commandFlags.Lookup("volume-cleanup"))
+	viper.BindPFlag("beerus.volumes.lifetimeThreshold", commandFlags.Lookup("volume-lifetime-threshold"))
+	viper.BindPFlag("beerus.volumes.ignoreLabels", commandFlags.Lookup("volume-ignore-labels"))
+	viper.BindPFlag("beerus.volumes.includeNamed", commandFlags.Lookup("include-named-volumes"))
 
-	if err != nil {
-		return fmt.Errorf("error creating docker client api: %w", err)
-	}
+	viper.BindPFlag("beerus.networks.enabled", commandFlags.Lookup("network-cleanup"))
+	viper.BindPFlag("beerus.networks.lifetimeThreshold", commandFlags.Lookup("network-lifetime-threshold"))
+	viper.BindPFlag("beerus.networks.ignoreLabels", commandFlags.Lookup("network-ignore-labels"))
 
+	viper.BindPFlag("beerus.buildCache.enabled", commandFlags.Lookup("build-cache-cleanup"))
+	viper.BindPFlag("beerus.buildCache.keepStorage", commandFlags.Lookup("build-cache-keep-storage"))
+	viper.BindPFlag("beerus.buildCache.lifetimeThreshold", commandFlags.Lookup("build-cache-lifetime-threshold"))
+	viper.BindPFlag("beerus.buildCache.includeShared", commandFlags.Lookup("build-cache-include-shared"))
+	viper.BindPFlag("beerus.diskUsage.enabled", commandFlags.Lookup("disk-usage-cleanup"))
+	viper.BindPFlag("beerus.diskUsage.highWatermark", commandFlags.Lookup("disk-usage-high-watermark"))
+	viper.BindPFlag("beerus.diskUsage.lowWatermark", commandFlags.Lookup("disk-usage-low-watermark"))
+	viper.BindPFlag("beerus.diskUsage.checkInterval", commandFlags.Lookup("disk-usage-check-interval"))
+}
+
+// cleanResources loads the configuration file specified in the command line
+// flag --config-file and creates a cleaner, along with its docker client, for
+// each configured docker endpoint, or for the daemon described by the docker
+// settings when none is configured. The cleaners are run by a supervisor
+// with the context created from the command context. The function also sets
+// up a signal handler to cancel the context when a SIGTERM or SIGINT signal
+// is received. When the --once flag is set, a single cleanup pass is
+// performed on every endpoint and a partial failure is reported through the
+// process exit code. Otherwise, the HTTP listener exposing the metrics and
+// health endpoints is started along with the cleaners, when an address is
+// configured. If any error occurs during the cleanup process, the function
+// returns the error.
+func cleanResources(cmd *cobra.Command, _ []string) error {
 	ctx, cancel := context.WithCancel(cmd.Context())
 	stopSignal := make(chan os.Signal, 1)
 
@@ -118,17 +302,230 @@ func cleanResources(cmd *cobra.Command, _ []string) error {
 	}()
 
 	filePath := cmd.Flag("config-file").Value
-	cfg := config.Load(filePath.String())
+	cfg, err := config.Load(filePath.String())
+	if err != nil {
+		return fmt.Errorf("error loading configuration: %w", err)
+	}
 
 	logger, err := logger.Create(cfg.Beerus.Logging)
 	if err != nil {
 		return fmt.Errorf("error creating logger: %w", err)
 	}
 
-	cleaner := cleaner.New(docker.New(cli, logger), cfg.Beerus, logger)
+	endpoints, err := endpoints(cfg.Beerus)
+	if err != nil {
+		return fmt.Errorf("error reading docker endpoints: %w", err)
+	}
+
+	auditLog, err := audit.New(cfg.Beerus.Audit)
+	if err != nil {
+		return fmt.Errorf("error opening audit log: %w", err)
+	}
+	defer auditLog.Close()
+
+	notify, err := notifier.New(cfg.Beerus.Notifications)
+	if err != nil {
+		return fmt.Errorf("error creating notifier: %w", err)
+	}
+
+	// the policies are shared by every endpoint, the engine being safe for
+	// concurrent use
+	rules, err := policy.New(cfg.Beerus.Policies)
+	if err != nil {
+		return fmt.Errorf("error compiling policies: %w", err)
+	}
+
+	calendar, err := schedule.New(cfg.Beerus.Schedule)
+	if err != nil {
+		return fmt.Errorf("error reading schedule: %w", err)
+	}
+
+	m := metrics.New()
+	supervised := make([]supervisor.Endpoint, 0, len(endpoints))
+
+	// the docker clients are closed by their cleaner once every endpoint is
+	// set up, so they are only closed here when the setup of an endpoint fails
+	clients := make([]*client.Client, 0, len(endpoints))
+	defer func() {
+		if len(supervised) == len(endpoints) {
+			return
+		}
+
+		for _, cli := range clients {
+			cli.Close()
+		}
+	}()
+
+	// endpoints are only named in the logs, the reports and the state
+	// directory when they are explicitly configured
+	named := len(cfg.Beerus.Endpoints) > 0
+
+	for _, endpoint := range endpoints {
+		settings, err := cfg.Beerus.ForEndpoint(endpoint)
+		if err != nil {
+			return err
+		}
+
+		names, err := compileNameFilters(settings)
+		if err != nil {
+			return fmt.Errorf("error reading name filters of endpoint %s: %w", endpoint.Name, err)
+		}
+
+		if err := validateDiskWatermarks(settings); err != nil {
+			return fmt.Errorf("error reading disk usage watermarks of endpoint %s: %w", endpoint.Name, err)
+		}
+
+		conn := endpointConnection(cfg.Docker, endpoint)
+
+		runtime, err := docker.ParseRuntime(conn.Runtime)
+		if err != nil {
+			return fmt.Errorf("error reading runtime of endpoint %s: %w", endpoint.Name, err)
+		}
+
+		cli, err := newDockerClient(conn)
+		if err != nil {
+			return fmt.Errorf("error creating docker client api of endpoint %s: %w", endpoint.Name, err)
+		}
+		clients = append(clients, cli)
+
+		endpointLogger, dataDir := logger, settings.DataDir
+		if named {
+			endpointLogger = logger.With("endpoint", endpoint.Name)
+			if dataDir != "" {
+				dataDir = filepath.Join(dataDir, endpoint.Name)
+			}
+		}
+
+		store, err := state.Open(dataDir)
+		if err != nil {
+			return fmt.Errorf("error opening state of endpoint %s: %w", endpoint.Name, err)
+		}
+
+		// the executor is shared by the docker client and the cleaner of the
+		// endpoint, capping the removals and inspections in flight to its daemon
+		exec := executor.New(int(settings.ConcurrencyLevel))
+
+		options := []cleaner.Option{
+			cleaner.WithExecutor(exec),
+			cleaner.WithOutput(cmd.OutOrStdout()),
+			cleaner.WithMetrics(m.WithEndpoint(endpoint.Name)),
+			cleaner.WithState(store),
+			cleaner.WithAudit(auditLog),
+			cleaner.WithNotifier(notify),
+			cleaner.WithPolicy(rules),
+			cleaner.WithSchedule(calendar),
+			cleaner.WithNameMatchers(names),
+		}
+
+		if named {
+			options = append(options, cleaner.WithEndpoint(endpoint.Name))
+		}
+
+		supervised = append(supervised, supervisor.Endpoint{
+			Name: endpoint.Name,
+			Cleaner: cleaner.New(
+				docker.New(cli, endpointLogger,
+					docker.WithExecutor(exec),
+					docker.WithRuntime(runtime),
+					docker.WithRequestTimeout(time.Duration(cfg.Docker.Timeout)*time.Second),
+					docker.WithRetryPolicy(docker.RetryPolicy{
+						MaxAttempts: int(settings.Retry.MaxAttempts),
+						BaseDelay:   time.Duration(settings.Retry.BaseDelay) * time.Millisecond,
+						Jitter:      settings.Retry.Jitter,
+					}),
+				),
+				settings,
+				endpointLogger,
+				options...,
+			),
+		})
+	}
+
+	sv := supervisor.New(logger, supervised...)
+
+	once, err := cmd.Flags().GetBool("once")
+	if err != nil {
+		return err
+	}
+
+	if once {
+		summary, err := sv.RunOnce(ctx)
+		if summary.Failed() > 0 {
+			partialErr := fmt.Errorf("%d of %d removals failed", summary.Failed(), summary.Failed()+summary.Removed())
+			if err != nil {
+				partialErr = fmt.Errorf("%w: %w", partialErr, err)
+			}
+
+			return &exitError{code: exitCodePartialFailure, err: partialErr}
+		}
+
+		if err != nil {
+			return fmt.Errorf("error cleaning resources: %w", err)
+		}
+
+		return nil
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	if address := cfg.Beerus.HTTP.Address; address != "" {
+		g.Go(func() error {
+			return server.New(address, m, sv, logger).Run(ctx)
+		})
+	}
+
+	g.Go(func() error {
+		if err := sv.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			return fmt.Errorf("error cleaning resources: %w", err)
+		}
+		return nil
+	})
+
+	return g.Wait()
+}
+
+// compileNameFilters returns the compiled name filters of the given settings,
+// so a malformed pattern fails at startup and the patterns are not compiled
+// again on every listing.
+func compileNameFilters(settings *config.Beerus) (cleaner.NameMatchers, error) {
+	var names cleaner.NameMatchers
+
+	filters := []struct {
+		name     string
+		patterns config.Patterns
+		matcher  *docker.NameMatcher
+	}{
+		{"images.repositories", settings.Images.Repositories, &names.Repositories},
+		{"images.tags", settings.Images.Tags, &names.Tags},
+		{"containers.names", settings.Containers.Names, &names.Containers},
+	}
+
+	for _, f := range filters {
+		matcher, err := docker.NameFilter(f.patterns).Compile()
+		if err != nil {
+			return cleaner.NameMatchers{}, fmt.Errorf("%s: %w", f.name, err)
+		}
+
+		*f.matcher = matcher
+	}
+
+	return names, nil
+}
+
+// validateDiskWatermarks reports the disk usage watermarks that cannot work
+// together when the disk usage cleanup is enabled, so it fails at startup
+// rather than once the initial sweep is over.
+func validateDiskWatermarks(settings *config.Beerus) error {
+	usage := settings.DiskUsage
+	if !usage.Enabled {
+		return nil
+	}
+
+	if usage.HighWatermark <= 0 {
+		return errors.New("diskUsage.highWatermark: must be set when the disk usage cleanup is enabled")
+	}
 
-	if err := cleaner.Run(ctx); err != nil {
-		return fmt.Errorf("error cleaning resources: %w", err)
+	if usage.LowWatermark > usage.HighWatermark {
+		return errors.New("diskUsage.lowWatermark: must not be greater than the high watermark")
 	}
 
 	return nil
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"log/slog"
 
+	"github.com/lucasmendesl/beerus/selector"
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
@@ -18,19 +21,62 @@ type Logging struct {
 	Format string `mapstructure:"format"`
 }
 
+type HTTP struct {
+	// Address defines the address of the optional HTTP listener, such as ":9090",
+	// exposing the Prometheus metrics on the /metrics endpoint, the liveness on the
+	// /healthz endpoint and the readiness on the /readyz endpoint.
+	// The listener is disabled when the address is empty.
+	Address string `mapstructure:"address"`
+
+	// LivenessThreshold represents the time (in seconds) the event stream may go
+	// without delivering an event nor a heartbeat before the liveness endpoint
+	// reports the application as dead. It should be kept above the one minute cap
+	// of the delay between the reconnection attempts of the event stream.
+	LivenessThreshold uint16 `mapstructure:"livenessThreshold"`
+}
+
+type Patterns struct {
+	// Include contains the patterns selecting the resources considered for removal. Every
+	// resource is considered when it is empty.
+	Include []string `mapstructure:"include"`
+
+	// Exclude contains the patterns of the resources never considered for removal, taking
+	// precedence over Include.
+	Exclude []string `mapstructure:"exclude"`
+}
+
 type Image struct {
 	// LifetimeThreshold represents the threshold in terms of time (in days)
 	// after which images are considered for removal. Images older than this threshold may be cleaned up.
 	LifetimeThreshold uint16 `mapstructure:"lifetimeThreshold"`
 
-	// IgnoreLabels contains a list of image labels that should be ignored during the cleanup process.
-	// Images with any of these labels will not be considered for removal.
-	IgnoreLabels []string `mapstructure:"ignoreLabels"`
+	// IgnoreLabels contains a list of label selectors, such as "beerus.keep" or "env in (prod, staging)",
+	// of the images that should be ignored during the cleanup process. Images matching any of these
+	// selectors will not be considered for removal.
+	IgnoreLabels []selector.Selector `mapstructure:"ignoreLabels"`
+
+	// TargetLabels contains a list of label selectors restricting the cleanup to the images matching
+	// any of them. Every image is considered when it is empty.
+	TargetLabels []selector.Selector `mapstructure:"targetLabels"`
 
 	// ForceRemovalOnConflict is a boolean that, if set to true, will force the
 	// removal of resources when a conflict is detected during the cleanup
 	// process (when one repository have more than one tag).
 	ForceRemovalOnConflict bool `mapstructure:"forceRemovalOnConflict"`
+
+	// KeepLastTags defines how many of the most recent images of each repository
+	// are always kept, even when they are older than the lifetime threshold.
+	// The retention is disabled when it is zero.
+	KeepLastTags uint16 `mapstructure:"keepLastTags"`
+
+	// Repositories selects the images considered for removal by the repositories they are
+	// tagged in, such as "ci/*" or "/^mirror\.local/.*$/". Patterns are shell globs, or
+	// regular expressions when enclosed in slashes. Dangling images are always considered.
+	Repositories Patterns `mapstructure:"repositories"`
+
+	// Tags selects the images considered for removal by their tags, without the repository,
+	// such as "pr-*" or "latest", following the same syntax as Repositories.
+	Tags Patterns `mapstructure:"tags"`
 }
 
 type Container struct {
@@ -40,9 +86,14 @@ type Container struct {
 	// restart loop and will be removed to prevent resource waste (using restart policy always).
 	MaxAlwaysRestartPolicyCount int `mapstructure:"maxAlwaysRestartPolicyCount"`
 
-	// IgnoreLabels contains a list of image labels that should be ignored during the cleanup process.
-	// Images with any of these labels will not be considered for removal.
-	IgnoreLabels []string `mapstructure:"ignoreLabels"`
+	// IgnoreLabels contains a list of label selectors, such as "beerus.keep" or "env!=ci", of the
+	// containers that should be ignored during the cleanup process. Containers matching any of these
+	// selectors will not be considered for removal.
+	IgnoreLabels []selector.Selector `mapstructure:"ignoreLabels"`
+
+	// TargetLabels contains a list of label selectors restricting the cleanup to the containers matching
+	// any of them. Every container is considered when it is empty.
+	TargetLabels []selector.Selector `mapstructure:"targetLabels"`
 
 	// ForceVolumeCleanup is a boolean that, if set to true, will force the removal of volumes associated with
 	// containers that are being removed. This can be useful for cleaning up volumes that are no longer in
@@ -60,13 +111,148 @@ type Container struct {
 	// due to a restart loop, and it is configured to always restart, then the link will be
 	// removed to prevent resource waste.
 	ForceLinkCleanup bool `mapstructure:"forceLinkCleanup"`
+
+	// Names selects the containers considered for removal by their names, without the
+	// leading slash, such as "ci-*" or "/^build-[0-9]+$/". Patterns are shell globs, or
+	// regular expressions when enclosed in slashes.
+	Names Patterns `mapstructure:"names"`
+}
+
+type Volume struct {
+	// Enabled is a boolean that, if set to true, enables the cleanup of volumes.
+	// Volumes may hold data that is meant to outlive containers, so they are
+	// only removed when explicitly requested.
+	Enabled bool `mapstructure:"enabled"`
+
+	// LifetimeThreshold represents the threshold in terms of time (in days)
+	// after which dangling volumes are considered for removal. Volumes not used by any
+	// container and older than this threshold may be cleaned up.
+	LifetimeThreshold uint16 `mapstructure:"lifetimeThreshold"`
+
+	// IgnoreLabels contains a list of label selectors of the volumes that should be ignored during the
+	// cleanup process. Volumes matching any of these selectors will not be considered for removal.
+	IgnoreLabels []selector.Selector `mapstructure:"ignoreLabels"`
+
+	// IncludeNamed is a boolean that, if set to true, makes named volumes eligible for
+	// removal as well. By default, only anonymous volumes, created without an explicit
+	// name, are removed, since named volumes are usually created to persist data.
+	IncludeNamed bool `mapstructure:"includeNamed"`
+}
+
+type Network struct {
+	// Enabled is a boolean that, if set to true, enables the cleanup of user-defined
+	// networks without any attached container. The built-in bridge, host and none
+	// networks are never removed.
+	Enabled bool `mapstructure:"enabled"`
+
+	// LifetimeThreshold represents the threshold in terms of time (in hours)
+	// after which networks without attached containers are considered for removal.
+	LifetimeThreshold uint16 `mapstructure:"lifetimeThreshold"`
+
+	// IgnoreLabels contains a list of label selectors of the networks that should be ignored during the
+	// cleanup process. Networks matching any of these selectors will not be considered for removal.
+	IgnoreLabels []selector.Selector `mapstructure:"ignoreLabels"`
+}
+
+type BuildCache struct {
+	// Enabled is a boolean that, if set to true, enables the periodic pruning of
+	// the BuildKit build cache.
+	Enabled bool `mapstructure:"enabled"`
+
+	// KeepStorage defines the amount of build cache to be kept, using human readable
+	// sizes such as "512MB" or "10GB". Cache records are pruned until the build cache
+	// fits in this budget. When empty, every prunable record is removed.
+	KeepStorage ByteSize `mapstructure:"keepStorage"`
+
+	// LifetimeThreshold represents the threshold in terms of time (in days)
+	// after which build cache records are considered for removal. Records newer
+	// than this threshold are never pruned. Zero means no age restriction.
+	LifetimeThreshold uint16 `mapstructure:"lifetimeThreshold"`
+
+	// IncludeShared is a boolean that, if set to true, also prunes shared and
+	// internal build cache records, such as the ones referenced by frontends.
+	IncludeShared bool `mapstructure:"includeShared"`
+}
+
+type DiskUsage struct {
+	// Enabled is a boolean that, if set to true, enables the periodic check of the
+	// disk space used by the Docker data-root against the watermarks.
+	Enabled bool `mapstructure:"enabled"`
+
+	// HighWatermark defines the disk usage, using human readable sizes such as "80GB",
+	// above which the cleanup is escalated: dangling images first, then expired
+	// images and finally the build cache.
+	HighWatermark ByteSize `mapstructure:"highWatermark"`
+
+	// LowWatermark defines the disk usage, using human readable sizes such as "60GB",
+	// under which the escalation stops. It must not be greater than the high watermark.
+	LowWatermark ByteSize `mapstructure:"lowWatermark"`
+
+	// CheckInterval represents the interval (in minutes) between the disk usage checks.
+	CheckInterval uint16 `mapstructure:"checkInterval"`
+}
+
+type Audit struct {
+	// Path defines the file where the audit trail of every removal and every kept
+	// resource is written as JSON Lines. The audit trail is disabled when it is empty.
+	Path string `mapstructure:"path"`
+
+	// MaxSize defines the size, using human readable sizes such as "100MB", after
+	// which the audit file is rotated. The file is never rotated when it is empty.
+	MaxSize ByteSize `mapstructure:"maxSize"`
+
+	// MaxBackups defines how many rotated audit files are kept.
+	MaxBackups int `mapstructure:"maxBackups"`
+}
+
+type Webhook struct {
+	// URL defines the endpoint receiving the summary of each cleanup cycle through an
+	// HTTP POST request. The webhook is disabled when it is empty.
+	URL string `mapstructure:"url"`
+
+	// Template defines an optional Go text/template rendering the request body from
+	// the cycle summary, such as the payload expected by a chat or incident tool.
+	// When empty, the summary itself is sent as JSON.
+	Template string `mapstructure:"template"`
+
+	// Timeout represents the time limit (in seconds) of each request to the webhook.
+	Timeout uint16 `mapstructure:"timeout"`
+
+	// MaxRetries defines how many times a request failing with a network error or a
+	// server error response is retried, waiting longer between each attempt.
+	MaxRetries uint8 `mapstructure:"maxRetries"`
+}
+
+type Notifications struct {
+	// MinReclaimed defines the amount of reclaimed space, using human readable sizes
+	// such as "1GB", under which the summary of a cycle without any failed removal
+	// is not sent. When empty, the summary of every cycle is sent.
+	MinReclaimed ByteSize `mapstructure:"minReclaimed"`
+
+	// Webhook contains the settings of the generic JSON webhook sink.
+	Webhook Webhook `mapstructure:"webhook"`
+}
+
+type Retry struct {
+	// MaxAttempts defines the maximum number of attempts of a Docker API call failing
+	// with a transient error, such as a conflict or a timeout, including the first one.
+	// The retries are disabled when it is lower than two.
+	MaxAttempts uint8 `mapstructure:"maxAttempts"`
+
+	// BaseDelay represents the delay (in milliseconds) before the first retry, doubled
+	// on every attempt.
+	BaseDelay uint16 `mapstructure:"baseDelay"`
+
+	// Jitter defines the fraction of the delay, between 0 and 1, randomly added to or
+	// removed from it, so concurrent calls do not retry all at once.
+	Jitter float64 `mapstructure:"jitter"`
 }
 
 type Beerus struct {
-	// ConcurrencyLevel defines the maximum number of goroutines that can run in parallel
-	// during the execution of the application. It controls how many items
-	// are processed at the same time. A higher value can lead to faster cleaning but
-	// may also increase the load on the system.
+	// ConcurrencyLevel defines the maximum number of removals and container inspections
+	// in flight at the same time on each endpoint. The listings, prunes, disk usage,
+	// ping and event calls are not capped. A higher value can lead to faster cleaning
+	// but may also increase the load on the daemon. Zero is raised to one.
 	ConcurrencyLevel uint8 `mapstructure:"concurrencyLevel"`
 
 	// ExpirePollCheckInterval specifies the interval in hours between each poll
@@ -76,15 +262,74 @@ type Beerus struct {
 	// but may also mean expired images are removed less quickly.
 	ExpirePollCheckInterval uint8 `mapstructure:"expiringPollCheckInterval"`
 
+	// Schedule specifies when the periodic sweeps run, in place of ExpirePollCheckInterval
+	// when a cron expression is set, and the maintenance windows outside of which the
+	// removals are deferred.
+	Schedule Schedule `mapstructure:"schedule"`
+
+	// DryRun is a boolean that, if set to true, makes the application go through
+	// the whole cleanup process without removing anything. Instead, a plan with
+	// the resources that would have been removed, the rule that matched each one
+	// and their size is printed at the end of each cleanup cycle.
+	DryRun bool `mapstructure:"dryRun"`
+
+	// DataDir defines the directory where the application persists its local state,
+	// such as the deletion history, the first time each image was seen, the last time
+	// each image was used and the last processed event, so it survives restarts and
+	// upgrades. The state is only kept in memory when it is empty.
+	DataDir string `mapstructure:"dataDir"`
+
+	// Retry specifies how the Docker API calls failing with a transient error,
+	// such as removals against a busy daemon, are retried.
+	Retry Retry `mapstructure:"retry"`
+
 	// Logging specifies the logging configuration, including log level and format.
 	Logging Logging `mapstructure:"logging"`
 
+	// Audit specifies the configuration of the audit trail, recording every
+	// decision made about a resource apart from the application logs.
+	Audit Audit `mapstructure:"audit"`
+
+	// Notifications specifies where the summary of each cleanup cycle is sent,
+	// such as a chat or incident tool webhook.
+	Notifications Notifications `mapstructure:"notifications"`
+
+	// HTTP specifies the configuration of the optional HTTP listener exposing
+	// the operational endpoints of the application.
+	HTTP HTTP `mapstructure:"http"`
+
 	// Images contains settings related to Docker image management, such as lifetime thresholds.
 	Images Image `mapstructure:"images"`
 
 	// Containers includes configuration parameters for managing Docker containers,
 	// particularly related to restart policies and removal criteria.
 	Containers Container `mapstructure:"containers"`
+
+	// Volumes contains settings related to Docker volume management, such as
+	// lifetime thresholds and whether named volumes can be removed.
+	Volumes Volume `mapstructure:"volumes"`
+
+	// Networks contains settings related to the cleanup of user-defined networks
+	// left behind without any attached container.
+	Networks Network `mapstructure:"networks"`
+
+	// BuildCache contains settings related to the pruning of the BuildKit build
+	// cache, such as the storage budget and the lifetime threshold.
+	BuildCache BuildCache `mapstructure:"buildCache"`
+
+	// DiskUsage contains settings related to the cleanup triggered by the disk
+	// space used by the Docker data-root, such as the high and low watermarks.
+	DiskUsage DiskUsage `mapstructure:"diskUsage"`
+
+	// Endpoints lists the Docker daemons managed by the application, each one by
+	// an independent cleaner. When empty, the daemon configured by the DOCKER_HOST,
+	// DOCKER_TLS_VERIFY and DOCKER_CERT_PATH environment variables is managed.
+	Endpoints []Endpoint `mapstructure:"endpoints"`
+
+	// Policies lists the rules deciding which containers and images are removed, in
+	// place of the fixed rules of their resource kind. They are evaluated in order,
+	// the first matching one deciding, and the resources matching none are kept.
+	Policies []Policy `mapstructure:"policies"`
 }
 
 // Config represents configuration settings for managing Docker images and containers.
@@ -95,14 +340,32 @@ type Config struct {
 	// over time and to ensure backwards compatibility.
 	Version string `mapstructure:"version"`
 
+	// Docker specifies how the Docker daemon is reached, such as its address, the
+	// API version and the TLS certificates.
+	Docker Docker `mapstructure:"docker"`
+
 	// Beerus holds the configuration settings specific to the Beerus application.
 	// It includes settings, logging, images, and container-related configurations.
 	Beerus *Beerus `mapstructure:"beerus"`
 }
 
+// decodeHook parses the label selectors and the human readable sizes while
+// decoding the settings, along with the durations and the comma separated
+// lists handled by default.
+func decodeHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		selector.DecodeHook(),
+		byteSizeHook(),
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	)
+}
+
 // Load returns a pointer to a Config struct with default values.
 // It is used to load default configuration settings for the application.
-func Load(configFile string) *Config {
+// It returns an error when the settings cannot be decoded, such as a
+// malformed label selector, so they are never silently dropped.
+func Load(configFile string) (*Config, error) {
 	var config Config
 
 	viper.SetConfigType("yaml")
@@ -124,9 +387,9 @@ func Load(configFile string) *Config {
 		slog.Info("Using configuration file", "file", viper.ConfigFileUsed())
 	}
 
-	if err := viper.Unmarshal(&config); err != nil {
-		slog.Error("Failed to unmarshal configuration", "error", err)
+	if err := viper.Unmarshal(&config, viper.DecodeHook(decodeHook())); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal configuration: %w", err)
 	}
 
-	return &config
+	return &config, nil
 }
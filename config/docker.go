@@ -0,0 +1,54 @@
+package config
+
+type TLS struct {
+	// CACert defines the path of the certificate authority used to verify the
+	// certificate of the Docker daemon.
+	CACert string `mapstructure:"caCert"`
+
+	// Cert defines the path of the client certificate presented to the Docker daemon.
+	Cert string `mapstructure:"cert"`
+
+	// Key defines the path of the private key of the client certificate.
+	Key string `mapstructure:"key"`
+
+	// Verify is a boolean that, if set to false, disables the verification of the
+	// certificate of the Docker daemon. The certificate is verified when it is not set.
+	Verify *bool `mapstructure:"verify"`
+}
+
+// Enabled reports whether TLS is used to connect to the Docker daemon, which
+// is the case as soon as a certificate is configured.
+func (t TLS) Enabled() bool {
+	return t.CACert != "" || t.Cert != "" || t.Key != ""
+}
+
+// SkipVerify reports whether the verification of the certificate of the
+// Docker daemon is disabled.
+func (t TLS) SkipVerify() bool {
+	return t.Verify != nil && !*t.Verify
+}
+
+type Docker struct {
+	// Host defines the address of the Docker daemon, such as "unix:///var/run/docker.sock",
+	// "tcp://docker.example.com:2376" or "ssh://beerus@docker.example.com". When it is empty
+	// and TLS is not configured, the daemon is configured by the DOCKER_HOST, DOCKER_API_VERSION,
+	// DOCKER_TLS_VERIFY and DOCKER_CERT_PATH environment variables.
+	Host string `mapstructure:"host"`
+
+	// APIVersion pins the version of the Docker API, such as "1.45". When empty, the version
+	// is negotiated with the daemon.
+	APIVersion string `mapstructure:"apiVersion"`
+
+	// TLS specifies the certificates used to connect to a tcp daemon over TLS, along with
+	// a client certificate for mutual TLS. TLS is disabled when no certificate is configured.
+	TLS TLS `mapstructure:"tls"`
+
+	// Timeout represents the time limit (in seconds) of each request to the Docker API,
+	// apart from the event stream. Zero means no time limit.
+	Timeout uint16 `mapstructure:"timeout"`
+
+	// Runtime defines the container engine serving the Docker API, which is "docker",
+	// "podman" or "auto" to detect it from the version reported by the daemon. Podman
+	// is handled in a compatibility mode adapting to the differences of its API.
+	Runtime string `mapstructure:"runtime"`
+}
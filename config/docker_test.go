@@ -0,0 +1,50 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/lucasmendesl/beerus/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLS(t *testing.T) {
+	var (
+		verify   = true
+		noVerify = false
+	)
+
+	tests := []struct {
+		name           string
+		tls            config.TLS
+		wantEnabled    bool
+		wantSkipVerify bool
+	}{
+		{
+			name: "no certificate",
+			tls:  config.TLS{Verify: &verify},
+		},
+		{
+			name:        "server verification only",
+			tls:         config.TLS{CACert: "/certs/ca.pem"},
+			wantEnabled: true,
+		},
+		{
+			name:        "mutual tls",
+			tls:         config.TLS{CACert: "/certs/ca.pem", Cert: "/certs/cert.pem", Key: "/certs/key.pem", Verify: &verify},
+			wantEnabled: true,
+		},
+		{
+			name:           "verification disabled",
+			tls:            config.TLS{Cert: "/certs/cert.pem", Key: "/certs/key.pem", Verify: &noVerify},
+			wantEnabled:    true,
+			wantSkipVerify: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.wantEnabled, tt.tls.Enabled())
+			require.Equal(t, tt.wantSkipVerify, tt.tls.SkipVerify())
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+type Endpoint struct {
+	// Name identifies the endpoint in the logs, the metrics, the audit trail and
+	// the notifications. It must be unique across the endpoints.
+	Name string `mapstructure:"name"`
+
+	// Host defines the address of the Docker daemon, such as "unix:///var/run/docker.sock",
+	// "tcp://build-01:2376" or "ssh://beerus@build-02". SSH endpoints require the docker
+	// binary on the remote host.
+	Host string `mapstructure:"host"`
+
+	// TLS specifies the certificates used to connect to a tcp endpoint over TLS.
+	// TLS is disabled when no certificate is configured. The API version and the
+	// request timeout of the endpoints are taken from the docker settings.
+	TLS TLS `mapstructure:"tls"`
+
+	// Runtime overrides the container engine serving the Docker API of this endpoint,
+	// which is "docker", "podman" or "auto". When empty, the one of the docker settings
+	// is used.
+	Runtime string `mapstructure:"runtime"`
+
+	// Images overrides the image settings for this endpoint. Only the settings
+	// present are overridden, the other ones are taken from the global image settings.
+	Images map[string]any `mapstructure:"images"`
+
+	// Containers overrides the container settings for this endpoint. Only the settings
+	// present are overridden, the other ones are taken from the global container settings.
+	Containers map[string]any `mapstructure:"containers"`
+}
+
+// ForEndpoint returns a copy of the configuration with the image and container
+// settings overridden by the ones of the given endpoint. It returns an error
+// when the overrides do not match the settings they override.
+func (b *Beerus) ForEndpoint(e Endpoint) (*Beerus, error) {
+	cfg := *b
+
+	if err := decodeOverrides(e.Images, &cfg.Images); err != nil {
+		return nil, fmt.Errorf("invalid image settings of endpoint %q: %w", e.Name, err)
+	}
+
+	if err := decodeOverrides(e.Containers, &cfg.Containers); err != nil {
+		return nil, fmt.Errorf("invalid container settings of endpoint %q: %w", e.Name, err)
+	}
+
+	return &cfg, nil
+}
+
+// decodeOverrides decodes the given settings onto the target, leaving the
+// fields that are not present untouched. The overridden lists are replaced
+// instead of being merged into the global ones.
+func decodeOverrides(overrides map[string]any, target any) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           target,
+		DecodeHook:       decodeHook(),
+		WeaklyTypedInput: true,
+		ZeroFields:       true,
+		ErrorUnused:      true,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return decoder.Decode(overrides)
+}
@@ -0,0 +1,118 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/lucasmendesl/beerus/config"
+	"github.com/lucasmendesl/beerus/selector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBeerus_ForEndpoint(t *testing.T) {
+	global := &config.Beerus{
+		ConcurrencyLevel: 5,
+		Images: config.Image{
+			LifetimeThreshold: 100,
+			IgnoreLabels:      []selector.Selector{selector.MustParse("keep")},
+			KeepLastTags:      2,
+			Repositories:      config.Patterns{Include: []string{"ci/*"}},
+		},
+		Containers: config.Container{
+			MaxAlwaysRestartPolicyCount: 3,
+			IgnoreLabels:                []selector.Selector{selector.MustParse("keep")},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		endpoint config.Endpoint
+		want     func(cfg *config.Beerus)
+		wantErr  string
+	}{
+		{
+			name:     "no overrides",
+			endpoint: config.Endpoint{Name: "build-01"},
+			want:     func(*config.Beerus) {},
+		},
+		{
+			name: "partial overrides",
+			endpoint: config.Endpoint{
+				Name: "build-01",
+				Images: map[string]any{
+					"lifetimethreshold": 7,
+					"ignoreLabels":      []any{"release", "base"},
+				},
+				Containers: map[string]any{
+					"forceVolumeCleanup": true,
+				},
+			},
+			want: func(cfg *config.Beerus) {
+				cfg.Images.LifetimeThreshold = 7
+				cfg.Images.IgnoreLabels = []selector.Selector{selector.MustParse("release"), selector.MustParse("base")}
+				cfg.Containers.ForceVolumeCleanup = true
+			},
+		},
+		{
+			name: "weakly typed override",
+			endpoint: config.Endpoint{
+				Name:   "build-01",
+				Images: map[string]any{"keepLastTags": "5"},
+			},
+			want: func(cfg *config.Beerus) {
+				cfg.Images.KeepLastTags = 5
+			},
+		},
+		{
+			name: "nested override",
+			endpoint: config.Endpoint{
+				Name: "build-01",
+				Images: map[string]any{
+					"repositories": map[string]any{"exclude": []any{"ci/base"}},
+				},
+			},
+			want: func(cfg *config.Beerus) {
+				cfg.Images.Repositories = config.Patterns{
+					Include: []string{"ci/*"},
+					Exclude: []string{"ci/base"},
+				}
+			},
+		},
+		{
+			name: "unknown setting",
+			endpoint: config.Endpoint{
+				Name:   "build-01",
+				Images: map[string]any{"lifetime": 7},
+			},
+			wantErr: `invalid image settings of endpoint "build-01"`,
+		},
+		{
+			name: "invalid value",
+			endpoint: config.Endpoint{
+				Name:       "build-02",
+				Containers: map[string]any{"maxAlwaysRestartPolicyCount": "often"},
+			},
+			wantErr: `invalid container settings of endpoint "build-02"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := global.ForEndpoint(tt.endpoint)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+
+			want := *global
+			tt.want(&want)
+			require.Equal(t, &want, cfg)
+
+			// the global settings are left untouched
+			require.Equal(t, uint16(100), global.Images.LifetimeThreshold)
+			require.Equal(t, []selector.Selector{selector.MustParse("keep")}, global.Images.IgnoreLabels)
+			require.False(t, global.Containers.ForceVolumeCleanup)
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package config
+
+type Policy struct {
+	// Name identifies the policy in the logs, the metrics, the audit trail and the
+	// dry-run plan, where the resources it selects are reported under the
+	// "policy:<name>" rule. It must be unique across the policies.
+	Name string `mapstructure:"name"`
+
+	// Resource defines the kind of resource the policy applies to, which is either
+	// "container" or "image".
+	Resource string `mapstructure:"resource"`
+
+	// Expression is the boolean expression evaluated over each resource, such as
+	// `status == "exited" && glob("ci/*", repository) && exitCode == 0 && age > duration("30m")`.
+	Expression string `mapstructure:"expression"`
+
+	// Action defines what happens to the resources matching the expression, which is
+	// either "remove" or "keep". It defaults to "remove".
+	Action string `mapstructure:"action"`
+}
@@ -0,0 +1,40 @@
+package config
+
+type Schedule struct {
+	// Cron is a cron expression scheduling the periodic sweeps in place of the
+	// ExpirePollCheckInterval, such as "0 3 * * *" or "@daily". The standard five
+	// fields are supported, along with the descriptors such as "@every 6h".
+	Cron string `mapstructure:"cron"`
+
+	// TimeZone is the IANA name of the time zone of the cron expression and of the
+	// maintenance windows, such as "Europe/Paris". The local time zone is used when
+	// it is empty.
+	TimeZone string `mapstructure:"timeZone"`
+
+	// MaintenanceWindows lists the periods when the resources may be removed. Outside
+	// of them, the sweeps, the disk usage escalations and, unless EventsBypassWindows
+	// is set, the removals triggered by the events are deferred. The resources may be
+	// removed at any time when it is empty.
+	MaintenanceWindows []MaintenanceWindow `mapstructure:"maintenanceWindows"`
+
+	// EventsBypassWindows is a boolean that, if set to true, lets the removals triggered
+	// by the container exit and image untag events happen outside of the maintenance
+	// windows. Otherwise, the resources are left to the first sweep of the next window.
+	EventsBypassWindows bool `mapstructure:"eventsBypassWindows"`
+}
+
+type MaintenanceWindow struct {
+	// Days lists the days of the week the window opens, such as "mon" or "sat", or
+	// ranges of days, such as "mon-fri". The window opens every day when it is empty.
+	Days []string `mapstructure:"days"`
+
+	// Start is the time of the day the window opens, such as "22:00".
+	Start string `mapstructure:"start"`
+
+	// End is the time of the day the window closes, such as "06:00". A window ending
+	// before its start closes on the next day, and "24:00" closes it at midnight.
+	End string `mapstructure:"end"`
+
+	// TimeZone overrides the time zone of the schedule for this window.
+	TimeZone string `mapstructure:"timeZone"`
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/docker/go-units"
+	"github.com/mitchellh/mapstructure"
+)
+
+// ByteSize is an amount of bytes, read from a human readable size such as
+// "512MB" or "10GB". An empty size is zero.
+type ByteSize int64
+
+// byteSizeHook returns a mapstructure decode hook parsing the strings decoded
+// into a ByteSize, so a malformed size fails the loading of the settings
+// instead of every cycle using it.
+func byteSizeHook() mapstructure.DecodeHookFuncType {
+	return func(from, to reflect.Type, data any) (any, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf(ByteSize(0)) {
+			return data, nil
+		}
+
+		text := strings.TrimSpace(data.(string))
+		if text == "" {
+			return ByteSize(0), nil
+		}
+
+		size, err := units.RAMInBytes(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid size %q: %w", text, err)
+		}
+
+		return ByteSize(size), nil
+	}
+}
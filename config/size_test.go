@@ -0,0 +1,89 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lucasmendesl/beerus/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_ByteSize(t *testing.T) {
+	tests := []struct {
+		name        string
+		keepStorage string
+		want        config.ByteSize
+		wantErr     string
+	}{
+		{name: "human readable size", keepStorage: `"10GB"`, want: 10 << 30},
+		{name: "empty size", keepStorage: `""`, want: 0},
+		{name: "malformed size", keepStorage: `"10 gigs"`, wantErr: `invalid size "10 gigs"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "beerus.yaml")
+			data := "beerus:\n  buildCache:\n    keepStorage: " + tt.keepStorage + "\n"
+			require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+
+			cfg, err := config.Load(path)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, cfg.Beerus.BuildCache.KeepStorage)
+		})
+	}
+}
+
+func TestLoad_DiskWatermarks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "beerus.yaml")
+	data := "beerus:\n  diskUsage:\n    highWatermark: \"80GB\"\n    lowWatermark: \"60GB\"\n"
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	require.Equal(t, config.ByteSize(80<<30), cfg.Beerus.DiskUsage.HighWatermark)
+	require.Equal(t, config.ByteSize(60<<30), cfg.Beerus.DiskUsage.LowWatermark)
+
+	data = "beerus:\n  diskUsage:\n    highWatermark: \"80 gigs\"\n"
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+
+	_, err = config.Load(path)
+	require.ErrorContains(t, err, `invalid size "80 gigs"`)
+}
+
+func TestLoad_AuditMaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "beerus.yaml")
+	data := "beerus:\n  audit:\n    maxSize: \"100MB\"\n"
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	require.Equal(t, config.ByteSize(100<<20), cfg.Beerus.Audit.MaxSize)
+
+	data = "beerus:\n  audit:\n    maxSize: \"huge\"\n"
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+
+	_, err = config.Load(path)
+	require.ErrorContains(t, err, `invalid size "huge"`)
+}
+
+func TestLoad_NotificationsMinReclaimed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "beerus.yaml")
+	data := "beerus:\n  notifications:\n    minReclaimed: \"1GB\"\n"
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	require.Equal(t, config.ByteSize(1<<30), cfg.Beerus.Notifications.MinReclaimed)
+
+	data = "beerus:\n  notifications:\n    minReclaimed: \"lots\"\n"
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+
+	_, err = config.Load(path)
+	require.ErrorContains(t, err, `invalid size "lots"`)
+}
@@ -0,0 +1,122 @@
+package docker
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+const untilFilter = "until"
+
+// PruneBuildCache removes BuildKit build cache records through the build
+// cache prune endpoint of the Docker Engine. Records are pruned until the
+// cache fits in the given storage budget, and only records older than the
+// lifetime threshold are considered, when one is provided.
+//
+// Parameters:
+//   - ctx: The context for managing request lifetime and cancellation.
+//   - options: A BuildCachePruneOptions struct containing the storage budget,
+//     the lifetime threshold in days and whether shared and internal records
+//     should be pruned as well.
+//
+// Returns:
+//   - A BuildCachePruneReport with the deleted records and reclaimed bytes.
+//   - An error if there is an issue pruning the build cache.
+func (d *dockerClient) PruneBuildCache(ctx context.Context, options BuildCachePruneOptions) (BuildCachePruneReport, error) {
+	pruneFilters := filters.NewArgs()
+	if options.LifetimeThresholdInDays > 0 {
+		pruneFilters.Add(untilFilter, fmt.Sprintf("%dh", int(options.LifetimeThresholdInDays)*dayInHours))
+	}
+
+	report, err := d.cli.BuildCachePrune(ctx, types.BuildCachePruneOptions{
+		All:         options.IncludeShared,
+		KeepStorage: options.KeepStorage,
+		Filters:     pruneFilters,
+	})
+
+	if err != nil {
+		return BuildCachePruneReport{}, fmt.Errorf("pruning build cache error: %w", err)
+	}
+
+	return BuildCachePruneReport{
+		CachesDeleted:  report.CachesDeleted,
+		SpaceReclaimed: report.SpaceReclaimed,
+	}, nil
+}
+
+// PlanBuildCachePrune returns the BuildKit build cache records a prune with
+// the given options would delete, and the bytes it would reclaim, without
+// deleting anything. It is an estimate computed from the records reported by
+// the disk usage endpoint, following the rules of the prune: the records in
+// use are kept, along with the shared ones unless IncludeShared is set and
+// the ones newer than the lifetime threshold, and the others are deleted from
+// the least recently used until the cache fits in the storage budget.
+//
+// Parameters:
+//   - ctx: The context for managing request lifetime and cancellation.
+//   - options: A BuildCachePruneOptions struct containing the storage budget,
+//     the lifetime threshold in days and whether shared and internal records
+//     would be pruned as well.
+//
+// Returns:
+//   - A BuildCachePruneReport with the records and bytes the prune would delete.
+//   - An error if there is an issue retrieving the build cache records.
+func (d *dockerClient) PlanBuildCachePrune(ctx context.Context, options BuildCachePruneOptions) (BuildCachePruneReport, error) {
+	du, err := d.cli.DiskUsage(ctx, types.DiskUsageOptions{
+		Types: []types.DiskUsageObject{types.BuildCacheObject},
+	})
+	if err != nil {
+		return BuildCachePruneReport{}, fmt.Errorf("build cache disk usage error: %w", err)
+	}
+
+	var (
+		total      int64
+		candidates []*types.BuildCache
+	)
+
+	for _, record := range du.BuildCache {
+		total += record.Size
+
+		if record.InUse || (record.Shared && !options.IncludeShared) {
+			continue
+		}
+
+		if !isExpired(lastUsedRecordAt(record), options.LifetimeThresholdInDays) {
+			continue
+		}
+
+		candidates = append(candidates, record)
+	}
+
+	slices.SortStableFunc(candidates, func(a, b *types.BuildCache) int {
+		return cmp.Compare(lastUsedRecordAt(a).UnixNano(), lastUsedRecordAt(b).UnixNano())
+	})
+
+	var report BuildCachePruneReport
+	for _, record := range candidates {
+		if options.KeepStorage > 0 && total <= options.KeepStorage {
+			break
+		}
+
+		total -= record.Size
+		report.CachesDeleted = append(report.CachesDeleted, record.ID)
+		report.SpaceReclaimed += uint64(max(record.Size, 0))
+	}
+
+	return report, nil
+}
+
+// lastUsedRecordAt returns the last time the given build cache record was
+// used, or its creation time when it was never used.
+func lastUsedRecordAt(record *types.BuildCache) time.Time {
+	if record.LastUsedAt != nil {
+		return *record.LastUsedAt
+	}
+
+	return record.CreatedAt
+}
@@ -0,0 +1,173 @@
+package docker_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/lucasmendesl/beerus/docker"
+	mock "github.com/lucasmendesl/beerus/docker/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDockerClient_PruneBuildCache(t *testing.T) {
+	var (
+		ctrl         = gomock.NewController(t)
+		dockerClient = mock.NewMockClient(ctrl)
+		logger       = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		pruneError = errors.New("prune error")
+	)
+	type args struct {
+		ctx     context.Context
+		options docker.BuildCachePruneOptions
+	}
+	tests := []struct {
+		name      string
+		args      args
+		mockSetup func()
+		expected  docker.BuildCachePruneReport
+		wantErr   wantErr
+	}{
+		{
+			name: "error on prune",
+			args: args{
+				ctx:     context.Background(),
+				options: docker.BuildCachePruneOptions{},
+			},
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					BuildCachePrune(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return(nil, pruneError).
+					Times(1)
+			},
+			wantErr: func(t *testing.T, err error) bool {
+				require.EqualError(t, err, "pruning build cache error: prune error")
+				return true
+			},
+		},
+		{
+			name: "prune with storage budget and lifetime threshold",
+			args: args{
+				ctx: context.Background(),
+				options: docker.BuildCachePruneOptions{
+					KeepStorage:             1024,
+					LifetimeThresholdInDays: 2,
+					IncludeShared:           true,
+				},
+			},
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					BuildCachePrune(
+						gomock.Any(),
+						types.BuildCachePruneOptions{
+							All:         true,
+							KeepStorage: 1024,
+							Filters:     filters.NewArgs(filters.Arg("until", "48h")),
+						},
+					).
+					Return(&types.BuildCachePruneReport{
+						CachesDeleted:  []string{"m0x2s8kqlvzc", "p3yd7w1nfhtb"},
+						SpaceReclaimed: 4096,
+					}, nil).
+					Times(1)
+			},
+			wantErr: nopErr,
+			expected: docker.BuildCachePruneReport{
+				CachesDeleted:  []string{"m0x2s8kqlvzc", "p3yd7w1nfhtb"},
+				SpaceReclaimed: 4096,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			d := docker.New(dockerClient, logger)
+
+			got, err := d.PruneBuildCache(tt.args.ctx, tt.args.options)
+			if tt.wantErr(t, err) {
+				return
+			}
+
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestDockerClient_PlanBuildCachePrune(t *testing.T) {
+	var (
+		ctrl         = gomock.NewController(t)
+		dockerClient = mock.NewMockClient(ctrl)
+		logger       = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		now      = time.Now()
+		lastWeek = now.Add(-7 * 24 * time.Hour)
+		lastYear = now.Add(-365 * 24 * time.Hour)
+	)
+
+	records := []*types.BuildCache{
+		{ID: "recent", Size: 1000, CreatedAt: lastYear, LastUsedAt: &now},
+		{ID: "in-use", Size: 1000, CreatedAt: lastYear, InUse: true},
+		{ID: "shared", Size: 1000, CreatedAt: lastYear, Shared: true},
+		{ID: "last-week", Size: 1000, CreatedAt: lastYear, LastUsedAt: &lastWeek},
+		{ID: "last-year", Size: 1000, CreatedAt: lastYear},
+	}
+
+	tests := []struct {
+		name     string
+		options  docker.BuildCachePruneOptions
+		expected docker.BuildCachePruneReport
+	}{
+		{
+			name: "every prunable record",
+			expected: docker.BuildCachePruneReport{
+				CachesDeleted:  []string{"last-year", "last-week", "recent"},
+				SpaceReclaimed: 3000,
+			},
+		},
+		{
+			name:    "least recently used records until the cache fits in the budget",
+			options: docker.BuildCachePruneOptions{KeepStorage: 3500},
+			expected: docker.BuildCachePruneReport{
+				CachesDeleted:  []string{"last-year", "last-week"},
+				SpaceReclaimed: 2000,
+			},
+		},
+		{
+			name:    "records older than the lifetime threshold, shared ones included",
+			options: docker.BuildCachePruneOptions{LifetimeThresholdInDays: 2, IncludeShared: true},
+			expected: docker.BuildCachePruneReport{
+				CachesDeleted:  []string{"shared", "last-year", "last-week"},
+				SpaceReclaimed: 3000,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dockerClient.
+				EXPECT().
+				DiskUsage(
+					gomock.Any(),
+					types.DiskUsageOptions{Types: []types.DiskUsageObject{types.BuildCacheObject}},
+				).
+				Return(types.DiskUsage{BuildCache: records}, nil).
+				Times(1)
+
+			got, err := docker.New(dockerClient, logger).PlanBuildCachePrune(context.Background(), tt.options)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -4,35 +4,74 @@ package docker
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"sync"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/lucasmendesl/beerus/executor"
 )
 
 type Client interface {
+	ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error)
 	ImageList(ctx context.Context, options image.ListOptions) ([]image.Summary, error)
 	ImageRemove(ctx context.Context, imageID string, options image.RemoveOptions) ([]image.DeleteResponse, error)
 	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	ContainerInspectWithRaw(ctx context.Context, containerID string, getSize bool) (types.ContainerJSON, []byte, error)
 	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
 	ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error)
 	Events(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error)
+	VolumeList(ctx context.Context, options volume.ListOptions) (volume.ListResponse, error)
+	VolumeRemove(ctx context.Context, volumeID string, force bool) error
+	NetworkList(ctx context.Context, options network.ListOptions) ([]network.Summary, error)
+	NetworkInspect(ctx context.Context, networkID string, options network.InspectOptions) (network.Inspect, error)
+	NetworkRemove(ctx context.Context, networkID string) error
+	BuildCachePrune(ctx context.Context, opts types.BuildCachePruneOptions) (*types.BuildCachePruneReport, error)
+	DiskUsage(ctx context.Context, options types.DiskUsageOptions) (types.DiskUsage, error)
 
 	Ping(ctx context.Context) (types.Ping, error)
+	ServerVersion(ctx context.Context) (types.Version, error)
 	Close() error
 }
 
 type dockerClient struct {
-	cli Client
-	log *slog.Logger
+	cli   Client
+	log   *slog.Logger
+	exec  *executor.Executor
+	retry RetryPolicy
+
+	runtimeMu sync.Mutex
+	runtime   Runtime
+}
+
+// Option configures optional behavior of the Docker client.
+type Option func(*dockerClient)
+
+// WithExecutor sets the executor capping the number of container inspections
+// made concurrently by ListContainers. It should be shared with the cleaner
+// of the same endpoint, so its removals share the cap. By default, the
+// inspections are made one at a time.
+func WithExecutor(e *executor.Executor) Option {
+	return func(d *dockerClient) {
+		d.exec = e
+	}
 }
 
 // New returns a new Client instance that can be used to interact with the Docker
 // engine.
-func New(cli Client, logger *slog.Logger) BeerusContainerAPI {
-	return &dockerClient{cli: cli, log: logger}
+func New(cli Client, logger *slog.Logger, options ...Option) BeerusContainerAPI {
+	d := &dockerClient{cli: cli, log: logger, exec: executor.New(1), runtime: RuntimeDocker}
+
+	for _, option := range options {
+		option(d)
+	}
+
+	return d
 }
 
 // Close closes the Docker client connection, releasing any resources that
@@ -41,3 +80,13 @@ func New(cli Client, logger *slog.Logger) BeerusContainerAPI {
 func (d *dockerClient) Close() error {
 	return d.cli.Close()
 }
+
+// Ping checks whether the Docker daemon is reachable, returning an error if
+// it does not answer.
+func (d *dockerClient) Ping(ctx context.Context) error {
+	if _, err := d.cli.Ping(ctx); err != nil {
+		return fmt.Errorf("pinging docker daemon error: %w", err)
+	}
+
+	return nil
+}
@@ -3,12 +3,13 @@ package docker
 import (
 	"context"
 	"fmt"
-	"sync"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/lucasmendesl/beerus/selector"
 )
 
 const statusFilter = "status"
@@ -26,49 +27,98 @@ func WithContainerStatus(status ...ContainerStatus) ListContainersOptions {
 
 // WithContainerLabel filters containers by label when calling ListContainers.
 // It returns a ListContainersOptions that sets the Label field of the
-// ListContainersParams struct. It takes a variable number of arguments of type
-// string, allowing multiple labels to be passed to filter containers by
-// multiple labels.
-func WithContainerLabel(label ...string) ListContainersOptions {
+// ListContainersParams struct. It takes a variable number of label selectors,
+// leaving out the containers matching any of them.
+func WithContainerLabel(selectors ...selector.Selector) ListContainersOptions {
+	return func(o *ListContainersParams) {
+		o.Label = selectors
+	}
+}
+
+// WithContainerTargetLabel restricts the containers returned by ListContainers
+// to the ones matching any of the given label selectors. Every container is
+// returned when there is none.
+func WithContainerTargetLabel(selectors ...selector.Selector) ListContainersOptions {
+	return func(o *ListContainersParams) {
+		o.TargetLabel = selectors
+	}
+}
+
+// WithContainerNames filters containers by name when calling ListContainers,
+// leaving out the containers not selected by the given matcher. The names are
+// matched without their leading slash.
+func WithContainerNames(matcher NameMatcher) ListContainersOptions {
 	return func(o *ListContainersParams) {
-		o.Label = label
+		o.Names = matcher
+	}
+}
+
+// WithContainerSize requests the size of the writable layer of each container
+// when calling ListContainers. Computing the size is expensive for the daemon,
+// so it should only be requested when the size is actually reported.
+func WithContainerSize() ListContainersOptions {
+	return func(o *ListContainersParams) {
+		o.Size = true
 	}
 }
 
 // ListContainers retrieves a list of Docker containers based on their status.
-// It creates concurrent goroutines to inspect each container and fetch its
-// details. The function filters containers by status and returns a slice of
-// Container objects containing their IDs, images, labels, creation time, and
-// current status.
+// It inspects each container to fetch its details, running the inspections
+// on the executor of the client, so the number of inspections in flight never
+// exceeds its limit. The function filters containers by status and returns a
+// slice of Container objects containing their IDs, images, labels, creation
+// time, and current status, in the order they were listed by the daemon.
+// Transient failures of the listing and of the inspections are retried
+// following the retry policy of the client. On Podman, the statuses that do
+// not exist are left out of the filter and the Podman specific states are
+// mapped to the Docker ones. The containers matching an ignored label
+// selector, matching none of the target label selectors, or whose names are
+// not selected by the name filter, are left out before being inspected.
 //
 // Parameters:
 // - ctx: The context for managing request lifetime and cancellation.
-// - concurrency: The number of concurrent goroutines to use for inspecting containers.
-// - status: Variadic parameter specifying one or more ContainerStatus values to filter the containers.
+// - options: Variadic parameter filtering the containers, such as by status or label.
 //
 // Returns:
 // - A slice of Container objects with details of each container.
 // - An error if there is an issue fetching or inspecting the containers.
-func (d *dockerClient) ListContainers(ctx context.Context, concurrency uint8, options ...ListContainersOptions) ([]Container, error) {
+func (d *dockerClient) ListContainers(ctx context.Context, options ...ListContainersOptions) ([]Container, error) {
 	listContainerParam := &ListContainersParams{
 		Status: []ContainerStatus{},
-		Label:  []string{},
+		Label:  []selector.Selector{},
 	}
 	for _, option := range options {
 		option(listContainerParam)
 	}
 
+	runtime, err := d.resolveRuntime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := statusFilters(runtime, listContainerParam.Status)
+	if len(listContainerParam.Status) > 0 && len(statuses) == 0 {
+		// none of the statuses exist on the runtime, and listing without
+		// any status filter would return every container
+		return []Container{}, nil
+	}
+
 	containerFilters := filters.NewArgs()
-	for _, s := range listContainerParam.Status {
-		containerFilters.Add(statusFilter, string(s))
+	for _, s := range statuses {
+		containerFilters.Add(statusFilter, s)
 	}
 
 	listOptionsParams := container.ListOptions{
 		All:     true,
+		Size:    listContainerParam.Size,
 		Filters: containerFilters,
 	}
 
-	containers, err := d.cli.ContainerList(ctx, listOptionsParams)
+	var containers []types.Container
+	err = d.withRetry(ctx, "list containers", func() (err error) {
+		containers, err = d.cli.ContainerList(ctx, listOptionsParams)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("fetching containers error: %w", err)
 	}
@@ -78,80 +128,168 @@ func (d *dockerClient) ListContainers(ctx context.Context, concurrency uint8, op
 		return []Container{}, nil
 	}
 
-	var wg sync.WaitGroup
-
-	containerCh := make(chan Container, concurrency)
-	containerList := make([]Container, 0, containersLen)
-	filteredContainers := make([]Container, 0)
+	filteredContainers := make([]Container, 0, containersLen)
 
 	for _, c := range containers {
+		ttl, err := ParseTTL(c.Labels)
+		if err != nil {
+			d.log.Warn("Ignoring container ttl, falling back to the global rules", "id", c.ID, "error", err)
+		}
+
 		filteredContainers = append(filteredContainers, Container{
 			ID:        c.ID,
+			Names:     c.Names,
 			Image:     c.Image,
 			ImageID:   c.ImageID,
 			Labels:    c.Labels,
 			CreatedAt: time.Unix(c.Created, 0),
-			Status:    ContainerStatus(c.Status),
+			Status:    containerStatus(runtime, c.State),
+			Size:      c.SizeRw,
+			TTL:       ttl,
 		})
 	}
 
 	filteredContainers = removeIgnored(filteredContainers, listContainerParam.Label...)
-	for _, c := range filteredContainers {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			details, err := d.Inspect(ctx, c.ID)
+	filteredContainers = removeUntargeted(filteredContainers, listContainerParam.TargetLabel...)
+
+	filteredContainers = removeUnselected(filteredContainers, listContainerParam.Names, Container.names)
+
+	// each inspection writes to its own index, so the containers keep the
+	// order of the list, and the ones that could not be inspected are left
+	// out afterwards
+	inspected := make([]bool, len(filteredContainers))
+	g, gctx := d.exec.Group(ctx)
+
+	for i, c := range filteredContainers {
+		g.Go(func() error {
+			details, err := d.inspect(gctx, c.ID, false)
 			if err != nil {
 				d.log.Error("Failed to inspect container", "error", err, "id", c.ID)
-				return
+				return nil
 			}
 
-			containerCh <- Container{
-				ID:            c.ID,
-				Image:         c.Image,
-				ImageID:       c.ImageID,
-				Labels:        c.Labels,
-				CreatedAt:     c.CreatedAt,
-				Status:        c.Status,
-				RestartCount:  details.RestartCount,
-				RestartPolicy: details.HostConfig.RestartPolicy,
+			if details.ContainerJSONBase != nil && details.State != nil {
+				filteredContainers[i].ExitCode = details.State.ExitCode
 			}
-		}()
-	}
-
-	// putting waitgroup to wait in a separate goroutine
-	// so we don't await all events to be processed to read the channel
-	go func() {
-		wg.Wait()
-		close(containerCh)
-	}()
-
-	for container := range containerCh {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-			containerList = append(containerList, container)
+
+			filteredContainers[i].RestartCount = details.RestartCount
+			filteredContainers[i].RestartPolicy = details.HostConfig.RestartPolicy
+			inspected[i] = true
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	containerList := make([]Container, 0, len(filteredContainers))
+	for i, c := range filteredContainers {
+		if inspected[i] {
+			containerList = append(containerList, c)
 		}
 	}
 
 	return containerList, nil
 }
 
-// Inspect retrieves detailed information about a Docker container by its ID.
+// Inspect retrieves a Docker container by its ID, with the details the
+// listing of the containers reports, such as its restart policy and the TTL
+// declared by the TTLLabel. The status is mapped to the Docker one on
+// Podman, as done by ListContainers. The size of the container is only
+// computed by the daemon when requested, as it is costly. Transient failures
+// are retried following the retry policy of the client.
 //
 // Parameters:
 //   - ctx: The context for managing request lifetime and cancellation.
 //   - containerID: The ID of the container to be inspected.
+//   - size: Whether the size of the container is computed.
 //
 // Returns:
-//   - A types.ContainerJSON object containing detailed information about the container.
+//   - The inspected container.
 //   - An error if there is an issue retrieving the container information.
-func (d *dockerClient) Inspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
-	return d.cli.ContainerInspect(ctx, containerID)
+func (d *dockerClient) Inspect(ctx context.Context, containerID string, size bool) (Container, error) {
+	runtime, err := d.resolveRuntime(ctx)
+	if err != nil {
+		return Container{}, err
+	}
+
+	details, err := d.inspect(ctx, containerID, size)
+	if err != nil {
+		return Container{}, err
+	}
+
+	ctr := Container{
+		ID:           details.ID,
+		Names:        []string{details.Name},
+		ImageID:      details.Image,
+		RestartCount: details.RestartCount,
+	}
+
+	if details.HostConfig != nil {
+		ctr.RestartPolicy = details.HostConfig.RestartPolicy
+	}
+
+	if details.State != nil {
+		ctr.Status = containerStatus(runtime, details.State.Status)
+		ctr.ExitCode = details.State.ExitCode
+	}
+
+	if details.SizeRw != nil {
+		ctr.Size = *details.SizeRw
+	}
+
+	if details.Config != nil {
+		ttl, err := ParseTTL(details.Config.Labels)
+		if err != nil {
+			d.log.Warn("Ignoring container ttl, falling back to the global rules", "id", details.ID, "error", err)
+		}
+
+		ctr.Image = details.Config.Image
+		ctr.Labels = details.Config.Labels
+		ctr.TTL = ttl
+	}
+
+	if createdAt, err := time.Parse(time.RFC3339Nano, details.Created); err == nil {
+		ctr.CreatedAt = createdAt
+	}
+
+	return ctr, nil
+}
+
+// inspect retrieves the raw details of a Docker container by its ID, along
+// with its size when requested, retrying the transient failures.
+func (d *dockerClient) inspect(ctx context.Context, containerID string, size bool) (types.ContainerJSON, error) {
+	var details types.ContainerJSON
+	err := d.withRetry(ctx, "inspect container", func() (err error) {
+		if size {
+			details, _, err = d.cli.ContainerInspectWithRaw(ctx, containerID, true)
+			return err
+		}
+
+		details, err = d.cli.ContainerInspect(ctx, containerID)
+		return err
+	})
+
+	return details, err
+}
+
+// names returns the names of the container without their leading slash.
+func (c Container) names() []string {
+	names := make([]string, 0, len(c.Names))
+	for _, name := range c.Names {
+		names = append(names, strings.TrimPrefix(name, "/"))
+	}
+
+	return names
 }
 
 // RemoveContainer removes a Docker container by its ID.
+// Transient failures are retried following the retry policy of the client.
 //
 // Parameters:
 //   - ctx: The context for managing request lifetime and cancellation.
@@ -162,9 +300,11 @@ func (d *dockerClient) Inspect(ctx context.Context, containerID string) (types.C
 // Returns:
 //   - An error if there is an issue removing the container.
 func (d *dockerClient) RemoveContainer(ctx context.Context, options RemoveContainerOptions) error {
-	return d.cli.ContainerRemove(ctx, options.ContainerID, container.RemoveOptions{
-		RemoveVolumes: options.RemoveVolumes,
-		RemoveLinks:   options.RemoveLinks,
+	return d.withRetry(ctx, "remove container", func() error {
+		return d.cli.ContainerRemove(ctx, options.ContainerID, container.RemoveOptions{
+			RemoveVolumes: options.RemoveVolumes,
+			RemoveLinks:   options.RemoveLinks,
+		})
 	})
 }
 
@@ -3,8 +3,10 @@ package docker_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -12,6 +14,8 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/lucasmendesl/beerus/docker"
 	mock "github.com/lucasmendesl/beerus/docker/mocks"
+	"github.com/lucasmendesl/beerus/executor"
+	"github.com/lucasmendesl/beerus/selector"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
@@ -34,9 +38,8 @@ func TestDockerClient_ListContainers(t *testing.T) {
 		containerNotFoundError = errors.New("container not found")
 	)
 	type args struct {
-		ctx         context.Context
-		concurrency uint8
-		options     []docker.ListContainersOptions
+		ctx     context.Context
+		options []docker.ListContainersOptions
 	}
 	tests := []struct {
 		name      string
@@ -48,9 +51,8 @@ func TestDockerClient_ListContainers(t *testing.T) {
 		{
 			name: "list container error",
 			args: args{
-				ctx:         context.Background(),
-				concurrency: 1,
-				options:     []docker.ListContainersOptions{},
+				ctx:     context.Background(),
+				options: []docker.ListContainersOptions{},
 			},
 			mockSetup: func() {
 				dockerClient.
@@ -70,9 +72,8 @@ func TestDockerClient_ListContainers(t *testing.T) {
 		{
 			name: "ignore inspect container error",
 			args: args{
-				ctx:         context.Background(),
-				concurrency: 1,
-				options:     []docker.ListContainersOptions{},
+				ctx:     context.Background(),
+				options: []docker.ListContainersOptions{},
 			},
 			mockSetup: func() {
 				dockerClient.
@@ -87,7 +88,7 @@ func TestDockerClient_ListContainers(t *testing.T) {
 							Image:   "busybox:latest",
 							Created: createdAt.Unix(),
 							Labels:  map[string]string{},
-							Status:  "stopped",
+							State:   "exited",
 						},
 					},
 						nil).
@@ -108,9 +109,8 @@ func TestDockerClient_ListContainers(t *testing.T) {
 		{
 			name: "list container empty",
 			args: args{
-				ctx:         context.Background(),
-				concurrency: 1,
-				options:     []docker.ListContainersOptions{},
+				ctx:     context.Background(),
+				options: []docker.ListContainersOptions{},
 			},
 			mockSetup: func() {
 				dockerClient.
@@ -128,10 +128,9 @@ func TestDockerClient_ListContainers(t *testing.T) {
 		{
 			name: "filter containers by labels",
 			args: args{
-				ctx:         context.Background(),
-				concurrency: 1,
+				ctx: context.Background(),
 				options: []docker.ListContainersOptions{
-					docker.WithContainerLabel("com.github.lucasmendesl.beerus.testLabel"),
+					docker.WithContainerLabel(selector.MustParse("com.github.lucasmendesl.beerus.testLabel")),
 				},
 			},
 			mockSetup: func() {
@@ -147,7 +146,7 @@ func TestDockerClient_ListContainers(t *testing.T) {
 							Image:   "beerus:latest",
 							Created: createdAt.Unix(),
 							Labels:  map[string]string{"com.github.lucasmendesl.beerus.service": "true"},
-							Status:  "stopped",
+							State:   "exited",
 							ImageID: "sha256:d55c68fb34057c75d9f0",
 						},
 						{
@@ -155,7 +154,7 @@ func TestDockerClient_ListContainers(t *testing.T) {
 							Image:   "busybox:latest",
 							Created: createdAt.Unix(),
 							Labels:  map[string]string{},
-							Status:  "stopped",
+							State:   "exited",
 							ImageID: "sha256:b5ad7243b38d33a8db255",
 						},
 						{
@@ -163,7 +162,7 @@ func TestDockerClient_ListContainers(t *testing.T) {
 							Image:   "nginx:latest",
 							Created: createdAt.Unix(),
 							Labels:  map[string]string{"com.github.lucasmendesl.beerus.testLabel": "true"},
-							Status:  "stopped",
+							State:   "exited",
 							ImageID: "sha256:d03820ba684b9a7ce9b13",
 						},
 					},
@@ -201,7 +200,7 @@ func TestDockerClient_ListContainers(t *testing.T) {
 					Image:        "busybox:latest",
 					ImageID:      "sha256:b5ad7243b38d33a8db255",
 					Labels:       map[string]string{},
-					Status:       "stopped",
+					Status:       "exited",
 					CreatedAt:    createdAt,
 					RestartCount: 0,
 					RestartPolicy: container.RestartPolicy{
@@ -211,13 +210,152 @@ func TestDockerClient_ListContainers(t *testing.T) {
 			},
 			wantErr: nopErr,
 		},
+		{
+			name: "status read from the container state",
+			args: args{
+				ctx:     context.Background(),
+				options: []docker.ListContainersOptions{},
+			},
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					ContainerList(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return([]types.Container{
+						{
+							ID:      "b0757c55a1fd",
+							Image:   "busybox:latest",
+							Created: createdAt.Unix(),
+							State:   "exited",
+							Status:  "Exited (0) 2 hours ago",
+						},
+					},
+						nil).
+					Times(1)
+
+				dockerClient.
+					EXPECT().
+					ContainerInspect(
+						gomock.Any(),
+						"b0757c55a1fd",
+					).
+					Return(types.ContainerJSON{
+						ContainerJSONBase: &types.ContainerJSONBase{
+							ID:    "b0757c55a1fd",
+							State: &types.ContainerState{Status: "exited"},
+							HostConfig: &container.HostConfig{
+								RestartPolicy: container.RestartPolicy{
+									Name: "no",
+								},
+							},
+						},
+					}, nil).
+					Times(1)
+			},
+			expected: []docker.Container{
+				{
+					ID:        "b0757c55a1fd",
+					Image:     "busybox:latest",
+					Status:    docker.ContainerStatusExited,
+					CreatedAt: createdAt,
+					RestartPolicy: container.RestartPolicy{
+						Name: "no",
+					},
+				},
+			},
+			wantErr: nopErr,
+		},
+		{
+			name: "filter containers by names",
+			args: args{
+				ctx: context.Background(),
+				options: []docker.ListContainersOptions{
+					docker.WithContainerNames(compileNameFilter(t, docker.NameFilter{
+						Include: []string{"ci-*", "/^build-[0-9]+$/"},
+						Exclude: []string{"ci-keep-*"},
+					})),
+				},
+			},
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					ContainerList(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return([]types.Container{
+						{
+							ID:      "d0fcf186fa",
+							Names:   []string{"/ci-keep-cache"},
+							Image:   "busybox:latest",
+							Created: createdAt.Unix(),
+							State:   "exited",
+						},
+						{
+							ID:      "b0757c55a1fd",
+							Names:   []string{"/build-42"},
+							Image:   "busybox:latest",
+							Created: createdAt.Unix(),
+							State:   "exited",
+							ImageID: "sha256:b5ad7243b38d33a8db255",
+						},
+						{
+							ID:      "b4ef436c698",
+							Names:   []string{"/postgres"},
+							Image:   "postgres:16",
+							Created: createdAt.Unix(),
+							State:   "exited",
+						},
+					},
+						nil).
+					Times(1)
+
+				dockerClient.
+					EXPECT().
+					ContainerInspect(
+						gomock.Any(),
+						"b0757c55a1fd",
+					).
+					Return(types.ContainerJSON{
+						ContainerJSONBase: &types.ContainerJSONBase{
+							ID:      "b0757c55a1fd",
+							Image:   "busybox:latest",
+							Created: createdAt.Format(time.RFC3339),
+							State:   &types.ContainerState{Status: "exited"},
+							HostConfig: &container.HostConfig{
+								RestartPolicy: container.RestartPolicy{
+									Name: "no",
+								},
+							},
+						},
+						Config: &container.Config{},
+					}, nil).
+					Times(1)
+			},
+			expected: []docker.Container{
+				{
+					ID:        "b0757c55a1fd",
+					Names:     []string{"/build-42"},
+					Image:     "busybox:latest",
+					ImageID:   "sha256:b5ad7243b38d33a8db255",
+					Status:    "exited",
+					CreatedAt: createdAt,
+					RestartPolicy: container.RestartPolicy{
+						Name: "no",
+					},
+				},
+			},
+			wantErr: nopErr,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockSetup()
 			d := docker.New(dockerClient, logger)
 
-			got, err := d.ListContainers(tt.args.ctx, tt.args.concurrency, tt.args.options...)
+			got, err := d.ListContainers(tt.args.ctx, tt.args.options...)
 			if tt.wantErr(t, err) {
 				return
 			}
@@ -227,6 +365,167 @@ func TestDockerClient_ListContainers(t *testing.T) {
 	}
 }
 
+func TestDockerClient_ListContainersInspectionCap(t *testing.T) {
+	const (
+		limit          = 3
+		containerCount = 30
+	)
+
+	var (
+		ctrl         = gomock.NewController(t)
+		dockerClient = mock.NewMockClient(ctrl)
+		logger       = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		inFlight atomic.Int32
+		peak     atomic.Int32
+	)
+
+	containers := make([]types.Container, 0, containerCount)
+	for i := range containerCount {
+		containers = append(containers, types.Container{ID: fmt.Sprintf("container-%02d", i), State: "exited"})
+	}
+
+	dockerClient.
+		EXPECT().
+		ContainerList(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Return(containers, nil).
+		Times(1)
+
+	dockerClient.
+		EXPECT().
+		ContainerInspect(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		DoAndReturn(func(_ context.Context, id string) (types.ContainerJSON, error) {
+			current := inFlight.Add(1)
+			defer inFlight.Add(-1)
+
+			for {
+				highest := peak.Load()
+				if current <= highest || peak.CompareAndSwap(highest, current) {
+					break
+				}
+			}
+
+			time.Sleep(5 * time.Millisecond)
+
+			return types.ContainerJSON{
+				ContainerJSONBase: &types.ContainerJSONBase{
+					ID:         id,
+					HostConfig: &container.HostConfig{},
+				},
+			}, nil
+		}).
+		Times(containerCount)
+
+	d := docker.New(dockerClient, logger, docker.WithExecutor(executor.New(limit)))
+
+	got, err := d.ListContainers(context.Background())
+	require.NoError(t, err)
+	require.Len(t, got, containerCount)
+	require.LessOrEqual(t, peak.Load(), int32(limit))
+
+	// the containers keep the order of the list
+	for i, c := range got {
+		require.Equal(t, containers[i].ID, c.ID)
+	}
+}
+
+func TestDockerClient_Inspect(t *testing.T) {
+	var (
+		ctrl         = gomock.NewController(t)
+		dockerClient = mock.NewMockClient(ctrl)
+		logger       = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		createdAt = time.Date(2025, time.January, 8, 10, 30, 0, 0, time.UTC)
+	)
+
+	tests := []struct {
+		name     string
+		runtime  docker.Runtime
+		state    string
+		size     bool
+		expected docker.ContainerStatus
+	}{
+		{name: "docker status", runtime: docker.RuntimeDocker, state: "exited", expected: docker.ContainerStatusExited},
+		{name: "podman status mapped to the docker one", runtime: docker.RuntimePodman, state: "stopped", expected: docker.ContainerStatusExited},
+		{name: "size computed on request", runtime: docker.RuntimeDocker, state: "exited", size: true, expected: docker.ContainerStatusExited},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := types.ContainerJSON{
+				ContainerJSONBase: &types.ContainerJSONBase{
+					ID:           "cadc6990a82e",
+					Name:         "/preview",
+					Image:        "sha256:b4ef436c698b07",
+					Created:      createdAt.Format(time.RFC3339Nano),
+					RestartCount: 2,
+					State: &types.ContainerState{
+						Status:   tt.state,
+						ExitCode: 1,
+					},
+					HostConfig: &container.HostConfig{
+						RestartPolicy: container.RestartPolicy{Name: "on-failure"},
+					},
+				},
+				Config: &container.Config{
+					Image:  "app:pr-42",
+					Labels: map[string]string{docker.TTLLabel: "6h"},
+				},
+			}
+
+			var sizeRw int64
+			if tt.size {
+				sizeRw = 2048
+				details.SizeRw = &sizeRw
+
+				dockerClient.
+					EXPECT().
+					ContainerInspectWithRaw(
+						gomock.Any(),
+						"cadc6990a82e",
+						true,
+					).
+					Return(details, nil, nil).
+					Times(1)
+			} else {
+				dockerClient.
+					EXPECT().
+					ContainerInspect(
+						gomock.Any(),
+						"cadc6990a82e",
+					).
+					Return(details, nil).
+					Times(1)
+			}
+
+			d := docker.New(dockerClient, logger, docker.WithRuntime(tt.runtime))
+
+			got, err := d.Inspect(context.Background(), "cadc6990a82e", tt.size)
+			require.NoError(t, err)
+			require.Equal(t, docker.Container{
+				ID:            "cadc6990a82e",
+				Names:         []string{"/preview"},
+				Image:         "app:pr-42",
+				ImageID:       "sha256:b4ef436c698b07",
+				Labels:        map[string]string{docker.TTLLabel: "6h"},
+				CreatedAt:     createdAt,
+				Status:        tt.expected,
+				ExitCode:      1,
+				RestartCount:  2,
+				RestartPolicy: container.RestartPolicy{Name: "on-failure"},
+				Size:          sizeRw,
+				TTL:           6 * time.Hour,
+			}, got)
+		})
+	}
+}
+
 func TestCanRemoveContainer(t *testing.T) {
 	type args struct {
 		container             docker.Container
@@ -0,0 +1,47 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+)
+
+// DiskUsage retrieves the disk space used by the Docker data-root through the
+// system disk usage endpoint of the Docker Engine, summing up the size of the
+// image layers, the writable layers of the containers, the volumes and the
+// build cache.
+//
+// Parameters:
+//   - ctx: The context for managing request lifetime and cancellation.
+//
+// Returns:
+//   - A DiskUsage with the bytes used by each kind of resource.
+//   - An error if there is an issue retrieving the disk usage.
+func (d *dockerClient) DiskUsage(ctx context.Context) (DiskUsage, error) {
+	du, err := d.cli.DiskUsage(ctx, types.DiskUsageOptions{})
+	if err != nil {
+		return DiskUsage{}, fmt.Errorf("docker disk usage error: %w", err)
+	}
+
+	usage := DiskUsage{Images: du.LayersSize}
+
+	for _, c := range du.Containers {
+		usage.Containers += c.SizeRw
+	}
+
+	for _, v := range du.Volumes {
+		// the size is -1 when the daemon could not compute it
+		if v.UsageData != nil && v.UsageData.Size > 0 {
+			usage.Volumes += v.UsageData.Size
+		}
+	}
+
+	for _, bc := range du.BuildCache {
+		if !bc.Shared {
+			usage.BuildCache += bc.Size
+		}
+	}
+
+	return usage, nil
+}
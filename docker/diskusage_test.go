@@ -0,0 +1,100 @@
+package docker_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/lucasmendesl/beerus/docker"
+	mock "github.com/lucasmendesl/beerus/docker/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDockerClient_DiskUsage(t *testing.T) {
+	var (
+		ctrl         = gomock.NewController(t)
+		dockerClient = mock.NewMockClient(ctrl)
+		logger       = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		diskUsageError = errors.New("disk usage error")
+	)
+	tests := []struct {
+		name      string
+		mockSetup func()
+		expected  docker.DiskUsage
+		wantErr   wantErr
+	}{
+		{
+			name: "error on disk usage",
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					DiskUsage(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return(types.DiskUsage{}, diskUsageError).
+					Times(1)
+			},
+			wantErr: func(t *testing.T, err error) bool {
+				require.EqualError(t, err, "docker disk usage error: disk usage error")
+				return true
+			},
+		},
+		{
+			name: "sum the usage of each kind of resource",
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					DiskUsage(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return(types.DiskUsage{
+						LayersSize: 4096,
+						Containers: []*types.Container{
+							{ID: "cadc6990a82e", SizeRw: 512},
+							{ID: "f1a3d2c0b9e8", SizeRw: 256},
+						},
+						Volumes: []*volume.Volume{
+							{Name: "data", UsageData: &volume.UsageData{Size: 1024}},
+							{Name: "unknown", UsageData: &volume.UsageData{Size: -1}},
+							{Name: "missing"},
+						},
+						BuildCache: []*types.BuildCache{
+							{ID: "ndlpt0hhvkqcdfkputsk4cq9c", Size: 2048},
+							{ID: "rnt4lm2jz6rvnm0sux62scz1n", Size: 8192, Shared: true},
+						},
+					}, nil).
+					Times(1)
+			},
+			expected: docker.DiskUsage{
+				Images:     4096,
+				Containers: 768,
+				Volumes:    1024,
+				BuildCache: 2048,
+			},
+			wantErr: nopErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			d := docker.New(dockerClient, logger)
+
+			got, err := d.DiskUsage(context.Background())
+			if tt.wantErr(t, err) {
+				return
+			}
+
+			require.Equal(t, tt.expected, got)
+			require.Equal(t, int64(7936), got.Total())
+		})
+	}
+}
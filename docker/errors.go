@@ -0,0 +1,97 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+)
+
+// ErrorClass is a string type classifying the errors returned by the Docker
+// API, so they can be reported and handled by their nature.
+type ErrorClass string
+
+const (
+	// ErrorClassConflict means that the request conflicts with the current
+	// state of the resource, such as an image referenced by a container.
+	ErrorClassConflict ErrorClass = "conflict"
+
+	// ErrorClassInUse means that the resource is still used, such as an image
+	// used by a container, a running container, a volume mounted by a
+	// container or a network with active endpoints.
+	ErrorClassInUse ErrorClass = "in-use"
+
+	// ErrorClassNotFound means that the resource no longer exists.
+	ErrorClassNotFound ErrorClass = "not-found"
+
+	// ErrorClassTimeout means that the request did not complete in time.
+	ErrorClassTimeout ErrorClass = "timeout"
+
+	// ErrorClassCanceled means that the request was canceled.
+	ErrorClassCanceled ErrorClass = "canceled"
+
+	// ErrorClassDaemon means that the daemon is unreachable or failed
+	// internally while handling the request.
+	ErrorClassDaemon ErrorClass = "daemon"
+
+	// ErrorClassUnknown means that the error could not be classified.
+	ErrorClassUnknown ErrorClass = "unknown"
+)
+
+// inUseMessages are the fragments of the messages the daemon returns along
+// with a conflict when removing a resource that is still used.
+var inUseMessages = []string{
+	"being used",
+	"in use",
+	"is running",
+	"active endpoints",
+}
+
+// Fatal reports whether an error of the class means that the daemon can not
+// handle any further request, so there is no point in going on with the
+// cleanup. The other classes only concern the resource being handled.
+func (c ErrorClass) Fatal() bool {
+	return c == ErrorClassDaemon
+}
+
+// ClassifyError returns the class of the given error returned by the Docker
+// API, relying on the error definitions of the Docker client. Conflicts due
+// to a resource that is still used are told apart from the other conflicts
+// through the message of the daemon.
+func ClassifyError(err error) ErrorClass {
+	switch {
+	case isInUse(err):
+		return ErrorClassInUse
+	case errdefs.IsConflict(err):
+		return ErrorClassConflict
+	case errdefs.IsNotFound(err):
+		return ErrorClassNotFound
+	case errdefs.IsDeadline(err), errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout
+	case errdefs.IsCancelled(err), errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case client.IsErrConnectionFailed(err), errdefs.IsUnavailable(err), errdefs.IsSystem(err):
+		return ErrorClassDaemon
+	default:
+		return ErrorClassUnknown
+	}
+}
+
+// isInUse reports whether the given error is a conflict raised by the
+// removal of a resource that is still used.
+func isInUse(err error) bool {
+	if !errdefs.IsConflict(err) && !errdefs.IsForbidden(err) {
+		return false
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, fragment := range inUseMessages {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,91 @@
+package docker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/lucasmendesl/beerus/docker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected docker.ErrorClass
+	}{
+		{
+			name:     "conflict",
+			err:      errdefs.Conflict(errors.New("conflict: unable to delete b0757c55a1fd (must be forced) - image is referenced in multiple repositories")),
+			expected: docker.ErrorClassConflict,
+		},
+		{
+			name:     "image in use",
+			err:      errdefs.Conflict(errors.New("image is being used by running container")),
+			expected: docker.ErrorClassInUse,
+		},
+		{
+			name:     "volume in use",
+			err:      fmt.Errorf("error removing volume: %w", errdefs.Conflict(errors.New("remove data: volume is in use - [cadc6990a82e]"))),
+			expected: docker.ErrorClassInUse,
+		},
+		{
+			name:     "network with active endpoints",
+			err:      errdefs.Forbidden(errors.New("error while removing network: network backend id 7d86a9 has active endpoints")),
+			expected: docker.ErrorClassInUse,
+		},
+		{
+			name:     "message without conflict",
+			err:      errors.New("image is being used by running container"),
+			expected: docker.ErrorClassUnknown,
+		},
+		{
+			name:     "wrapped not found",
+			err:      fmt.Errorf("error removing image: %w", errdefs.NotFound(errors.New("no such image"))),
+			expected: docker.ErrorClassNotFound,
+		},
+		{
+			name:     "deadline exceeded",
+			err:      context.DeadlineExceeded,
+			expected: docker.ErrorClassTimeout,
+		},
+		{
+			name:     "canceled",
+			err:      context.Canceled,
+			expected: docker.ErrorClassCanceled,
+		},
+		{
+			name:     "daemon internal error",
+			err:      errdefs.System(errors.New("driver failed")),
+			expected: docker.ErrorClassDaemon,
+		},
+		{
+			name:     "unknown",
+			err:      errors.New("something went wrong"),
+			expected: docker.ErrorClassUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, docker.ClassifyError(tt.err))
+		})
+	}
+}
+
+func TestErrorClass_Fatal(t *testing.T) {
+	for _, class := range []docker.ErrorClass{
+		docker.ErrorClassConflict,
+		docker.ErrorClassInUse,
+		docker.ErrorClassNotFound,
+		docker.ErrorClassTimeout,
+		docker.ErrorClassCanceled,
+		docker.ErrorClassUnknown,
+	} {
+		require.False(t, class.Fatal(), class)
+	}
+
+	require.True(t, docker.ErrorClassDaemon.Fatal())
+}
@@ -2,6 +2,8 @@ package docker
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/filters"
@@ -16,42 +18,73 @@ const (
 // events.Message struct describing a Docker event, or an error if there is an
 // issue fetching the event stream.
 //
-// The function takes a context.Context and a variadic list of
-// events.Action values, which are used to filter the types of events that are
-// returned.
+// The function takes a context.Context, the time from which past events
+// should be replayed and a variadic list of events.Action values, which are
+// used to filter the types of events that are returned. A zero since only
+// streams the events emitted from now on. On Podman, the died action of the
+// container exits reported by some releases is requested along with die and
+// reported as die.
 //
 // The function returns a channel of EventResult objects, which is closed when
 // the context is canceled or when an error occurs. If an error occurs, the
 // channel will contain a single EventResult object with a non-nil Err field.
 // If the context is canceled, the channel will contain a single EventResult
 // object with an Err field that is equal to the context's error.
-func (d *dockerClient) FromEvents(ctx context.Context, actions ...events.Action) <-chan EventResult {
-	filterOpts := filters.NewArgs(
-		filters.Arg(filterType, string(events.ContainerEventType)),
-		filters.Arg(filterType, string(events.ImageEventType)),
-	)
-	for _, action := range actions {
-		filterOpts.Add(eventAction, string(action))
-	}
-
-	options := events.ListOptions{
-		Filters: filterOpts,
-	}
-
+func (d *dockerClient) FromEvents(ctx context.Context, since time.Time, actions ...events.Action) <-chan EventResult {
 	eventCh := make(chan EventResult, 1)
 	go func() {
-		msgCh, errCh := d.cli.Events(ctx, options)
 		defer close(eventCh)
 
+		runtime, err := d.resolveRuntime(ctx)
+		if err != nil {
+			eventCh <- EventResult{Err: err}
+			return
+		}
+
+		filterOpts := filters.NewArgs(
+			filters.Arg(filterType, string(events.ContainerEventType)),
+			filters.Arg(filterType, string(events.ImageEventType)),
+		)
+		for _, action := range eventActions(runtime, actions) {
+			filterOpts.Add(eventAction, string(action))
+		}
+
+		options := events.ListOptions{
+			Filters: filterOpts,
+		}
+
+		if !since.IsZero() {
+			// the daemon expects the timestamp as seconds and nanoseconds
+			options.Since = fmt.Sprintf("%d.%09d", since.Unix(), since.Nanosecond())
+		}
+
+		msgCh, errCh := d.cli.Events(ctx, options)
+
 		for {
+			var result EventResult
+
 			select {
 			case <-ctx.Done():
-				eventCh <- EventResult{Err: ctx.Err()}
+				// the consumer may have stopped reading, so the error is only
+				// delivered when there is room for it
+				select {
+				case eventCh <- EventResult{Err: ctx.Err()}:
+				default:
+				}
 				return
 			case msg := <-msgCh:
-				eventCh <- EventResult{Message: msg}
+				result = EventResult{Message: eventMessage(runtime, msg)}
 			case err := <-errCh:
-				eventCh <- EventResult{Err: err}
+				result = EventResult{Err: err}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case eventCh <- result:
+			}
+
+			if result.Err != nil {
 				return
 			}
 		}
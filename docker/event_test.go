@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/docker/docker/api/types/events"
 	"github.com/lucasmendesl/beerus/docker"
@@ -23,6 +24,7 @@ func TestDockerClient_FromEvents(t *testing.T) {
 	)
 	type args struct {
 		ctx    context.Context
+		since  time.Time
 		action events.Action
 	}
 	tests := []struct {
@@ -53,6 +55,30 @@ func TestDockerClient_FromEvents(t *testing.T) {
 			expected: events.Message{Type: "container", Action: events.ActionDie},
 			wantErr:  nopErr,
 		},
+		{
+			name: "replay events since the given time",
+			args: args{
+				ctx:    context.Background(),
+				since:  time.Unix(1736294400, 123),
+				action: events.ActionDie,
+			},
+			setupMock: func(dockerApi *mock.MockClient) {
+				eventCh := make(chan events.Message, 1)
+				errCh := make(chan error, 1)
+				dockerApi.
+					EXPECT().
+					Events(
+						gomock.Any(),
+						gomock.Cond(func(options events.ListOptions) bool {
+							return options.Since == "1736294400.000000123"
+						}),
+					).Return(eventCh, errCh).
+					Times(1)
+				eventCh <- events.Message{Type: "container", Action: events.ActionDie, TimeNano: 1736294400000000123}
+			},
+			expected: events.Message{Type: "container", Action: events.ActionDie, TimeNano: 1736294400000000123},
+			wantErr:  nopErr,
+		},
 		{
 			name: "error fetching events",
 			setupMock: func(dockerApi *mock.MockClient) {
@@ -87,7 +113,7 @@ func TestDockerClient_FromEvents(t *testing.T) {
 			wg.Add(1)
 
 			tt.setupMock(mockCli)
-			resultCh := dockerClient.FromEvents(ctx, events.ActionCreate)
+			resultCh := dockerClient.FromEvents(ctx, tt.args.since, tt.args.action)
 
 			go func() {
 				defer wg.Done()
@@ -0,0 +1,119 @@
+package docker
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// NameFilter selects resources by name with include and exclude patterns. A
+// resource is selected when one of its names matches one of the Include
+// patterns, or when there is none, and none of its names matches one of the
+// Exclude patterns. Patterns are shell globs, such as "ci/*", where * does
+// not match a slash, or regular expressions when enclosed in slashes, such as
+// "/^ci-[0-9]+$/". Resources without any name, such as the dangling images,
+// are always selected.
+type NameFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// NameMatcher is a compiled NameFilter, so the patterns are compiled once
+// rather than on every listing. The zero NameMatcher selects everything.
+type NameMatcher struct {
+	include []func(string) bool
+	exclude []func(string) bool
+}
+
+// Compile returns the matcher of the patterns of the filter. It returns an
+// error naming the first malformed pattern of the filter.
+func (f NameFilter) Compile() (NameMatcher, error) {
+	var (
+		m   NameMatcher
+		err error
+	)
+
+	if m.include, err = compilePatterns(f.Include); err != nil {
+		return NameMatcher{}, err
+	}
+
+	if m.exclude, err = compilePatterns(f.Exclude); err != nil {
+		return NameMatcher{}, err
+	}
+
+	return m, nil
+}
+
+// compilePatterns returns a function reporting whether a name matches, for
+// each one of the given patterns.
+func compilePatterns(patterns []string) ([]func(string) bool, error) {
+	matchers := make([]func(string) bool, 0, len(patterns))
+
+	for _, p := range patterns {
+		if len(p) > 1 && strings.HasPrefix(p, "/") && strings.HasSuffix(p, "/") {
+			re, err := regexp.Compile(p[1 : len(p)-1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+			}
+
+			matchers = append(matchers, re.MatchString)
+			continue
+		}
+
+		// the pattern is matched against a name to report a malformed one
+		// before any listing
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+
+		matchers = append(matchers, func(name string) bool {
+			matched, _ := path.Match(p, name)
+			return matched
+		})
+	}
+
+	return matchers, nil
+}
+
+// Selects reports whether a resource with the given names is selected.
+func (m NameMatcher) Selects(names ...string) bool {
+	if len(names) == 0 {
+		return true
+	}
+
+	matches := func(matchers []func(string) bool) bool {
+		return slices.ContainsFunc(names, func(name string) bool {
+			return slices.ContainsFunc(matchers, func(match func(string) bool) bool {
+				return match(name)
+			})
+		})
+	}
+
+	if matches(m.exclude) {
+		return false
+	}
+
+	return len(m.include) == 0 || matches(m.include)
+}
+
+// ImageSelected reports whether the given image is kept by the same name
+// matchers as the listing of the images: its repositories are selected by
+// the given repositories matcher and its tags by the given tags matcher. An
+// image without any tag left is always selected.
+func ImageSelected(img Image, repositories, tags NameMatcher) bool {
+	return repositories.Selects(img.repositories()...) && tags.Selects(img.tags()...)
+}
+
+// removeUnselected filters out the items of the given slice whose names,
+// returned by the given function, are not selected by the given matcher.
+func removeUnselected[T any](items []T, matcher NameMatcher, names func(T) []string) []T {
+	if len(matcher.include) == 0 && len(matcher.exclude) == 0 {
+		return items
+	}
+
+	return slices.DeleteFunc(items, func(item T) bool {
+		return !matcher.Selects(names(item)...)
+	})
+}
@@ -0,0 +1,123 @@
+package docker_test
+
+import (
+	"testing"
+
+	"github.com/lucasmendesl/beerus/docker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNameFilter_Compile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   docker.NameFilter
+		names    []string
+		expected bool
+		wantErr  string
+	}{
+		{
+			name:     "empty filter selects everything",
+			names:    []string{"nginx"},
+			expected: true,
+		},
+		{
+			name:     "glob include",
+			filter:   docker.NameFilter{Include: []string{"ci/*"}},
+			names:    []string{"ci/app"},
+			expected: true,
+		},
+		{
+			name:     "glob star does not match a slash",
+			filter:   docker.NameFilter{Include: []string{"ci/*"}},
+			names:    []string{"ci/team/app"},
+			expected: false,
+		},
+		{
+			name:     "regexp include",
+			filter:   docker.NameFilter{Include: []string{"/^pr-[0-9]+$/"}},
+			names:    []string{"pr-42"},
+			expected: true,
+		},
+		{
+			name:     "not included",
+			filter:   docker.NameFilter{Include: []string{"ci-*", "/^build-/"}},
+			names:    []string{"postgres"},
+			expected: false,
+		},
+		{
+			name:     "one of the names included",
+			filter:   docker.NameFilter{Include: []string{"ci/*"}},
+			names:    []string{"nginx", "ci/nginx"},
+			expected: true,
+		},
+		{
+			name: "exclude takes precedence",
+			filter: docker.NameFilter{
+				Include: []string{"ci/*"},
+				Exclude: []string{"/cache$/"},
+			},
+			names:    []string{"ci/cache"},
+			expected: false,
+		},
+		{
+			name:     "resource without names",
+			filter:   docker.NameFilter{Include: []string{"ci/*"}},
+			expected: true,
+		},
+		{
+			name:    "invalid glob",
+			filter:  docker.NameFilter{Include: []string{"ci/["}},
+			names:   []string{"ci/app"},
+			wantErr: `invalid pattern "ci/["`,
+		},
+		{
+			name:    "invalid regexp",
+			filter:  docker.NameFilter{Exclude: []string{"/(ci/"}},
+			names:   []string{"ci/app"},
+			wantErr: `invalid pattern "/(ci/"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := tt.filter.Compile()
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, matcher.Selects(tt.names...))
+		})
+	}
+}
+
+func TestNameMatcher_ZeroSelectsEverything(t *testing.T) {
+	var matcher docker.NameMatcher
+
+	require.True(t, matcher.Selects("ci/app"))
+	require.True(t, matcher.Selects())
+}
+
+func TestImageSelected(t *testing.T) {
+	repositories := compileNameFilter(t, docker.NameFilter{Include: []string{"ci/*"}})
+	tags := compileNameFilter(t, docker.NameFilter{Exclude: []string{"/^v[0-9]+$/"}})
+
+	require.True(t, docker.ImageSelected(docker.Image{Tags: []string{"ci/app:pr-42"}}, repositories, tags))
+	require.False(t, docker.ImageSelected(docker.Image{Tags: []string{"web/app:pr-42"}}, repositories, tags))
+	require.False(t, docker.ImageSelected(docker.Image{Tags: []string{"ci/app:v2"}}, repositories, tags))
+
+	// an image without any tag left is always selected
+	require.True(t, docker.ImageSelected(docker.Image{Dangling: true}, repositories, tags))
+}
+
+// compileNameFilter returns the matcher of the given filter, failing the test
+// when one of its patterns is malformed.
+func compileNameFilter(t *testing.T, filter docker.NameFilter) docker.NameMatcher {
+	t.Helper()
+
+	matcher, err := filter.Compile()
+	require.NoError(t, err)
+
+	return matcher
+}
@@ -1,12 +1,17 @@
 package docker
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"slices"
+	"strings"
 	"time"
 
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/errdefs"
 )
 
 const (
@@ -17,7 +22,18 @@ const (
 // ListExpiredImages retrieves a list of Docker images that are considered
 // removable based on specific criteria. It fetches all images and filters
 // them to identify those that are either dangling or expired according to
-// the provided lifetime threshold.
+// the provided lifetime threshold, or to the TTL declared by the image
+// through the TTLLabel when present. When KeepLastTags is set, the most
+// recent images of each repository are never returned, whatever their age,
+// when DanglingOnly is set, only the dangling images are returned, and when
+// All is set, the images are returned whatever their age. The
+// age of the images found in LastUsed is counted from their last use. A
+// transient failure of the listing is retried following the retry policy
+// of the client. On Podman, images without any tag are dangling as well.
+// The images with an ignored label, or whose repositories or tags are not
+// selected by the Repositories and Tags filters, are left out. The ID of
+// every listed image is returned as well, so the caller can tell the images
+// that no longer exist without listing them again.
 //
 // Parameters:
 //   - ctx: The context for managing request lifetime and cancellation.
@@ -26,68 +42,327 @@ const (
 //
 // Returns:
 //   - A slice of image.Summary containing removable images.
+//   - The ID of every image, intermediate images included.
 //   - An error if there is an issue retrieving the list of images.
-func (d *dockerClient) ListExpiredImages(ctx context.Context, options ExpiredImageListOptions) ([]Image, error) {
-	images, err := d.cli.ImageList(ctx, image.ListOptions{
-		All: true,
+func (d *dockerClient) ListExpiredImages(ctx context.Context, options ExpiredImageListOptions) ([]Image, []string, error) {
+	runtime, err := d.resolveRuntime(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var images []image.Summary
+	err = d.withRetry(ctx, "list images", func() (err error) {
+		images, err = d.cli.ImageList(ctx, image.ListOptions{
+			All: true,
+		})
+		return err
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("expired docker images error: %w", err)
+		return nil, nil, fmt.Errorf("expired docker images error: %w", err)
 	}
 
 	removableImages := make([]Image, 0, len(images))
+	ids := make([]string, 0, len(images))
 
 	if len(images) == 0 {
-		return removableImages, nil
+		return removableImages, ids, nil
+	}
+
+	var retained map[string]struct{}
+	if options.KeepLastTags > 0 {
+		retained = retainedImages(images, options.KeepLastTags)
 	}
 
 	for _, image := range images {
-		isDangling := slices.Contains(image.RepoTags, danglingImageTag)
-		imageExpired := isImageExpired(image.Created, options.LifetimeThresholdInDays)
+		ids = append(ids, image.ID)
+
+		if _, ok := retained[image.ID]; ok {
+			continue
+		}
+
+		ttl, err := ParseTTL(image.Labels)
+		if err != nil {
+			d.log.Warn("Ignoring image ttl, falling back to the lifetime threshold", "id", image.ID, "error", err)
+		}
+
+		isDangling := danglingImage(runtime, image.RepoTags)
+		if options.DanglingOnly && !isDangling {
+			continue
+		}
+
+		// the age of an image is counted from the last time a container used
+		// it, when known, instead of its build time
+		usedAt := lastUsedAt(image.Created, options.LastUsed[image.ID])
+		imageExpired := isExpired(usedAt, options.LifetimeThresholdInDays)
 
-		if isDangling || imageExpired {
+		// the ttl declared by the image takes the place of the global
+		// lifetime threshold
+		if ttl > 0 {
+			imageExpired = time.Since(ttlStartedAt(usedAt, image.ID, options.FirstSeen)) >= ttl
+		}
+
+		if isDangling || imageExpired || options.All {
 			removableImages = append(removableImages, Image{
-				ID:     image.ID,
-				Labels: image.Labels,
-				Tags:   image.RepoTags,
+				ID:         image.ID,
+				Labels:     image.Labels,
+				Tags:       image.RepoTags,
+				Dangling:   isDangling,
+				Size:       image.Size,
+				LastUsedAt: usedAt,
+				TTL:        ttl,
 			})
 		}
 	}
 
-	return removeIgnored(removableImages, options.IgnoreLabels...), nil
+	removableImages = removeIgnored(removableImages, options.IgnoreLabels...)
+	removableImages = removeUntargeted(removableImages, options.TargetLabels...)
+
+	removableImages = removeUnselected(removableImages, options.Repositories, Image.repositories)
+	removableImages = removeUnselected(removableImages, options.Tags, Image.tags)
+
+	return removableImages, ids, nil
+}
+
+// InspectImage retrieves a Docker image by its ID, with the details the
+// listing of the images reports, such as its tags, its labels and the TTL
+// declared by the TTLLabel. The image is dangling when no tag points to it
+// anymore. Transient failures are retried following the retry policy of the
+// client.
+//
+// Parameters:
+//   - ctx: The context for managing request lifetime and cancellation.
+//   - imageID: The ID of the image to be inspected.
+//
+// Returns:
+//   - The inspected image.
+//   - An error if there is an issue retrieving the image information.
+func (d *dockerClient) InspectImage(ctx context.Context, imageID string) (Image, error) {
+	var details types.ImageInspect
+	err := d.withRetry(ctx, "inspect image", func() (err error) {
+		details, _, err = d.cli.ImageInspectWithRaw(ctx, imageID)
+		return err
+	})
+
+	if err != nil {
+		return Image{}, fmt.Errorf("docker image inspect error: %w", err)
+	}
+
+	img := Image{
+		ID:       details.ID,
+		Tags:     details.RepoTags,
+		Dangling: len(details.RepoTags) == 0 || slices.Contains(details.RepoTags, danglingImageTag),
+		Size:     details.Size,
+	}
+
+	if details.Config != nil {
+		ttl, err := ParseTTL(details.Config.Labels)
+		if err != nil {
+			d.log.Warn("Ignoring image ttl, falling back to the lifetime threshold", "id", details.ID, "error", err)
+		}
+
+		img.Labels = details.Config.Labels
+		img.TTL = ttl
+	}
+
+	return img, nil
+}
+
+// repositories returns the repositories of the tags of the image, in their
+// familiar form, such as "ci/app" or "nginx".
+func (i Image) repositories() []string {
+	var repositories []string
+	for _, tag := range i.Tags {
+		if repository, _ := SplitReference(tag); repository != "" {
+			repositories = append(repositories, repository)
+		}
+	}
+
+	return repositories
+}
+
+// tags returns the tags of the image without their repository, such as
+// "latest" or "1.27".
+func (i Image) tags() []string {
+	var tags []string
+	for _, ref := range i.Tags {
+		if _, tag := SplitReference(ref); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// SplitReference returns the repository, in its familiar form, such as
+// "ci/app" or "nginx", and the tag of the given image reference. Both are
+// empty when the reference cannot be parsed, or when it is an image ID, as
+// reported for the containers whose image was untagged.
+func SplitReference(ref string) (string, string) {
+	if ref == danglingImageTag || strings.HasPrefix(ref, "sha256:") {
+		return "", ""
+	}
+
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", ""
+	}
+
+	var tag string
+	if tagged, ok := named.(reference.Tagged); ok {
+		tag = tagged.Tag()
+	}
+
+	return reference.FamiliarName(named), tag
 }
 
 // RemoveImage removes a Docker image by its ID.
+// Transient failures are retried following the retry policy of the client.
+// A forced removal on Podman also removes the containers using the image,
+// so the removal is never forced there: the tags of the image are removed
+// one by one instead, the image being removed along with its last tag.
 //
 // Parameters:
 //   - ctx: The context for managing request lifetime and cancellation.
 //   - options: A RemoveImageOptions struct containing the ID of the image
-//     to be removed and a force flag to indicate whether the image should
-//     be forcibly removed.
+//     to be removed, its tags and a force flag to indicate whether the
+//     image should be forcibly removed.
 //
 // Returns:
 //   - An error if there is an issue removing the image.
 func (d *dockerClient) RemoveImage(ctx context.Context, options RemoveImageOptions) error {
-	_, err := d.cli.ImageRemove(ctx, options.ImageID, image.RemoveOptions{
-		Force: options.Force,
+	runtime, err := d.resolveRuntime(ctx)
+	if err != nil {
+		return err
+	}
+
+	if runtime == RuntimePodman && options.Force {
+		return d.untagImage(ctx, options)
+	}
+
+	return d.removeImageRef(ctx, options.ImageID, options.Force)
+}
+
+// untagImage removes the tags of the given image one by one, the image
+// being removed along with its last tag, or removes the image by its ID,
+// without forcing it, when it has no tag. Tags already removed are ignored.
+func (d *dockerClient) untagImage(ctx context.Context, options RemoveImageOptions) error {
+	tags := slices.DeleteFunc(slices.Clone(options.Tags), func(tag string) bool {
+		return tag == danglingImageTag
 	})
-	return err
+
+	if len(tags) == 0 {
+		return d.removeImageRef(ctx, options.ImageID, false)
+	}
+
+	for _, tag := range tags {
+		if err := d.removeImageRef(ctx, tag, false); err != nil && !errdefs.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
 }
 
-// isImageExpired checks if a Docker image is expired based on its creation
-// time and the given lifetime threshold in days.
+// removeImageRef removes the image with the given ID, or the given tag.
+func (d *dockerClient) removeImageRef(ctx context.Context, ref string, force bool) error {
+	return d.withRetry(ctx, "remove image", func() error {
+		_, err := d.cli.ImageRemove(ctx, ref, image.RemoveOptions{
+			Force: force,
+		})
+		return err
+	})
+}
+
+// lastUsedAt returns the time from which the age of a Docker image is
+// counted, which is the last time it was used by a container when that is
+// known, or its creation time otherwise.
 //
 // Parameters:
 //   - created: The creation time of the image in seconds since the Unix
 //     epoch.
-//   - lifetimeThresholdInDays: The lifetime threshold in days.
+//   - lastUsed: The last time the image was used, or the zero time when
+//     it is unknown.
 //
 // Returns:
-//   - A boolean indicating whether the image is expired or not.
-func isImageExpired(created int64, lifetimeThresholdInDays uint16) bool {
-	createdTime := time.Unix(created, 0)
+//   - The latest time between the creation and the last use of the image.
+func lastUsedAt(created int64, lastUsed time.Time) time.Time {
+	createdAt := time.Unix(created, 0)
+	if lastUsed.After(createdAt) {
+		return lastUsed
+	}
+
+	return createdAt
+}
+
+// ttlStartedAt returns the time the TTL of an image last used at the given
+// time started, which is never before the image was first seen when the
+// first seen times are known. An image missing from them is seen for the
+// first time now.
+func ttlStartedAt(usedAt time.Time, imageID string, firstSeen map[string]time.Time) time.Time {
+	if firstSeen == nil {
+		return usedAt
+	}
+
+	seenAt, ok := firstSeen[imageID]
+	if !ok {
+		return time.Now()
+	}
+
+	if seenAt.After(usedAt) {
+		return seenAt
+	}
+
+	return usedAt
+}
+
+// isExpired checks if a resource created at the given time is older than
+// the given lifetime threshold in days.
+func isExpired(createdTime time.Time, lifetimeThresholdInDays uint16) bool {
 	days := uint16(time.Since(createdTime).Hours() / dayInHours)
 
 	return days >= lifetimeThresholdInDays
 }
+
+// retainedImages groups the given images by repository and returns the IDs
+// of the keepLast most recent images of each one of them. An image tagged in
+// several repositories is retained when it is among the most recent images
+// of any of them.
+func retainedImages(images []image.Summary, keepLast uint16) map[string]struct{} {
+	repositories := make(map[string][]image.Summary)
+
+	for _, img := range images {
+		for _, tag := range img.RepoTags {
+			if tag == danglingImageTag {
+				continue
+			}
+
+			named, err := reference.ParseNormalizedNamed(tag)
+			if err != nil {
+				continue
+			}
+
+			repository := reference.FamiliarName(named)
+			alreadyGrouped := slices.ContainsFunc(repositories[repository], func(s image.Summary) bool {
+				return s.ID == img.ID
+			})
+
+			if !alreadyGrouped {
+				repositories[repository] = append(repositories[repository], img)
+			}
+		}
+	}
+
+	retained := make(map[string]struct{})
+	for _, repositoryImages := range repositories {
+		slices.SortStableFunc(repositoryImages, func(a, b image.Summary) int {
+			return cmp.Compare(b.Created, a.Created)
+		})
+
+		for _, img := range repositoryImages[:min(len(repositoryImages), int(keepLast))] {
+			retained[img.ID] = struct{}{}
+		}
+	}
+
+	return retained
+}
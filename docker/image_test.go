@@ -8,9 +8,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
 	"github.com/lucasmendesl/beerus/docker"
 	mock "github.com/lucasmendesl/beerus/docker/mocks"
+	"github.com/lucasmendesl/beerus/selector"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
@@ -21,6 +24,10 @@ func TestDockerClient_ListExpiredImages(t *testing.T) {
 		dockerClient = mock.NewMockClient(ctrl)
 		logger       = slog.New(slog.NewJSONHandler(io.Discard, nil))
 
+		// the creation times are reported in seconds, so the expected last use
+		// times must not hold any fraction of a second
+		now = time.Now().Truncate(time.Second)
+
 		listImagesError = errors.New("list images error")
 	)
 	type args struct {
@@ -96,19 +103,19 @@ func TestDockerClient_ListExpiredImages(t *testing.T) {
 					Return([]image.Summary{
 						{
 							ID:       "d55c68fb3405",
-							Created:  time.Now().Add(-time.Hour * 24 * 10).Unix(),
+							Created:  now.Add(-time.Hour * 24 * 10).Unix(),
 							RepoTags: []string{"golang:latest"},
 							Labels:   map[string]string{},
 						},
 						{
 							ID:       "a76d6a1f0270",
-							Created:  time.Now().Add(-time.Hour * 24 * 110).Unix(),
+							Created:  now.Add(-time.Hour * 24 * 110).Unix(),
 							RepoTags: []string{"nginx:latest"},
 							Labels:   map[string]string{},
 						},
 						{
 							ID:       "9897f4c66b5e",
-							Created:  time.Now().Unix(),
+							Created:  now.Unix(),
 							RepoTags: []string{"<none>:<none>"},
 							Labels:   map[string]string{},
 						},
@@ -118,14 +125,242 @@ func TestDockerClient_ListExpiredImages(t *testing.T) {
 			wantErr: nopErr,
 			expected: []docker.Image{
 				{
-					ID:     "a76d6a1f0270",
-					Tags:   []string{"nginx:latest"},
-					Labels: map[string]string{},
+					ID:         "a76d6a1f0270",
+					LastUsedAt: now.Add(-time.Hour * 24 * 110),
+					Tags:       []string{"nginx:latest"},
+					Labels:     map[string]string{},
+				},
+				{
+					ID:         "9897f4c66b5e",
+					LastUsedAt: now,
+					Tags:       []string{"<none>:<none>"},
+					Labels:     map[string]string{},
+					Dangling:   true,
+				},
+			},
+		},
+		{
+			name: "images expired by their own ttl",
+			args: args{
+				ctx: context.Background(),
+				options: docker.ExpiredImageListOptions{
+					LifetimeThresholdInDays: 100,
+				},
+			},
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					ImageList(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return([]image.Summary{
+						{
+							ID:       "3f9a6b2c1d0e",
+							Created:  now.Add(-time.Hour * 8).Unix(),
+							RepoTags: []string{"preview:pr-42"},
+							Labels:   map[string]string{docker.TTLLabel: "6h"},
+						},
+						{
+							ID:       "c81e728d9d4c",
+							Created:  now.Add(-time.Hour * 24 * 120).Unix(),
+							RepoTags: []string{"base:stable"},
+							Labels:   map[string]string{docker.TTLLabel: "4380h"},
+						},
+						{
+							ID:       "e4da3b7fbbce",
+							Created:  now.Add(-time.Hour * 24 * 110).Unix(),
+							RepoTags: []string{"nginx:latest"},
+							Labels:   map[string]string{docker.TTLLabel: "forever"},
+						},
+					}, nil).
+					Times(1)
+			},
+			wantErr: nopErr,
+			expected: []docker.Image{
+				{
+					ID:         "3f9a6b2c1d0e",
+					LastUsedAt: now.Add(-time.Hour * 8),
+					Tags:       []string{"preview:pr-42"},
+					Labels:     map[string]string{docker.TTLLabel: "6h"},
+					TTL:        6 * time.Hour,
+				},
+				{
+					ID:         "e4da3b7fbbce",
+					LastUsedAt: now.Add(-time.Hour * 24 * 110),
+					Tags:       []string{"nginx:latest"},
+					Labels:     map[string]string{docker.TTLLabel: "forever"},
+				},
+			},
+		},
+		{
+			name: "ttl counted from the first time the image was seen",
+			args: args{
+				ctx: context.Background(),
+				options: docker.ExpiredImageListOptions{
+					LifetimeThresholdInDays: 100,
+					FirstSeen: map[string]time.Time{
+						"3f9a6b2c1d0e": now.Add(-time.Hour * 2),
+						"a87ff679a2f3": now.Add(-time.Hour * 7),
+					},
+				},
+			},
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					ImageList(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return([]image.Summary{
+						{
+							ID:       "3f9a6b2c1d0e",
+							Created:  now.Add(-time.Hour * 8).Unix(),
+							RepoTags: []string{"preview:pr-42"},
+							Labels:   map[string]string{docker.TTLLabel: "6h"},
+						},
+						{
+							ID:       "a87ff679a2f3",
+							Created:  now.Add(-time.Hour * 8).Unix(),
+							RepoTags: []string{"preview:pr-43"},
+							Labels:   map[string]string{docker.TTLLabel: "6h"},
+						},
+						{
+							ID:       "1679091c5a88",
+							Created:  now.Add(-time.Hour * 8).Unix(),
+							RepoTags: []string{"preview:pr-44"},
+							Labels:   map[string]string{docker.TTLLabel: "6h"},
+						},
+					}, nil).
+					Times(1)
+			},
+			wantErr: nopErr,
+			// the image pulled two hours ago and the one never seen before
+			// are kept, whatever their build time
+			expected: []docker.Image{
+				{
+					ID:         "a87ff679a2f3",
+					LastUsedAt: now.Add(-time.Hour * 8),
+					Tags:       []string{"preview:pr-43"},
+					Labels:     map[string]string{docker.TTLLabel: "6h"},
+					TTL:        6 * time.Hour,
+				},
+			},
+		},
+		{
+			name: "keep the most recent images of each repository",
+			args: args{
+				ctx: context.Background(),
+				options: docker.ExpiredImageListOptions{
+					LifetimeThresholdInDays: 30,
+					KeepLastTags:            2,
+				},
+			},
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					ImageList(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return([]image.Summary{
+						{
+							ID:       "a87ff679a2f3",
+							Created:  now.Add(-time.Hour * 24 * 40).Unix(),
+							RepoTags: []string{"registry.local:5000/app:v3", "registry.local:5000/app:latest"},
+						},
+						{
+							ID:       "e4da3b7fbbce",
+							Created:  now.Add(-time.Hour * 24 * 50).Unix(),
+							RepoTags: []string{"registry.local:5000/app:v2"},
+						},
+						{
+							ID:       "1679091c5a88",
+							Created:  now.Add(-time.Hour * 24 * 60).Unix(),
+							RepoTags: []string{"registry.local:5000/app:v1"},
+						},
+						{
+							ID:       "8f14e45fceea",
+							Created:  now.Add(-time.Hour * 24 * 10).Unix(),
+							RepoTags: []string{"nginx:1.27"},
+						},
+						{
+							ID:       "c9f0f895fb98",
+							Created:  now.Add(-time.Hour * 24 * 90).Unix(),
+							RepoTags: []string{"nginx:1.25"},
+						},
+						{
+							ID:       "45c48cce2e2d",
+							Created:  now.Add(-time.Hour * 24 * 120).Unix(),
+							RepoTags: []string{"nginx:1.23"},
+						},
+						{
+							ID:       "d3d9446802a4",
+							Created:  now.Add(-time.Hour * 24 * 70).Unix(),
+							RepoTags: []string{"<none>:<none>"},
+						},
+					}, nil).
+					Times(1)
+			},
+			wantErr: nopErr,
+			expected: []docker.Image{
+				{
+					ID:         "1679091c5a88",
+					LastUsedAt: now.Add(-time.Hour * 24 * 60),
+					Tags:       []string{"registry.local:5000/app:v1"},
 				},
 				{
-					ID:     "9897f4c66b5e",
-					Tags:   []string{"<none>:<none>"},
-					Labels: map[string]string{},
+					ID:         "45c48cce2e2d",
+					LastUsedAt: now.Add(-time.Hour * 24 * 120),
+					Tags:       []string{"nginx:1.23"},
+				},
+				{
+					ID:         "d3d9446802a4",
+					LastUsedAt: now.Add(-time.Hour * 24 * 70),
+					Tags:       []string{"<none>:<none>"},
+					Dangling:   true,
+				},
+			},
+		},
+		{
+			name: "images expired since their last use",
+			args: args{
+				ctx: context.Background(),
+				options: docker.ExpiredImageListOptions{
+					LifetimeThresholdInDays: 100,
+					LastUsed: map[string]time.Time{
+						"6512bd43d9ca": now.Add(-time.Hour * 48),
+						"c20ad4d76fe9": now.Add(-time.Hour * 24 * 150),
+					},
+				},
+			},
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					ImageList(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return([]image.Summary{
+						{
+							ID:       "6512bd43d9ca",
+							Created:  now.Add(-time.Hour * 24 * 700).Unix(),
+							RepoTags: []string{"debian:bookworm"},
+						},
+						{
+							ID:       "c20ad4d76fe9",
+							Created:  now.Add(-time.Hour * 24 * 300).Unix(),
+							RepoTags: []string{"alpine:3.18"},
+						},
+					}, nil).
+					Times(1)
+			},
+			wantErr: nopErr,
+			expected: []docker.Image{
+				{
+					ID:         "c20ad4d76fe9",
+					LastUsedAt: now.Add(-time.Hour * 24 * 150),
+					Tags:       []string{"alpine:3.18"},
 				},
 			},
 		},
@@ -135,7 +370,7 @@ func TestDockerClient_ListExpiredImages(t *testing.T) {
 				ctx: context.Background(),
 				options: docker.ExpiredImageListOptions{
 					LifetimeThresholdInDays: 50,
-					IgnoreLabels:            []string{"com.github.lucasmendesl.beerus.testLabel"},
+					IgnoreLabels:            []selector.Selector{selector.MustParse("com.github.lucasmendesl.beerus.testLabel")},
 				},
 			},
 			mockSetup: func() {
@@ -148,19 +383,19 @@ func TestDockerClient_ListExpiredImages(t *testing.T) {
 					Return([]image.Summary{
 						{
 							ID:       "104340b97284",
-							Created:  time.Now().Add(-time.Hour * 24 * 55).Unix(),
+							Created:  now.Add(-time.Hour * 24 * 55).Unix(),
 							RepoTags: []string{"beerus:latest"},
 							Labels:   map[string]string{"com.github.lucasmendesl.beerus.service": "true"},
 						},
 						{
 							ID:       "492084a114c1",
-							Created:  time.Now().Add(-time.Hour * 24 * 70).Unix(),
+							Created:  now.Add(-time.Hour * 24 * 70).Unix(),
 							RepoTags: []string{"nginx:latest"},
 							Labels:   map[string]string{"com.github.lucasmendesl.beerus.testLabel": "true"},
 						},
 						{
 							ID:       "b320553669f9",
-							Created:  time.Now().Add(-time.Hour * 24 * 55).Unix(),
+							Created:  now.Add(-time.Hour * 24 * 55).Unix(),
 							RepoTags: []string{"php:latest"},
 							Labels:   map[string]string{},
 						},
@@ -170,9 +405,159 @@ func TestDockerClient_ListExpiredImages(t *testing.T) {
 			wantErr: nopErr,
 			expected: []docker.Image{
 				{
-					ID:     "b320553669f9",
-					Tags:   []string{"php:latest"},
-					Labels: map[string]string{},
+					ID:         "b320553669f9",
+					LastUsedAt: now.Add(-time.Hour * 24 * 55),
+					Tags:       []string{"php:latest"},
+					Labels:     map[string]string{},
+				},
+			},
+		},
+		{
+			name: "every image when all is set",
+			args: args{
+				ctx: context.Background(),
+				options: docker.ExpiredImageListOptions{
+					LifetimeThresholdInDays: 100,
+					All:                     true,
+				},
+			},
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					ImageList(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return([]image.Summary{
+						{
+							ID:       "d55c68fb3405",
+							Created:  now.Add(-time.Hour * 24 * 10).Unix(),
+							RepoTags: []string{"golang:latest"},
+						},
+						{
+							ID:       "a76d6a1f0270",
+							Created:  now.Add(-time.Hour * 24 * 110).Unix(),
+							RepoTags: []string{"nginx:latest"},
+						},
+					}, nil).
+					Times(1)
+			},
+			wantErr: nopErr,
+			expected: []docker.Image{
+				{
+					ID:         "d55c68fb3405",
+					Tags:       []string{"golang:latest"},
+					LastUsedAt: now.Add(-time.Hour * 24 * 10),
+				},
+				{
+					ID:         "a76d6a1f0270",
+					Tags:       []string{"nginx:latest"},
+					LastUsedAt: now.Add(-time.Hour * 24 * 110),
+				},
+			},
+		},
+		{
+			name: "filter images by label selectors",
+			args: args{
+				ctx: context.Background(),
+				options: docker.ExpiredImageListOptions{
+					LifetimeThresholdInDays: 100,
+					IgnoreLabels:            []selector.Selector{selector.MustParse("env=prod")},
+					TargetLabels:            []selector.Selector{selector.MustParse("team in (ci, qa)")},
+				},
+			},
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					ImageList(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return([]image.Summary{
+						{
+							ID:       "d55c68fb3405",
+							Created:  now.Add(-time.Hour * 24 * 110).Unix(),
+							RepoTags: []string{"ci/app:1"},
+							Labels:   map[string]string{"team": "ci", "env": "prod"},
+						},
+						{
+							ID:       "a76d6a1f0270",
+							Created:  now.Add(-time.Hour * 24 * 110).Unix(),
+							RepoTags: []string{"ci/app:2"},
+							Labels:   map[string]string{"team": "ci", "env": "dev"},
+						},
+						{
+							ID:       "b5ad7243b38d",
+							Created:  now.Add(-time.Hour * 24 * 110).Unix(),
+							RepoTags: []string{"web/app:1"},
+							Labels:   map[string]string{"team": "web"},
+						},
+					}, nil).
+					Times(1)
+			},
+			wantErr: nopErr,
+			expected: []docker.Image{
+				{
+					ID:         "a76d6a1f0270",
+					Tags:       []string{"ci/app:2"},
+					Labels:     map[string]string{"team": "ci", "env": "dev"},
+					LastUsedAt: now.Add(-time.Hour * 24 * 110),
+				},
+			},
+		},
+		{
+			name: "filter images by repository and tag",
+			args: args{
+				ctx: context.Background(),
+				options: docker.ExpiredImageListOptions{
+					LifetimeThresholdInDays: 100,
+					Repositories:            compileNameFilter(t, docker.NameFilter{Include: []string{"ci/*"}}),
+					Tags:                    compileNameFilter(t, docker.NameFilter{Exclude: []string{"/^v[0-9]+$/"}}),
+				},
+			},
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					ImageList(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return([]image.Summary{
+						{
+							ID:       "d55c68fb3405",
+							Created:  now.Add(-time.Hour * 24 * 110).Unix(),
+							RepoTags: []string{"ci/app:pr-12"},
+						},
+						{
+							ID:       "a76d6a1f0270",
+							Created:  now.Add(-time.Hour * 24 * 110).Unix(),
+							RepoTags: []string{"ci/app:v3"},
+						},
+						{
+							ID:       "b5ad7243b38d",
+							Created:  now.Add(-time.Hour * 24 * 110).Unix(),
+							RepoTags: []string{"nginx:latest"},
+						},
+						{
+							ID:       "c3f1a2b9d7e5",
+							Created:  now.Add(-time.Hour * 24 * 110).Unix(),
+							RepoTags: []string{"<none>:<none>"},
+						},
+					}, nil).
+					Times(1)
+			},
+			wantErr: nopErr,
+			expected: []docker.Image{
+				{
+					ID:         "d55c68fb3405",
+					Tags:       []string{"ci/app:pr-12"},
+					LastUsedAt: now.Add(-time.Hour * 24 * 110),
+				},
+				{
+					ID:         "c3f1a2b9d7e5",
+					Tags:       []string{"<none>:<none>"},
+					Dangling:   true,
+					LastUsedAt: now.Add(-time.Hour * 24 * 110),
 				},
 			},
 		},
@@ -182,7 +567,7 @@ func TestDockerClient_ListExpiredImages(t *testing.T) {
 			tt.mockSetup()
 			d := docker.New(dockerClient, logger)
 
-			got, err := d.ListExpiredImages(tt.args.ctx, tt.args.options)
+			got, _, err := d.ListExpiredImages(tt.args.ctx, tt.args.options)
 			if tt.wantErr(t, err) {
 				return
 			}
@@ -191,3 +576,92 @@ func TestDockerClient_ListExpiredImages(t *testing.T) {
 		})
 	}
 }
+
+func TestDockerClient_InspectImage(t *testing.T) {
+	var (
+		ctrl         = gomock.NewController(t)
+		dockerClient = mock.NewMockClient(ctrl)
+		logger       = slog.New(slog.NewJSONHandler(io.Discard, nil))
+	)
+
+	tests := []struct {
+		name     string
+		tags     []string
+		expected docker.Image
+	}{
+		{
+			name: "tagged image",
+			tags: []string{"ci/app:pr-42"},
+			expected: docker.Image{
+				ID:     "sha256:3f9a6b2c1d0e",
+				Tags:   []string{"ci/app:pr-42"},
+				Labels: map[string]string{docker.TTLLabel: "6h"},
+				Size:   1024,
+				TTL:    6 * time.Hour,
+			},
+		},
+		{
+			name: "image without any tag left",
+			expected: docker.Image{
+				ID:       "sha256:3f9a6b2c1d0e",
+				Labels:   map[string]string{docker.TTLLabel: "6h"},
+				Dangling: true,
+				Size:     1024,
+				TTL:      6 * time.Hour,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dockerClient.
+				EXPECT().
+				ImageInspectWithRaw(
+					gomock.Any(),
+					"sha256:3f9a6b2c1d0e",
+				).
+				Return(types.ImageInspect{
+					ID:       "sha256:3f9a6b2c1d0e",
+					RepoTags: tt.tags,
+					Size:     1024,
+					Config: &container.Config{
+						Labels: map[string]string{docker.TTLLabel: "6h"},
+					},
+				}, nil, nil).
+				Times(1)
+
+			got, err := docker.New(dockerClient, logger).InspectImage(context.Background(), "sha256:3f9a6b2c1d0e")
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestDockerClient_ListExpiredImagesIDs(t *testing.T) {
+	var (
+		ctrl         = gomock.NewController(t)
+		dockerClient = mock.NewMockClient(ctrl)
+		logger       = slog.New(slog.NewJSONHandler(io.Discard, nil))
+		now          = time.Now()
+	)
+
+	dockerClient.
+		EXPECT().
+		ImageList(
+			gomock.Any(),
+			image.ListOptions{All: true},
+		).
+		Return([]image.Summary{
+			{ID: "d55c68fb3405", Created: now.Add(-time.Hour * 24 * 110).Unix(), RepoTags: []string{"nginx:1.23"}},
+			{ID: "a6f7c2e1b9d0", Created: now.Unix(), RepoTags: []string{"nginx:1.27"}},
+		}, nil).
+		Times(1)
+
+	// the ID of every image is returned, the ones kept included
+	images, ids, err := docker.New(dockerClient, logger, docker.WithRuntime(docker.RuntimeDocker)).
+		ListExpiredImages(context.Background(), docker.ExpiredImageListOptions{LifetimeThresholdInDays: 100})
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	require.Equal(t, "d55c68fb3405", images[0].ID)
+	require.Equal(t, []string{"d55c68fb3405", "a6f7c2e1b9d0"}, ids)
+}
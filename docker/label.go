@@ -1,7 +1,19 @@
 package docker
 
+import (
+	"fmt"
+	"time"
+
+	"github.com/lucasmendesl/beerus/selector"
+)
+
 const beerusServiceLabel = "com.github.lucasmendesl.beerus.service"
 
+// TTLLabel is the label where a container or an image declares its own
+// lifetime as a Go duration, such as "6h" or "720h", taking the place of
+// the global lifetime threshold.
+const TTLLabel = "com.github.lucasmendesl.beerus.ttl"
+
 type labeler interface {
 	GetLabels() map[string]string
 }
@@ -14,18 +26,30 @@ func (c Container) GetLabels() map[string]string {
 	return c.Labels
 }
 
-// removeIgnored filters out items from the given slice that have labels matching any of the given labels or the built-in "com.github.lucasmendesl.beerus.service" label.
+func (v Volume) GetLabels() map[string]string {
+	return v.Labels
+}
+
+func (n Network) GetLabels() map[string]string {
+	return n.Labels
+}
+
+// serviceSelector selects the resources labeled as part of Beerus itself,
+// which are never removed.
+var serviceSelector = selector.MustParse(beerusServiceLabel)
+
+// removeIgnored filters out items from the given slice that match any of the given label selectors or have the built-in "com.github.lucasmendesl.beerus.service" label.
 //
-// It takes a slice of items that satisfy the labeler interface and a variable number of label strings.
+// It takes a slice of items that satisfy the labeler interface and a variable number of label selectors.
 // The labeler interface requires a GetLabels() method that returns a map of labels.
 // The filtered slice is returned as the result.
-func removeIgnored[T labeler](items []T, labels ...string) []T {
-	ignoredList := []string{beerusServiceLabel}
-	ignoredList = append(ignoredList, labels...)
+func removeIgnored[T labeler](items []T, selectors ...selector.Selector) []T {
+	ignoredList := []selector.Selector{serviceSelector}
+	ignoredList = append(ignoredList, selectors...)
 
 	filteredItems := make([]T, 0, len(items))
 	for _, item := range items {
-		if !hasIgnoredLabels(item.GetLabels(), ignoredList) {
+		if !selector.MatchesAny(item.GetLabels(), ignoredList) {
 			filteredItems = append(filteredItems, item)
 		}
 	}
@@ -33,11 +57,49 @@ func removeIgnored[T labeler](items []T, labels ...string) []T {
 	return filteredItems
 }
 
-func hasIgnoredLabels(labels map[string]string, ignoredLabels []string) bool {
-	for _, label := range ignoredLabels {
-		if _, ok := labels[label]; ok {
-			return true
+// removeUntargeted filters out items from the given slice that match none of the given label selectors.
+// The slice is returned untouched when there is no selector.
+func removeUntargeted[T labeler](items []T, selectors ...selector.Selector) []T {
+	if len(selectors) == 0 {
+		return items
+	}
+
+	filteredItems := make([]T, 0, len(items))
+	for _, item := range items {
+		if selector.MatchesAny(item.GetLabels(), selectors) {
+			filteredItems = append(filteredItems, item)
 		}
 	}
-	return false
+
+	return filteredItems
+}
+
+// LabelsSelected reports whether the given item is kept by the same label
+// selectors as the listings: it matches none of the ignored selectors, nor
+// has the built-in service label, and matches one of the target selectors
+// when there is any.
+func LabelsSelected(item labeler, ignored, targets []selector.Selector) bool {
+	items := removeUntargeted(removeIgnored([]labeler{item}, ignored...), targets...)
+	return len(items) == 1
+}
+
+// ParseTTL returns the lifetime declared by the TTLLabel in the given labels.
+// It returns zero when the label is not set, and an error when its value is
+// not a positive Go duration.
+func ParseTTL(labels map[string]string) (time.Duration, error) {
+	value, ok := labels[TTLLabel]
+	if !ok {
+		return 0, nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ttl label %q: %w", value, err)
+	}
+
+	if ttl <= 0 {
+		return 0, fmt.Errorf("invalid ttl label %q: must be positive", value)
+	}
+
+	return ttl, nil
 }
@@ -0,0 +1,113 @@
+package docker_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lucasmendesl/beerus/docker"
+	"github.com/lucasmendesl/beerus/selector"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		expected time.Duration
+		wantErr  wantErr
+	}{
+		{
+			name:     "label not set",
+			labels:   map[string]string{},
+			expected: 0,
+			wantErr:  nopErr,
+		},
+		{
+			name:     "valid duration",
+			labels:   map[string]string{docker.TTLLabel: "6h30m"},
+			expected: 6*time.Hour + 30*time.Minute,
+			wantErr:  nopErr,
+		},
+		{
+			name:   "invalid duration",
+			labels: map[string]string{docker.TTLLabel: "two days"},
+			wantErr: func(t *testing.T, err error) bool {
+				require.ErrorContains(t, err, `invalid ttl label "two days"`)
+				return true
+			},
+		},
+		{
+			name:   "non positive duration",
+			labels: map[string]string{docker.TTLLabel: "-1h"},
+			wantErr: func(t *testing.T, err error) bool {
+				require.EqualError(t, err, `invalid ttl label "-1h": must be positive`)
+				return true
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := docker.ParseTTL(tt.labels)
+			if tt.wantErr(t, err) {
+				return
+			}
+
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestLabelsSelected(t *testing.T) {
+	var (
+		ignored = []selector.Selector{selector.MustParse("env=prod")}
+		targets = []selector.Selector{selector.MustParse("team"), selector.MustParse("owner!=ops")}
+	)
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		ignored  []selector.Selector
+		targets  []selector.Selector
+		expected bool
+	}{
+		{
+			name:     "no selector",
+			labels:   map[string]string{"env": "prod"},
+			expected: true,
+		},
+		{
+			name:     "built-in service label",
+			labels:   map[string]string{"com.github.lucasmendesl.beerus.service": "true"},
+			expected: false,
+		},
+		{
+			name:     "ignored label value",
+			labels:   map[string]string{"env": "prod", "team": "ci"},
+			ignored:  ignored,
+			targets:  targets,
+			expected: false,
+		},
+		{
+			name:     "other label value",
+			labels:   map[string]string{"env": "dev", "team": "ci"},
+			ignored:  ignored,
+			targets:  targets,
+			expected: true,
+		},
+		{
+			name:     "not targeted",
+			labels:   map[string]string{"env": "dev", "owner": "ops"},
+			ignored:  ignored,
+			targets:  targets,
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctr := docker.Container{Labels: tt.labels}
+			require.Equal(t, tt.expected, docker.LabelsSelected(ctr, tt.ignored, tt.targets))
+		})
+	}
+}
@@ -12,8 +12,8 @@ package docker
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
-	types "github.com/docker/docker/api/types"
 	events "github.com/docker/docker/api/types/events"
 	docker "github.com/lucasmendesl/beerus/docker"
 	gomock "go.uber.org/mock/gomock"
@@ -57,10 +57,25 @@ func (mr *MockBeerusContainerAPIMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockBeerusContainerAPI)(nil).Close))
 }
 
+// DiskUsage mocks base method.
+func (m *MockBeerusContainerAPI) DiskUsage(ctx context.Context) (docker.DiskUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiskUsage", ctx)
+	ret0, _ := ret[0].(docker.DiskUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiskUsage indicates an expected call of DiskUsage.
+func (mr *MockBeerusContainerAPIMockRecorder) DiskUsage(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiskUsage", reflect.TypeOf((*MockBeerusContainerAPI)(nil).DiskUsage), ctx)
+}
+
 // FromEvents mocks base method.
-func (m *MockBeerusContainerAPI) FromEvents(ctx context.Context, actions ...events.Action) <-chan docker.EventResult {
+func (m *MockBeerusContainerAPI) FromEvents(ctx context.Context, since time.Time, actions ...events.Action) <-chan docker.EventResult {
 	m.ctrl.T.Helper()
-	varargs := []any{ctx}
+	varargs := []any{ctx, since}
 	for _, a := range actions {
 		varargs = append(varargs, a)
 	}
@@ -70,31 +85,46 @@ func (m *MockBeerusContainerAPI) FromEvents(ctx context.Context, actions ...even
 }
 
 // FromEvents indicates an expected call of FromEvents.
-func (mr *MockBeerusContainerAPIMockRecorder) FromEvents(ctx any, actions ...any) *gomock.Call {
+func (mr *MockBeerusContainerAPIMockRecorder) FromEvents(ctx, since any, actions ...any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]any{ctx}, actions...)
+	varargs := append([]any{ctx, since}, actions...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FromEvents", reflect.TypeOf((*MockBeerusContainerAPI)(nil).FromEvents), varargs...)
 }
 
 // Inspect mocks base method.
-func (m *MockBeerusContainerAPI) Inspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+func (m *MockBeerusContainerAPI) Inspect(ctx context.Context, containerID string, size bool) (docker.Container, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Inspect", ctx, containerID)
-	ret0, _ := ret[0].(types.ContainerJSON)
+	ret := m.ctrl.Call(m, "Inspect", ctx, containerID, size)
+	ret0, _ := ret[0].(docker.Container)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Inspect indicates an expected call of Inspect.
-func (mr *MockBeerusContainerAPIMockRecorder) Inspect(ctx, containerID any) *gomock.Call {
+func (mr *MockBeerusContainerAPIMockRecorder) Inspect(ctx, containerID, size any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Inspect", reflect.TypeOf((*MockBeerusContainerAPI)(nil).Inspect), ctx, containerID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Inspect", reflect.TypeOf((*MockBeerusContainerAPI)(nil).Inspect), ctx, containerID, size)
+}
+
+// InspectImage mocks base method.
+func (m *MockBeerusContainerAPI) InspectImage(ctx context.Context, imageID string) (docker.Image, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InspectImage", ctx, imageID)
+	ret0, _ := ret[0].(docker.Image)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InspectImage indicates an expected call of InspectImage.
+func (mr *MockBeerusContainerAPIMockRecorder) InspectImage(ctx, imageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InspectImage", reflect.TypeOf((*MockBeerusContainerAPI)(nil).InspectImage), ctx, imageID)
 }
 
 // ListContainers mocks base method.
-func (m *MockBeerusContainerAPI) ListContainers(ctx context.Context, concurrency uint8, options ...docker.ListContainersOptions) ([]docker.Container, error) {
+func (m *MockBeerusContainerAPI) ListContainers(ctx context.Context, options ...docker.ListContainersOptions) ([]docker.Container, error) {
 	m.ctrl.T.Helper()
-	varargs := []any{ctx, concurrency}
+	varargs := []any{ctx}
 	for _, a := range options {
 		varargs = append(varargs, a)
 	}
@@ -105,19 +135,20 @@ func (m *MockBeerusContainerAPI) ListContainers(ctx context.Context, concurrency
 }
 
 // ListContainers indicates an expected call of ListContainers.
-func (mr *MockBeerusContainerAPIMockRecorder) ListContainers(ctx, concurrency any, options ...any) *gomock.Call {
+func (mr *MockBeerusContainerAPIMockRecorder) ListContainers(ctx any, options ...any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]any{ctx, concurrency}, options...)
+	varargs := append([]any{ctx}, options...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListContainers", reflect.TypeOf((*MockBeerusContainerAPI)(nil).ListContainers), varargs...)
 }
 
 // ListExpiredImages mocks base method.
-func (m *MockBeerusContainerAPI) ListExpiredImages(ctx context.Context, options docker.ExpiredImageListOptions) ([]docker.Image, error) {
+func (m *MockBeerusContainerAPI) ListExpiredImages(ctx context.Context, options docker.ExpiredImageListOptions) ([]docker.Image, []string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListExpiredImages", ctx, options)
 	ret0, _ := ret[0].([]docker.Image)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].([]string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // ListExpiredImages indicates an expected call of ListExpiredImages.
@@ -126,6 +157,80 @@ func (mr *MockBeerusContainerAPIMockRecorder) ListExpiredImages(ctx, options any
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiredImages", reflect.TypeOf((*MockBeerusContainerAPI)(nil).ListExpiredImages), ctx, options)
 }
 
+// ListExpiredVolumes mocks base method.
+func (m *MockBeerusContainerAPI) ListExpiredVolumes(ctx context.Context, options docker.ExpiredVolumeListOptions) ([]docker.Volume, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpiredVolumes", ctx, options)
+	ret0, _ := ret[0].([]docker.Volume)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpiredVolumes indicates an expected call of ListExpiredVolumes.
+func (mr *MockBeerusContainerAPIMockRecorder) ListExpiredVolumes(ctx, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpiredVolumes", reflect.TypeOf((*MockBeerusContainerAPI)(nil).ListExpiredVolumes), ctx, options)
+}
+
+// ListOrphanedNetworks mocks base method.
+func (m *MockBeerusContainerAPI) ListOrphanedNetworks(ctx context.Context, options docker.OrphanedNetworkListOptions) ([]docker.Network, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrphanedNetworks", ctx, options)
+	ret0, _ := ret[0].([]docker.Network)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrphanedNetworks indicates an expected call of ListOrphanedNetworks.
+func (mr *MockBeerusContainerAPIMockRecorder) ListOrphanedNetworks(ctx, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrphanedNetworks", reflect.TypeOf((*MockBeerusContainerAPI)(nil).ListOrphanedNetworks), ctx, options)
+}
+
+// Ping mocks base method.
+func (m *MockBeerusContainerAPI) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockBeerusContainerAPIMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockBeerusContainerAPI)(nil).Ping), ctx)
+}
+
+// PlanBuildCachePrune mocks base method.
+func (m *MockBeerusContainerAPI) PlanBuildCachePrune(ctx context.Context, options docker.BuildCachePruneOptions) (docker.BuildCachePruneReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PlanBuildCachePrune", ctx, options)
+	ret0, _ := ret[0].(docker.BuildCachePruneReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PlanBuildCachePrune indicates an expected call of PlanBuildCachePrune.
+func (mr *MockBeerusContainerAPIMockRecorder) PlanBuildCachePrune(ctx, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlanBuildCachePrune", reflect.TypeOf((*MockBeerusContainerAPI)(nil).PlanBuildCachePrune), ctx, options)
+}
+
+// PruneBuildCache mocks base method.
+func (m *MockBeerusContainerAPI) PruneBuildCache(ctx context.Context, options docker.BuildCachePruneOptions) (docker.BuildCachePruneReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PruneBuildCache", ctx, options)
+	ret0, _ := ret[0].(docker.BuildCachePruneReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PruneBuildCache indicates an expected call of PruneBuildCache.
+func (mr *MockBeerusContainerAPIMockRecorder) PruneBuildCache(ctx, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneBuildCache", reflect.TypeOf((*MockBeerusContainerAPI)(nil).PruneBuildCache), ctx, options)
+}
+
 // RemoveContainer mocks base method.
 func (m *MockBeerusContainerAPI) RemoveContainer(ctx context.Context, options docker.RemoveContainerOptions) error {
 	m.ctrl.T.Helper()
@@ -153,3 +258,31 @@ func (mr *MockBeerusContainerAPIMockRecorder) RemoveImage(ctx, options any) *gom
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveImage", reflect.TypeOf((*MockBeerusContainerAPI)(nil).RemoveImage), ctx, options)
 }
+
+// RemoveNetwork mocks base method.
+func (m *MockBeerusContainerAPI) RemoveNetwork(ctx context.Context, options docker.RemoveNetworkOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveNetwork", ctx, options)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveNetwork indicates an expected call of RemoveNetwork.
+func (mr *MockBeerusContainerAPIMockRecorder) RemoveNetwork(ctx, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveNetwork", reflect.TypeOf((*MockBeerusContainerAPI)(nil).RemoveNetwork), ctx, options)
+}
+
+// RemoveVolume mocks base method.
+func (m *MockBeerusContainerAPI) RemoveVolume(ctx context.Context, options docker.RemoveVolumeOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveVolume", ctx, options)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveVolume indicates an expected call of RemoveVolume.
+func (mr *MockBeerusContainerAPIMockRecorder) RemoveVolume(ctx, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveVolume", reflect.TypeOf((*MockBeerusContainerAPI)(nil).RemoveVolume), ctx, options)
+}
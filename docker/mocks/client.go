@@ -17,6 +17,8 @@ import (
 	container "github.com/docker/docker/api/types/container"
 	events "github.com/docker/docker/api/types/events"
 	image "github.com/docker/docker/api/types/image"
+	network "github.com/docker/docker/api/types/network"
+	volume "github.com/docker/docker/api/types/volume"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -44,6 +46,21 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 	return m.recorder
 }
 
+// BuildCachePrune mocks base method.
+func (m *MockClient) BuildCachePrune(ctx context.Context, opts types.BuildCachePruneOptions) (*types.BuildCachePruneReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuildCachePrune", ctx, opts)
+	ret0, _ := ret[0].(*types.BuildCachePruneReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuildCachePrune indicates an expected call of BuildCachePrune.
+func (mr *MockClientMockRecorder) BuildCachePrune(ctx, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildCachePrune", reflect.TypeOf((*MockClient)(nil).BuildCachePrune), ctx, opts)
+}
+
 // Close mocks base method.
 func (m *MockClient) Close() error {
 	m.ctrl.T.Helper()
@@ -73,6 +90,22 @@ func (mr *MockClientMockRecorder) ContainerInspect(ctx, containerID any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContainerInspect", reflect.TypeOf((*MockClient)(nil).ContainerInspect), ctx, containerID)
 }
 
+// ContainerInspectWithRaw mocks base method.
+func (m *MockClient) ContainerInspectWithRaw(ctx context.Context, containerID string, getSize bool) (types.ContainerJSON, []byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ContainerInspectWithRaw", ctx, containerID, getSize)
+	ret0, _ := ret[0].(types.ContainerJSON)
+	ret1, _ := ret[1].([]byte)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ContainerInspectWithRaw indicates an expected call of ContainerInspectWithRaw.
+func (mr *MockClientMockRecorder) ContainerInspectWithRaw(ctx, containerID, getSize any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContainerInspectWithRaw", reflect.TypeOf((*MockClient)(nil).ContainerInspectWithRaw), ctx, containerID, getSize)
+}
+
 // ContainerList mocks base method.
 func (m *MockClient) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
 	m.ctrl.T.Helper()
@@ -102,6 +135,21 @@ func (mr *MockClientMockRecorder) ContainerRemove(ctx, containerID, options any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContainerRemove", reflect.TypeOf((*MockClient)(nil).ContainerRemove), ctx, containerID, options)
 }
 
+// DiskUsage mocks base method.
+func (m *MockClient) DiskUsage(ctx context.Context, options types.DiskUsageOptions) (types.DiskUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiskUsage", ctx, options)
+	ret0, _ := ret[0].(types.DiskUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiskUsage indicates an expected call of DiskUsage.
+func (mr *MockClientMockRecorder) DiskUsage(ctx, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiskUsage", reflect.TypeOf((*MockClient)(nil).DiskUsage), ctx, options)
+}
+
 // Events mocks base method.
 func (m *MockClient) Events(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error) {
 	m.ctrl.T.Helper()
@@ -117,6 +165,22 @@ func (mr *MockClientMockRecorder) Events(ctx, options any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Events", reflect.TypeOf((*MockClient)(nil).Events), ctx, options)
 }
 
+// ImageInspectWithRaw mocks base method.
+func (m *MockClient) ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImageInspectWithRaw", ctx, imageID)
+	ret0, _ := ret[0].(types.ImageInspect)
+	ret1, _ := ret[1].([]byte)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ImageInspectWithRaw indicates an expected call of ImageInspectWithRaw.
+func (mr *MockClientMockRecorder) ImageInspectWithRaw(ctx, imageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImageInspectWithRaw", reflect.TypeOf((*MockClient)(nil).ImageInspectWithRaw), ctx, imageID)
+}
+
 // ImageList mocks base method.
 func (m *MockClient) ImageList(ctx context.Context, options image.ListOptions) ([]image.Summary, error) {
 	m.ctrl.T.Helper()
@@ -147,6 +211,50 @@ func (mr *MockClientMockRecorder) ImageRemove(ctx, imageID, options any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImageRemove", reflect.TypeOf((*MockClient)(nil).ImageRemove), ctx, imageID, options)
 }
 
+// NetworkInspect mocks base method.
+func (m *MockClient) NetworkInspect(ctx context.Context, networkID string, options network.InspectOptions) (network.Inspect, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetworkInspect", ctx, networkID, options)
+	ret0, _ := ret[0].(network.Inspect)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetworkInspect indicates an expected call of NetworkInspect.
+func (mr *MockClientMockRecorder) NetworkInspect(ctx, networkID, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetworkInspect", reflect.TypeOf((*MockClient)(nil).NetworkInspect), ctx, networkID, options)
+}
+
+// NetworkList mocks base method.
+func (m *MockClient) NetworkList(ctx context.Context, options network.ListOptions) ([]network.Summary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetworkList", ctx, options)
+	ret0, _ := ret[0].([]network.Summary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NetworkList indicates an expected call of NetworkList.
+func (mr *MockClientMockRecorder) NetworkList(ctx, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetworkList", reflect.TypeOf((*MockClient)(nil).NetworkList), ctx, options)
+}
+
+// NetworkRemove mocks base method.
+func (m *MockClient) NetworkRemove(ctx context.Context, networkID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NetworkRemove", ctx, networkID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NetworkRemove indicates an expected call of NetworkRemove.
+func (mr *MockClientMockRecorder) NetworkRemove(ctx, networkID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NetworkRemove", reflect.TypeOf((*MockClient)(nil).NetworkRemove), ctx, networkID)
+}
+
 // Ping mocks base method.
 func (m *MockClient) Ping(ctx context.Context) (types.Ping, error) {
 	m.ctrl.T.Helper()
@@ -161,3 +269,47 @@ func (mr *MockClientMockRecorder) Ping(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockClient)(nil).Ping), ctx)
 }
+
+// ServerVersion mocks base method.
+func (m *MockClient) ServerVersion(ctx context.Context) (types.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ServerVersion", ctx)
+	ret0, _ := ret[0].(types.Version)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ServerVersion indicates an expected call of ServerVersion.
+func (mr *MockClientMockRecorder) ServerVersion(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServerVersion", reflect.TypeOf((*MockClient)(nil).ServerVersion), ctx)
+}
+
+// VolumeList mocks base method.
+func (m *MockClient) VolumeList(ctx context.Context, options volume.ListOptions) (volume.ListResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VolumeList", ctx, options)
+	ret0, _ := ret[0].(volume.ListResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VolumeList indicates an expected call of VolumeList.
+func (mr *MockClientMockRecorder) VolumeList(ctx, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VolumeList", reflect.TypeOf((*MockClient)(nil).VolumeList), ctx, options)
+}
+
+// VolumeRemove mocks base method.
+func (m *MockClient) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VolumeRemove", ctx, volumeID, force)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VolumeRemove indicates an expected call of VolumeRemove.
+func (mr *MockClientMockRecorder) VolumeRemove(ctx, volumeID, force any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VolumeRemove", reflect.TypeOf((*MockClient)(nil).VolumeRemove), ctx, volumeID, force)
+}
@@ -0,0 +1,109 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+)
+
+const (
+	// customNetworkType filters user-defined networks, leaving out the
+	// networks created by the daemon.
+	customNetworkType = "custom"
+
+	localNetworkScope = "local"
+)
+
+// builtInNetworks are the networks created by the daemon itself, which must
+// never be removed.
+var builtInNetworks = []string{
+	network.NetworkBridge,
+	network.NetworkHost,
+	network.NetworkNone,
+}
+
+// ListOrphanedNetworks retrieves a list of user-defined Docker networks that
+// are considered removable. It fetches the local user-defined networks older
+// than the provided lifetime threshold and inspects each one of them, keeping
+// only the networks without any attached container. The built-in bridge, host
+// and none networks are never returned.
+//
+// Parameters:
+//   - ctx: The context for managing request lifetime and cancellation.
+//   - options: A struct containing criteria for removable networks, such as
+//     the lifetime threshold in hours and the labels to be ignored.
+//
+// Returns:
+//   - A slice of Network containing removable networks.
+//   - An error if there is an issue retrieving or inspecting the networks.
+func (d *dockerClient) ListOrphanedNetworks(ctx context.Context, options OrphanedNetworkListOptions) ([]Network, error) {
+	networks, err := d.cli.NetworkList(ctx, network.ListOptions{
+		Filters: filters.NewArgs(filters.Arg(filterType, customNetworkType)),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("orphaned docker networks error: %w", err)
+	}
+
+	lifetimeThreshold := time.Duration(options.LifetimeThresholdInHours) * time.Hour
+	candidates := make([]Network, 0, len(networks))
+
+	for _, n := range networks {
+		if slices.Contains(builtInNetworks, n.Name) || n.Scope != localNetworkScope {
+			continue
+		}
+
+		if time.Since(n.Created) < lifetimeThreshold {
+			continue
+		}
+
+		candidates = append(candidates, Network{
+			ID:        n.ID,
+			Name:      n.Name,
+			Driver:    n.Driver,
+			Labels:    n.Labels,
+			CreatedAt: n.Created,
+		})
+	}
+
+	candidates = removeIgnored(candidates, options.IgnoreLabels...)
+	orphanedNetworks := make([]Network, 0, len(candidates))
+
+	for _, n := range candidates {
+		// the containers attached to a network are only reported when
+		// inspecting it.
+		details, err := d.cli.NetworkInspect(ctx, n.ID, network.InspectOptions{})
+		if errdefs.IsNotFound(err) {
+			d.log.Debug("Network removed before being inspected, skipping it", "name", n.Name)
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("inspecting network %s error: %w", n.Name, err)
+		}
+
+		if len(details.Containers) == 0 {
+			orphanedNetworks = append(orphanedNetworks, n)
+		}
+	}
+
+	return orphanedNetworks, nil
+}
+
+// RemoveNetwork removes a Docker network by its ID.
+//
+// Parameters:
+//   - ctx: The context for managing request lifetime and cancellation.
+//   - options: A RemoveNetworkOptions struct containing the ID of the network
+//     to be removed.
+//
+// Returns:
+//   - An error if there is an issue removing the network.
+func (d *dockerClient) RemoveNetwork(ctx context.Context, options RemoveNetworkOptions) error {
+	return d.cli.NetworkRemove(ctx, options.NetworkID)
+}
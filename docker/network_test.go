@@ -0,0 +1,156 @@
+package docker_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+	"github.com/lucasmendesl/beerus/docker"
+	mock "github.com/lucasmendesl/beerus/docker/mocks"
+	"github.com/lucasmendesl/beerus/selector"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDockerClient_ListOrphanedNetworks(t *testing.T) {
+	var (
+		ctrl         = gomock.NewController(t)
+		dockerClient = mock.NewMockClient(ctrl)
+		logger       = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		listNetworksError = errors.New("list networks error")
+
+		oldCreatedAt    = time.Now().Add(-time.Hour * 48)
+		recentCreatedAt = time.Now().Add(-time.Minute * 10)
+	)
+	type args struct {
+		ctx     context.Context
+		options docker.OrphanedNetworkListOptions
+	}
+	tests := []struct {
+		name      string
+		args      args
+		mockSetup func()
+		expected  []docker.Network
+		wantErr   wantErr
+	}{
+		{
+			name: "error on list networks",
+			args: args{
+				ctx: context.Background(),
+				options: docker.OrphanedNetworkListOptions{
+					LifetimeThresholdInHours: 24,
+				},
+			},
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					NetworkList(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return(nil, listNetworksError).
+					Times(1)
+			},
+			wantErr: func(t *testing.T, err error) bool {
+				require.EqualError(t, err, "orphaned docker networks error: list networks error")
+				return true
+			},
+		},
+		{
+			name: "only old networks without containers",
+			args: args{
+				ctx: context.Background(),
+				options: docker.OrphanedNetworkListOptions{
+					LifetimeThresholdInHours: 24,
+					IgnoreLabels:             []selector.Selector{selector.MustParse("com.github.lucasmendesl.beerus.testLabel")},
+				},
+			},
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					NetworkList(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return([]network.Summary{
+						{ID: "0d1c3f5a7b9e", Name: "bridge", Scope: "local", Created: oldCreatedAt},
+						{ID: "5e8a2c4d6f10", Name: "ci_default", Driver: "bridge", Scope: "local", Created: oldCreatedAt},
+						{ID: "7a9c1e3b5d2f", Name: "app_default", Driver: "bridge", Scope: "local", Created: oldCreatedAt},
+						{ID: "9b2d4f6a8c1e", Name: "fresh_default", Driver: "bridge", Scope: "local", Created: recentCreatedAt},
+						{ID: "2f4a6c8e0b1d", Name: "ingress", Driver: "overlay", Scope: "swarm", Created: oldCreatedAt},
+						{ID: "4c6e8a0b2d3f", Name: "vanished_default", Driver: "bridge", Scope: "local", Created: oldCreatedAt},
+						{
+							ID:      "6d8f0a2c4e5b",
+							Name:    "kept_default",
+							Driver:  "bridge",
+							Scope:   "local",
+							Created: oldCreatedAt,
+							Labels:  map[string]string{"com.github.lucasmendesl.beerus.testLabel": "true"},
+						},
+					}, nil).
+					Times(1)
+
+				dockerClient.
+					EXPECT().
+					NetworkInspect(
+						gomock.Any(),
+						"5e8a2c4d6f10",
+						gomock.Any(),
+					).
+					Return(network.Inspect{ID: "5e8a2c4d6f10"}, nil).
+					Times(1)
+
+				dockerClient.
+					EXPECT().
+					NetworkInspect(
+						gomock.Any(),
+						"7a9c1e3b5d2f",
+						gomock.Any(),
+					).
+					Return(network.Inspect{
+						ID:         "7a9c1e3b5d2f",
+						Containers: map[string]network.EndpointResource{"cadc6990a82e": {Name: "app"}},
+					}, nil).
+					Times(1)
+
+				dockerClient.
+					EXPECT().
+					NetworkInspect(
+						gomock.Any(),
+						"4c6e8a0b2d3f",
+						gomock.Any(),
+					).
+					Return(network.Inspect{}, errdefs.NotFound(errors.New("network not found"))).
+					Times(1)
+			},
+			wantErr: nopErr,
+			expected: []docker.Network{
+				{
+					ID:        "5e8a2c4d6f10",
+					Name:      "ci_default",
+					Driver:    "bridge",
+					CreatedAt: oldCreatedAt,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			d := docker.New(dockerClient, logger)
+
+			got, err := d.ListOrphanedNetworks(tt.args.ctx, tt.args.options)
+			if tt.wantErr(t, err) {
+				return
+			}
+
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
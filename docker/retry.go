@@ -0,0 +1,88 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/docker/docker/errdefs"
+)
+
+// RetryPolicy describes how the calls to the Docker API failing with a
+// transient error are retried. The delay between the attempts starts at
+// BaseDelay and doubles on every attempt, randomized by up to the Jitter
+// fraction of it in both directions, so concurrent calls do not retry all
+// at once.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of a call, including the
+	// first one. Values lower than two disable the retries.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry.
+	BaseDelay time.Duration
+
+	// Jitter is the fraction, between zero and one, of the delay randomly
+	// added to or removed from it.
+	Jitter float64
+}
+
+// WithRetryPolicy sets the policy retrying the calls to the Docker API that
+// fail with a transient error. By default, the calls are not retried.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(d *dockerClient) {
+		d.retry = p
+	}
+}
+
+// IsTransient reports whether the given error returned by the Docker API is
+// known to be transient, so the call may succeed when retried a bit later:
+// conflicts other than resources still in use, timeouts and an unavailable
+// daemon.
+func IsTransient(err error) bool {
+	switch ClassifyError(err) {
+	case ErrorClassConflict, ErrorClassTimeout:
+		return true
+	case ErrorClassDaemon:
+		return errdefs.IsUnavailable(err)
+	default:
+		return false
+	}
+}
+
+// withRetry runs the given call, retrying it following the retry policy of
+// the client while it fails with a transient error and the context is not
+// canceled. It returns the error of the last attempt.
+func (d *dockerClient) withRetry(ctx context.Context, operation string, call func() error) error {
+	delay := d.retry.BaseDelay
+
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= d.retry.MaxAttempts || !IsTransient(err) || ctx.Err() != nil {
+			return err
+		}
+
+		wait := d.retry.jittered(delay)
+		d.log.Warn("Transient docker api error, retrying", "operation", operation, "attempt", attempt, "retry in", wait, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (retry interrupted: %w)", err, ctx.Err())
+		case <-time.After(wait):
+		}
+
+		delay *= 2
+	}
+}
+
+// jittered returns the given delay randomized by up to the jitter fraction
+// of it in both directions.
+func (p RetryPolicy) jittered(delay time.Duration) time.Duration {
+	jitter := min(max(p.Jitter, 0), 1)
+	if jitter == 0 || delay <= 0 {
+		return delay
+	}
+
+	factor := 1 + jitter*(2*rand.Float64()-1)
+	return time.Duration(float64(delay) * factor)
+}
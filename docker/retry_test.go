@@ -0,0 +1,227 @@
+package docker_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/errdefs"
+	"github.com/lucasmendesl/beerus/docker"
+	mock "github.com/lucasmendesl/beerus/docker/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "conflict",
+			err:      errdefs.Conflict(errors.New("removal of container cadc6990a82e is already in progress")),
+			expected: true,
+		},
+		{
+			name:     "timeout",
+			err:      errdefs.Deadline(errors.New("request timed out")),
+			expected: true,
+		},
+		{
+			name:     "unavailable daemon",
+			err:      errdefs.Unavailable(errors.New("daemon is busy")),
+			expected: true,
+		},
+		{
+			name:     "in use",
+			err:      errdefs.Conflict(errors.New("image is being used by running container cadc6990a82e")),
+			expected: false,
+		},
+		{
+			name:     "not found",
+			err:      errdefs.NotFound(errors.New("no such image")),
+			expected: false,
+		},
+		{
+			name:     "daemon internal error",
+			err:      errdefs.System(errors.New("driver failed")),
+			expected: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, docker.IsTransient(tt.err))
+		})
+	}
+}
+
+func TestDockerClient_RemoveImageRetry(t *testing.T) {
+	var (
+		logger   = slog.New(slog.NewJSONHandler(io.Discard, nil))
+		conflict = errdefs.Conflict(errors.New("removal of image b0757c55a1fd is already in progress"))
+		inUse    = errdefs.Conflict(errors.New("image is being used by stopped container cadc6990a82e"))
+
+		policy = docker.RetryPolicy{
+			MaxAttempts: 3,
+			BaseDelay:   time.Millisecond,
+			Jitter:      0.5,
+		}
+	)
+
+	tests := []struct {
+		name      string
+		policy    docker.RetryPolicy
+		results   []error
+		wantCalls int
+		wantErr   error
+	}{
+		{
+			name:      "transient error retried until it succeeds",
+			policy:    policy,
+			results:   []error{conflict, errdefs.Deadline(errors.New("timeout")), nil},
+			wantCalls: 3,
+		},
+		{
+			name:      "attempts exhausted",
+			policy:    policy,
+			results:   []error{conflict, conflict, conflict},
+			wantCalls: 3,
+			wantErr:   conflict,
+		},
+		{
+			name:      "permanent error not retried",
+			policy:    policy,
+			results:   []error{inUse},
+			wantCalls: 1,
+			wantErr:   inUse,
+		},
+		{
+			name:      "retries disabled by default",
+			results:   []error{conflict},
+			wantCalls: 1,
+			wantErr:   conflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctrl         = gomock.NewController(t)
+				dockerClient = mock.NewMockClient(ctrl)
+				calls        int
+			)
+
+			dockerClient.
+				EXPECT().
+				ImageRemove(
+					gomock.Any(),
+					"b0757c55a1fd",
+					gomock.Any(),
+				).
+				DoAndReturn(func(context.Context, string, image.RemoveOptions) ([]image.DeleteResponse, error) {
+					err := tt.results[calls]
+					calls++
+					return nil, err
+				}).
+				Times(tt.wantCalls)
+
+			options := []docker.Option{}
+			if tt.policy.MaxAttempts > 0 {
+				options = append(options, docker.WithRetryPolicy(tt.policy))
+			}
+
+			d := docker.New(dockerClient, logger, options...)
+			err := d.RemoveImage(context.Background(), docker.RemoveImageOptions{ImageID: "b0757c55a1fd"})
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestDockerClient_RetryCanceled(t *testing.T) {
+	var (
+		ctrl         = gomock.NewController(t)
+		dockerClient = mock.NewMockClient(ctrl)
+		logger       = slog.New(slog.NewJSONHandler(io.Discard, nil))
+		conflict     = errdefs.Conflict(errors.New("removal of container cadc6990a82e is already in progress"))
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the context is canceled while waiting for the retry, which is never
+	// attempted
+	dockerClient.
+		EXPECT().
+		ContainerRemove(
+			gomock.Any(),
+			"cadc6990a82e",
+			gomock.Any(),
+		).
+		DoAndReturn(func(context.Context, string, any) error {
+			cancel()
+			return conflict
+		}).
+		Times(1)
+
+	d := docker.New(dockerClient, logger, docker.WithRetryPolicy(docker.RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Hour,
+	}))
+
+	err := d.RemoveContainer(ctx, docker.RemoveContainerOptions{ContainerID: "cadc6990a82e"})
+	require.ErrorIs(t, err, conflict)
+}
+
+func TestDockerClient_ListExpiredImagesRetry(t *testing.T) {
+	var (
+		ctrl         = gomock.NewController(t)
+		dockerClient = mock.NewMockClient(ctrl)
+		logger       = slog.New(slog.NewJSONHandler(io.Discard, nil))
+	)
+
+	gomock.InOrder(
+		dockerClient.
+			EXPECT().
+			ImageList(
+				gomock.Any(),
+				gomock.Any(),
+			).
+			Return(nil, errdefs.Unavailable(errors.New("daemon is busy"))).
+			Times(1),
+		dockerClient.
+			EXPECT().
+			ImageList(
+				gomock.Any(),
+				gomock.Any(),
+			).
+			Return([]image.Summary{
+				{
+					ID:       "b0757c55a1fd",
+					RepoTags: []string{"<none>:<none>"},
+					Created:  time.Now().Unix(),
+				},
+			}, nil).
+			Times(1),
+	)
+
+	d := docker.New(dockerClient, logger, docker.WithRetryPolicy(docker.RetryPolicy{
+		MaxAttempts: 2,
+		BaseDelay:   time.Millisecond,
+	}))
+
+	images, _, err := d.ListExpiredImages(context.Background(), docker.ExpiredImageListOptions{LifetimeThresholdInDays: 1})
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	require.Equal(t, "b0757c55a1fd", images[0].ID)
+}
@@ -0,0 +1,169 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+)
+
+// Runtime is the container engine serving the Docker API. Podman serves a
+// Docker-compatible API, with a few differences the client adapts to.
+type Runtime string
+
+const (
+	// RuntimeAuto means that the runtime is detected from the version
+	// reported by the daemon.
+	RuntimeAuto Runtime = "auto"
+
+	// RuntimeDocker means that the API is served by the Docker engine.
+	RuntimeDocker Runtime = "docker"
+
+	// RuntimePodman means that the API is served by the Docker-compatible
+	// socket of Podman.
+	RuntimePodman Runtime = "podman"
+)
+
+// podmanComponent is the name of the component reported by Podman in the
+// version response.
+const podmanComponent = "podman"
+
+// podmanActionDied is the action of the container exit events reported by
+// some Podman releases instead of die.
+const podmanActionDied events.Action = "died"
+
+// ParseRuntime returns the runtime with the given name, where an empty name
+// means that the runtime is detected. It returns an error for an unknown
+// runtime.
+func ParseRuntime(name string) (Runtime, error) {
+	switch r := Runtime(strings.ToLower(name)); r {
+	case "":
+		return RuntimeAuto, nil
+	case RuntimeAuto, RuntimeDocker, RuntimePodman:
+		return r, nil
+	default:
+		return "", fmt.Errorf("unknown container runtime %q", name)
+	}
+}
+
+// WithRuntime sets the runtime serving the Docker API. When it is
+// RuntimeAuto, the runtime is detected from the version reported by the
+// daemon on the first call that depends on it. By default, the API is
+// expected to be served by the Docker engine.
+func WithRuntime(r Runtime) Option {
+	return func(d *dockerClient) {
+		d.runtime = r
+	}
+}
+
+// isPodman reports whether the given version response was returned by
+// Podman, which lists itself among the components, such as "Podman Engine".
+func isPodman(v types.Version) bool {
+	return slices.ContainsFunc(v.Components, func(c types.ComponentVersion) bool {
+		return strings.Contains(strings.ToLower(c.Name), podmanComponent)
+	})
+}
+
+// resolveRuntime returns the runtime serving the Docker API, detecting it
+// from the version reported by the daemon the first time when it is not
+// known. A failed detection is attempted again on the next call.
+func (d *dockerClient) resolveRuntime(ctx context.Context) (Runtime, error) {
+	d.runtimeMu.Lock()
+	defer d.runtimeMu.Unlock()
+
+	if d.runtime != RuntimeAuto {
+		return d.runtime, nil
+	}
+
+	var version types.Version
+	err := d.withRetry(ctx, "detect runtime", func() (err error) {
+		version, err = d.cli.ServerVersion(ctx)
+		return err
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("detecting container runtime error: %w", err)
+	}
+
+	d.runtime = RuntimeDocker
+	if isPodman(version) {
+		d.runtime = RuntimePodman
+	}
+
+	d.log.Info("Detected container runtime", "runtime", d.runtime, "version", version.Version, "api version", version.APIVersion)
+	return d.runtime, nil
+}
+
+// statusFilters returns the status filter values understood by the given
+// runtime matching the given statuses. Podman has no dead state and rejects
+// it, and its stopped containers, not cleaned up yet, are exited ones.
+func statusFilters(r Runtime, statuses []ContainerStatus) []string {
+	values := make([]string, 0, len(statuses))
+	for _, s := range statuses {
+		switch {
+		case r != RuntimePodman:
+			values = append(values, string(s))
+		case s == ContainerStatusDead:
+			continue
+		case s == ContainerStatusExited:
+			values = append(values, string(s), "stopped")
+		default:
+			values = append(values, string(s))
+		}
+	}
+
+	return values
+}
+
+// containerStatus returns the status of a container reported by the given
+// runtime, mapping the Podman states that are not translated to the Docker
+// ones by every Podman release.
+func containerStatus(r Runtime, state string) ContainerStatus {
+	if r != RuntimePodman {
+		return ContainerStatus(state)
+	}
+
+	switch state {
+	case "stopped":
+		return ContainerStatusExited
+	case "configured", "initialized":
+		return ContainerStatusCreated
+	default:
+		return ContainerStatus(state)
+	}
+}
+
+// eventActions returns the actions of the events filter understood by the
+// given runtime matching the given actions, adding the died action reported
+// by some Podman releases for the container exits.
+func eventActions(r Runtime, actions []events.Action) []events.Action {
+	if r == RuntimePodman && slices.Contains(actions, events.ActionDie) {
+		return append(slices.Clone(actions), podmanActionDied)
+	}
+
+	return actions
+}
+
+// eventMessage returns the given event message, with the died action of the
+// container exits reported by some Podman releases replaced by die.
+func eventMessage(r Runtime, msg events.Message) events.Message {
+	if r == RuntimePodman && msg.Action == podmanActionDied {
+		msg.Action = events.ActionDie
+	}
+
+	return msg
+}
+
+// danglingImage reports whether an image with the given tags reported by the
+// given runtime is dangling. Podman lists the dangling images without any
+// tag, instead of the <none>:<none> one.
+func danglingImage(r Runtime, tags []string) bool {
+	if r == RuntimePodman && len(tags) == 0 {
+		return true
+	}
+
+	return slices.Contains(tags, danglingImageTag)
+}
@@ -0,0 +1,315 @@
+package docker_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/lucasmendesl/beerus/docker"
+	mock "github.com/lucasmendesl/beerus/docker/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// podmanAPIVersion is the Docker API version served by the recorded Podman
+// responses.
+const podmanAPIVersion = "1.41"
+
+// podmanRecorder replays the Podman API responses recorded under
+// testdata/podman, keeping the requests it received.
+type podmanRecorder struct {
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (p *podmanRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.requests = append(p.requests, r.Clone(context.Background()))
+	p.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v"+podmanAPIVersion)
+
+	var fixture string
+	switch {
+	case r.Method == http.MethodGet && path == "/version":
+		fixture = "version.json"
+	case r.Method == http.MethodGet && path == "/containers/json":
+		fixture = "containers.json"
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/containers/"):
+		fixture = filepath.Join("containers", strings.TrimSuffix(strings.TrimPrefix(path, "/containers/"), "/json")+".json")
+	case r.Method == http.MethodGet && path == "/images/json":
+		fixture = "images.json"
+	case r.Method == http.MethodGet && path == "/events":
+		fixture = "events.json"
+	case r.Method == http.MethodDelete && path == "/images/localhost/app:1.0":
+		// the tag was already removed by another client
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, `{"cause":"image not known","message":"localhost/app:1.0: image not known","response":404}`)
+		return
+	case r.Method == http.MethodDelete && strings.HasPrefix(path, "/images/"):
+		fixture = "image_remove.json"
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := os.ReadFile(filepath.Join("testdata", "podman", fixture))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// received returns the requests received with the given method and path.
+func (p *podmanRecorder) received(method, path string) []*http.Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var requests []*http.Request
+	for _, r := range p.requests {
+		if r.Method == method && r.URL.Path == "/v"+podmanAPIVersion+path {
+			requests = append(requests, r)
+		}
+	}
+
+	return requests
+}
+
+// newPodmanClient returns a client detecting the runtime of a server
+// replaying the recorded Podman API responses.
+func newPodmanClient(t *testing.T) (docker.BeerusContainerAPI, *podmanRecorder) {
+	t.Helper()
+
+	recorder := &podmanRecorder{}
+	server := httptest.NewServer(recorder)
+	t.Cleanup(server.Close)
+
+	cli, err := client.NewClientWithOpts(
+		client.WithHost("tcp://"+server.Listener.Addr().String()),
+		client.WithVersion(podmanAPIVersion),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { cli.Close() })
+
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	return docker.New(cli, logger, docker.WithRuntime(docker.RuntimeAuto)), recorder
+}
+
+// requestFilters returns the filters of the given request.
+func requestFilters(t *testing.T, r *http.Request) filters.Args {
+	t.Helper()
+
+	args, err := filters.FromJSON(r.URL.Query().Get("filters"))
+	require.NoError(t, err)
+	return args
+}
+
+func TestParseRuntime(t *testing.T) {
+	tests := []struct {
+		name     string
+		runtime  string
+		expected docker.Runtime
+		wantErr  bool
+	}{
+		{name: "empty runtime", runtime: "", expected: docker.RuntimeAuto},
+		{name: "auto runtime", runtime: "auto", expected: docker.RuntimeAuto},
+		{name: "docker runtime", runtime: "docker", expected: docker.RuntimeDocker},
+		{name: "podman runtime", runtime: "Podman", expected: docker.RuntimePodman},
+		{name: "unknown runtime", runtime: "containerd", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtime, err := docker.ParseRuntime(tt.runtime)
+			if tt.wantErr {
+				require.ErrorContains(t, err, `unknown container runtime "containerd"`)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, runtime)
+		})
+	}
+}
+
+func TestDockerClient_PodmanListContainers(t *testing.T) {
+	api, recorder := newPodmanClient(t)
+
+	containers, err := api.ListContainers(context.Background(), docker.WithContainerStatus(
+		docker.ContainerStatusExited,
+		docker.ContainerStatusDead,
+		docker.ContainerStatusCreated,
+	))
+	require.NoError(t, err)
+
+	// podman rejects the dead status, and lists its stopped containers
+	// apart from the exited ones
+	listRequests := recorder.received(http.MethodGet, "/containers/json")
+	require.Len(t, listRequests, 1)
+	require.ElementsMatch(t, []string{"exited", "stopped", "created"}, requestFilters(t, listRequests[0]).Get("status"))
+
+	statuses := make(map[string]docker.ContainerStatus, len(containers))
+	for _, c := range containers {
+		statuses[c.ID] = c.Status
+	}
+
+	require.Equal(t, map[string]docker.ContainerStatus{
+		"3f1c2a9d7e5b": docker.ContainerStatusExited,
+		"8e4d1b6c0a2f": docker.ContainerStatusExited,
+		"5a7f9c3e1d8b": docker.ContainerStatusCreated,
+	}, statuses)
+
+	// the runtime is only detected once
+	containers, err = api.ListContainers(context.Background(), docker.WithContainerStatus(docker.ContainerStatusDead))
+	require.NoError(t, err)
+	require.Empty(t, containers)
+
+	require.Len(t, recorder.received(http.MethodGet, "/containers/json"), 1)
+	require.Len(t, recorder.received(http.MethodGet, "/version"), 1)
+}
+
+func TestDockerClient_PodmanListExpiredImages(t *testing.T) {
+	api, _ := newPodmanClient(t)
+
+	images, _, err := api.ListExpiredImages(context.Background(), docker.ExpiredImageListOptions{
+		DanglingOnly: true,
+	})
+	require.NoError(t, err)
+
+	// podman lists the dangling images without any tag
+	require.Len(t, images, 1)
+	require.Equal(t, "sha256:9f8e7d6c5b4a", images[0].ID)
+	require.True(t, images[0].Dangling)
+}
+
+func TestDockerClient_PodmanFromEvents(t *testing.T) {
+	api, recorder := newPodmanClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	eventCh := api.FromEvents(ctx, time.Time{}, events.ActionDie, events.ActionUnTag)
+
+	var received []events.Message
+	for result := range eventCh {
+		if result.Err != nil {
+			break
+		}
+		received = append(received, result.Message)
+	}
+
+	require.Len(t, received, 2)
+	require.Equal(t, events.ActionDie, received[0].Action)
+	require.Equal(t, "3f1c2a9d7e5b", received[0].Actor.ID)
+	require.Equal(t, events.ActionUnTag, received[1].Action)
+
+	eventRequests := recorder.received(http.MethodGet, "/events")
+	require.Len(t, eventRequests, 1)
+	require.ElementsMatch(t, []string{"die", "died", "untag"}, requestFilters(t, eventRequests[0]).Get("event"))
+}
+
+func TestDockerClient_PodmanRemoveImage(t *testing.T) {
+	api, recorder := newPodmanClient(t)
+
+	err := api.RemoveImage(context.Background(), docker.RemoveImageOptions{
+		ImageID: "sha256:1a2b3c4d5e6f",
+		Tags:    []string{"localhost/app:1.0", "localhost/app:stable"},
+		Force:   true,
+	})
+	require.NoError(t, err)
+
+	// a forced removal would remove the containers using the image, so the
+	// tags are removed one by one instead, ignoring the ones already removed
+	removals := recorder.received(http.MethodDelete, "/images/localhost/app:1.0")
+	removals = append(removals, recorder.received(http.MethodDelete, "/images/localhost/app:stable")...)
+	require.Len(t, removals, 2)
+
+	for _, r := range removals {
+		require.NotEqual(t, "1", r.URL.Query().Get("force"))
+	}
+
+	require.Empty(t, recorder.received(http.MethodDelete, "/images/sha256:1a2b3c4d5e6f"))
+}
+
+func TestDockerClient_RuntimeDetection(t *testing.T) {
+	var (
+		ctrl   = gomock.NewController(t)
+		logger = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		versionErr = errors.New("version error")
+	)
+
+	tests := []struct {
+		name      string
+		setupMock func(dockerApi *mock.MockClient)
+		wantErr   error
+	}{
+		{
+			name: "docker engine keeps the dead status",
+			setupMock: func(dockerApi *mock.MockClient) {
+				dockerApi.
+					EXPECT().
+					ServerVersion(gomock.Any()).
+					Return(types.Version{Components: []types.ComponentVersion{{Name: "Engine"}}}, nil).
+					Times(1)
+
+				dockerApi.
+					EXPECT().
+					ContainerList(gomock.Any(), gomock.Cond(func(options container.ListOptions) bool {
+						return options.Filters.ExactMatch("status", "dead")
+					})).
+					Return([]types.Container{}, nil).
+					Times(1)
+			},
+		},
+		{
+			name: "failed detection",
+			setupMock: func(dockerApi *mock.MockClient) {
+				dockerApi.
+					EXPECT().
+					ServerVersion(gomock.Any()).
+					Return(types.Version{}, versionErr).
+					Times(1)
+			},
+			wantErr: versionErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dockerApi := mock.NewMockClient(ctrl)
+			tt.setupMock(dockerApi)
+
+			api := docker.New(dockerApi, logger,
+				docker.WithRuntime(docker.RuntimeAuto),
+				docker.WithRetryPolicy(docker.RetryPolicy{MaxAttempts: 1}),
+			)
+
+			_, err := api.ListContainers(context.Background(), docker.WithContainerStatus(docker.ContainerStatusDead))
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package docker
+
+import (
+	"context"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// WithRequestTimeout sets the time limit of each request to the Docker API,
+// apart from the event stream, which is meant to stay open. Each retried
+// attempt has its own time limit. By default, the requests have no time
+// limit.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(d *dockerClient) {
+		if timeout > 0 {
+			d.cli = &timeoutClient{Client: d.cli, timeout: timeout}
+		}
+	}
+}
+
+// timeoutClient is a Client bounding each request by a time limit, apart
+// from the event stream.
+type timeoutClient struct {
+	Client
+	timeout time.Duration
+}
+
+func (t *timeoutClient) ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.Client.ImageInspectWithRaw(ctx, imageID)
+}
+
+func (t *timeoutClient) ImageList(ctx context.Context, options image.ListOptions) ([]image.Summary, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.Client.ImageList(ctx, options)
+}
+
+func (t *timeoutClient) ImageRemove(ctx context.Context, imageID string, options image.RemoveOptions) ([]image.DeleteResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.Client.ImageRemove(ctx, imageID, options)
+}
+
+func (t *timeoutClient) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.Client.ContainerInspect(ctx, containerID)
+}
+
+func (t *timeoutClient) ContainerInspectWithRaw(ctx context.Context, containerID string, getSize bool) (types.ContainerJSON, []byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.Client.ContainerInspectWithRaw(ctx, containerID, getSize)
+}
+
+func (t *timeoutClient) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.Client.ContainerRemove(ctx, containerID, options)
+}
+
+func (t *timeoutClient) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.Client.ContainerList(ctx, options)
+}
+
+func (t *timeoutClient) VolumeList(ctx context.Context, options volume.ListOptions) (volume.ListResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.Client.VolumeList(ctx, options)
+}
+
+func (t *timeoutClient) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.Client.VolumeRemove(ctx, volumeID, force)
+}
+
+func (t *timeoutClient) NetworkList(ctx context.Context, options network.ListOptions) ([]network.Summary, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.Client.NetworkList(ctx, options)
+}
+
+func (t *timeoutClient) NetworkInspect(ctx context.Context, networkID string, options network.InspectOptions) (network.Inspect, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.Client.NetworkInspect(ctx, networkID, options)
+}
+
+func (t *timeoutClient) NetworkRemove(ctx context.Context, networkID string) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.Client.NetworkRemove(ctx, networkID)
+}
+
+func (t *timeoutClient) BuildCachePrune(ctx context.Context, opts types.BuildCachePruneOptions) (*types.BuildCachePruneReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.Client.BuildCachePrune(ctx, opts)
+}
+
+func (t *timeoutClient) DiskUsage(ctx context.Context, options types.DiskUsageOptions) (types.DiskUsage, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.Client.DiskUsage(ctx, options)
+}
+
+func (t *timeoutClient) Ping(ctx context.Context) (types.Ping, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.Client.Ping(ctx)
+}
+
+func (t *timeoutClient) ServerVersion(ctx context.Context) (types.Version, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	return t.Client.ServerVersion(ctx)
+}
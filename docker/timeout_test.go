@@ -0,0 +1,124 @@
+package docker_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/errdefs"
+	"github.com/lucasmendesl/beerus/docker"
+	mock "github.com/lucasmendesl/beerus/docker/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDockerClient_RequestTimeout(t *testing.T) {
+	var (
+		ctrl         = gomock.NewController(t)
+		dockerClient = mock.NewMockClient(ctrl)
+		logger       = slog.New(slog.NewJSONHandler(io.Discard, nil))
+	)
+
+	// each attempt is bounded by its own time limit, and the attempts timing
+	// out are retried
+	gomock.InOrder(
+		dockerClient.
+			EXPECT().
+			ContainerRemove(
+				gomock.Any(),
+				"cadc6990a82e",
+				gomock.Any(),
+			).
+			DoAndReturn(func(ctx context.Context, _ string, _ container.RemoveOptions) error {
+				_, ok := ctx.Deadline()
+				require.True(t, ok)
+
+				<-ctx.Done()
+				return ctx.Err()
+			}).
+			Times(1),
+		dockerClient.
+			EXPECT().
+			ContainerRemove(
+				gomock.Any(),
+				"cadc6990a82e",
+				gomock.Any(),
+			).
+			DoAndReturn(func(ctx context.Context, _ string, _ container.RemoveOptions) error {
+				require.NoError(t, ctx.Err())
+				return nil
+			}).
+			Times(1),
+	)
+
+	d := docker.New(dockerClient, logger,
+		docker.WithRequestTimeout(10*time.Millisecond),
+		docker.WithRetryPolicy(docker.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}),
+	)
+
+	err := d.RemoveContainer(context.Background(), docker.RemoveContainerOptions{ContainerID: "cadc6990a82e"})
+	require.NoError(t, err)
+}
+
+func TestDockerClient_RequestTimeoutExceeded(t *testing.T) {
+	var (
+		ctrl         = gomock.NewController(t)
+		dockerClient = mock.NewMockClient(ctrl)
+		logger       = slog.New(slog.NewJSONHandler(io.Discard, nil))
+	)
+
+	dockerClient.
+		EXPECT().
+		Ping(gomock.Any()).
+		DoAndReturn(func(ctx context.Context) (types.Ping, error) {
+			<-ctx.Done()
+			return types.Ping{}, ctx.Err()
+		}).
+		Times(1)
+
+	d := docker.New(dockerClient, logger, docker.WithRequestTimeout(10*time.Millisecond))
+
+	err := d.Ping(context.Background())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, docker.ErrorClassTimeout, docker.ClassifyError(err))
+}
+
+func TestDockerClient_RequestTimeoutEventStream(t *testing.T) {
+	var (
+		ctrl         = gomock.NewController(t)
+		dockerClient = mock.NewMockClient(ctrl)
+		logger       = slog.New(slog.NewJSONHandler(io.Discard, nil))
+		streamErr    = errdefs.System(errors.New("event stream closed"))
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the event stream is meant to stay open, so it has no time limit
+	dockerClient.
+		EXPECT().
+		Events(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		DoAndReturn(func(ctx context.Context, _ events.ListOptions) (<-chan events.Message, <-chan error) {
+			_, ok := ctx.Deadline()
+			require.False(t, ok)
+
+			errCh := make(chan error, 1)
+			errCh <- streamErr
+			return make(chan events.Message), errCh
+		}).
+		Times(1)
+
+	d := docker.New(dockerClient, logger, docker.WithRequestTimeout(10*time.Millisecond))
+
+	got := <-d.FromEvents(ctx, time.Time{}, events.ActionDie)
+	require.ErrorIs(t, got.Err, streamErr)
+}
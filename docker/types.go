@@ -6,18 +6,27 @@ import (
 	"context"
 	"time"
 
-	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/events"
+	"github.com/lucasmendesl/beerus/selector"
 )
 
 type BeerusContainerAPI interface {
-	Inspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
-	ListContainers(ctx context.Context, concurrency uint8, options ...ListContainersOptions) ([]Container, error)
+	Inspect(ctx context.Context, containerID string, size bool) (Container, error)
+	ListContainers(ctx context.Context, options ...ListContainersOptions) ([]Container, error)
 	RemoveContainer(ctx context.Context, options RemoveContainerOptions) error
-	ListExpiredImages(ctx context.Context, options ExpiredImageListOptions) ([]Image, error)
+	InspectImage(ctx context.Context, imageID string) (Image, error)
+	ListExpiredImages(ctx context.Context, options ExpiredImageListOptions) ([]Image, []string, error)
 	RemoveImage(ctx context.Context, options RemoveImageOptions) error
-	FromEvents(ctx context.Context, actions ...events.Action) <-chan EventResult
+	ListExpiredVolumes(ctx context.Context, options ExpiredVolumeListOptions) ([]Volume, error)
+	RemoveVolume(ctx context.Context, options RemoveVolumeOptions) error
+	ListOrphanedNetworks(ctx context.Context, options OrphanedNetworkListOptions) ([]Network, error)
+	RemoveNetwork(ctx context.Context, options RemoveNetworkOptions) error
+	PruneBuildCache(ctx context.Context, options BuildCachePruneOptions) (BuildCachePruneReport, error)
+	PlanBuildCachePrune(ctx context.Context, options BuildCachePruneOptions) (BuildCachePruneReport, error)
+	DiskUsage(ctx context.Context) (DiskUsage, error)
+	FromEvents(ctx context.Context, since time.Time, actions ...events.Action) <-chan EventResult
+	Ping(ctx context.Context) error
 	Close() error
 }
 
@@ -49,10 +58,52 @@ const (
 	ContainerStatusCreated ContainerStatus = "created"
 )
 
-// ExpiredImageListOptions represents criteria for removable images.
+// ExpiredImageListOptions represents criteria for removable images. LastUsed
+// maps image IDs to the last time they were used by a container, and All
+// lists every image, whatever its age, leaving the selection to the caller.
+// When FirstSeen is set, it maps image IDs to the first time the caller saw
+// them, the images missing from it being seen for the first time, and the
+// TTL of an image never starts before it was first seen, so an old image
+// pulled recently is given its whole TTL.
+// Repositories and Tags select the images by the repositories and the tags
+// they are tagged with, the dangling images being always selected. When
+// TargetLabels is set, only the images matching one of its selectors are
+// listed.
 type ExpiredImageListOptions struct {
 	LifetimeThresholdInDays uint16
-	IgnoreLabels            []string
+	IgnoreLabels            []selector.Selector
+	TargetLabels            []selector.Selector
+	Repositories            NameMatcher
+	Tags                    NameMatcher
+	KeepLastTags            uint16
+	DanglingOnly            bool
+	All                     bool
+	LastUsed                map[string]time.Time
+	FirstSeen               map[string]time.Time
+}
+
+// ExpiredVolumeListOptions represents criteria for removable volumes. Only
+// anonymous volumes are considered unless IncludeNamed is set.
+type ExpiredVolumeListOptions struct {
+	LifetimeThresholdInDays uint16
+	IgnoreLabels            []selector.Selector
+	IncludeNamed            bool
+}
+
+// OrphanedNetworkListOptions represents criteria for removable networks.
+type OrphanedNetworkListOptions struct {
+	LifetimeThresholdInHours uint16
+	IgnoreLabels             []selector.Selector
+}
+
+// BuildCachePruneOptions represents options for pruning the build cache.
+// KeepStorage is the amount of bytes of build cache to be kept, and records
+// newer than the lifetime threshold are never pruned. Shared and internal
+// records are only pruned when IncludeShared is set.
+type BuildCachePruneOptions struct {
+	KeepStorage             int64
+	LifetimeThresholdInDays uint16
+	IncludeShared           bool
 }
 
 // RemoveContainerOptions represents options for removing a container.
@@ -62,30 +113,103 @@ type RemoveContainerOptions struct {
 	RemoveLinks   bool
 }
 
-// RemoveImageOptions represents options for removing an image.
+// RemoveImageOptions represents options for removing an image. Tags holds
+// the tags of the image, removed one by one instead of forcing the removal
+// on Podman.
 type RemoveImageOptions struct {
 	ImageID string
+	Tags    []string
 	Force   bool
 }
 
+// RemoveVolumeOptions represents options for removing a volume.
+type RemoveVolumeOptions struct {
+	Name  string
+	Force bool
+}
+
+// RemoveNetworkOptions represents options for removing a network.
+type RemoveNetworkOptions struct {
+	NetworkID string
+}
+
 // Container represents a Docker container, containing its ID, status, image name,
-// and image ID.
+// and image ID. ExitCode is the exit code of its last run, and TTL is the
+// lifetime declared by the TTLLabel, or zero when the container does not
+// declare one.
 type Container struct {
 	ID            string
+	Names         []string
 	Image         string
 	ImageID       string
 	Labels        map[string]string
 	CreatedAt     time.Time
 	Status        ContainerStatus
+	ExitCode      int
 	RestartCount  int
 	RestartPolicy container.RestartPolicy
+	Size          int64
+	TTL           time.Duration
 }
 
 // Image represents a Docker image, containing its ID, tags, and labels.
+// Dangling reports whether the image has no tag pointing to it, Size is the
+// size of the image in bytes, LastUsedAt is the last time the image was used
+// by a container, or its creation time when unknown, and TTL is the lifetime
+// declared by the TTLLabel, or zero when the image does not declare one.
 type Image struct {
-	ID     string
-	Labels map[string]string
-	Tags   []string
+	ID         string
+	Labels     map[string]string
+	Tags       []string
+	Dangling   bool
+	Size       int64
+	LastUsedAt time.Time
+	TTL        time.Duration
+}
+
+// Volume represents a Docker volume, containing its name, labels and creation
+// time. Anonymous reports whether the volume was created without a name, and
+// Size is the size of the volume in bytes, or -1 when it is unknown.
+type Volume struct {
+	Name      string
+	Labels    map[string]string
+	CreatedAt time.Time
+	Anonymous bool
+	Size      int64
+}
+
+// Network represents a user-defined Docker network, containing its ID, name,
+// driver, labels and creation time.
+type Network struct {
+	ID        string
+	Name      string
+	Driver    string
+	Labels    map[string]string
+	CreatedAt time.Time
+}
+
+// BuildCachePruneReport represents the outcome of a build cache prune,
+// containing the IDs of the deleted cache records and the amount of bytes
+// reclaimed.
+type BuildCachePruneReport struct {
+	CachesDeleted  []string
+	SpaceReclaimed uint64
+}
+
+// DiskUsage represents the disk space used by the Docker data-root, in
+// bytes, for each kind of resource. Images holds the size of the image
+// layers, counting shared layers once, and BuildCache only accounts for the
+// records not shared with the images.
+type DiskUsage struct {
+	Images     int64
+	Containers int64
+	Volumes    int64
+	BuildCache int64
+}
+
+// Total returns the disk space used by all the resources, in bytes.
+func (u DiskUsage) Total() int64 {
+	return u.Images + u.Containers + u.Volumes + u.BuildCache
 }
 
 // EventResult represents a result from the event stream, which may contain
@@ -97,8 +221,12 @@ type EventResult struct {
 
 // ListContainersParams represents parameters for filtering and retrieving a list
 // of Docker containers. The parameters are used by the ListContainers method to
-// filter the containers by status and labels.
+// filter the containers by status and labels. When Size is set, the size of
+// the writable layer of each container is also computed by the daemon.
 type ListContainersParams struct {
-	Status []ContainerStatus
-	Label  []string
+	Status      []ContainerStatus
+	Label       []selector.Selector
+	TargetLabel []selector.Selector
+	Names       NameMatcher
+	Size        bool
 }
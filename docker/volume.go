@@ -0,0 +1,147 @@
+package docker
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/volume"
+)
+
+const (
+	danglingFilter = "dangling"
+
+	// anonymousVolumeLabel is set by the daemon on volumes created without a
+	// name, since Docker Engine 23.0.
+	anonymousVolumeLabel = "com.docker.volume.anonymous"
+
+	// anonymousVolumeNameLength is the length of the random hex name given by
+	// the daemon to anonymous volumes.
+	anonymousVolumeNameLength = 64
+)
+
+// ListExpiredVolumes retrieves a list of Docker volumes that are considered
+// removable. Only dangling volumes, which are not referenced by any container,
+// are fetched, and they are filtered to keep those older than the provided
+// lifetime threshold. Named volumes are only considered when requested, as
+// they usually hold data that is meant to outlive containers. The listing
+// does not report the size of the volumes, so it is requested from the disk
+// usage endpoint when there are removable volumes, and left unknown when the
+// daemon cannot compute it.
+//
+// Parameters:
+//   - ctx: The context for managing request lifetime and cancellation.
+//   - options: A struct containing criteria for removable volumes, such as
+//     the lifetime threshold in days and the labels to be ignored.
+//
+// Returns:
+//   - A slice of Volume containing removable volumes.
+//   - An error if there is an issue retrieving the list of volumes.
+func (d *dockerClient) ListExpiredVolumes(ctx context.Context, options ExpiredVolumeListOptions) ([]Volume, error) {
+	response, err := d.cli.VolumeList(ctx, volume.ListOptions{
+		Filters: filters.NewArgs(filters.Arg(danglingFilter, "true")),
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("expired docker volumes error: %w", err)
+	}
+
+	removableVolumes := make([]Volume, 0, len(response.Volumes))
+	for _, v := range response.Volumes {
+		anonymous := isAnonymousVolume(v)
+		if !anonymous && !options.IncludeNamed {
+			continue
+		}
+
+		createdAt, err := time.Parse(time.RFC3339, v.CreatedAt)
+		if err != nil {
+			d.log.Warn("Failed to parse volume creation time, skipping it", "name", v.Name, "error", err)
+			continue
+		}
+
+		if !isExpired(createdAt, options.LifetimeThresholdInDays) {
+			continue
+		}
+
+		removableVolumes = append(removableVolumes, Volume{
+			Name:      v.Name,
+			Labels:    v.Labels,
+			CreatedAt: createdAt,
+			Anonymous: anonymous,
+			Size:      -1,
+		})
+	}
+
+	removableVolumes = removeIgnored(removableVolumes, options.IgnoreLabels...)
+	if len(removableVolumes) == 0 {
+		return removableVolumes, nil
+	}
+
+	sizes, err := d.volumeSizes(ctx)
+	if err != nil {
+		d.log.Warn("Failed to compute the size of the volumes, reporting it as unknown", "error", err)
+		return removableVolumes, nil
+	}
+
+	for i, v := range removableVolumes {
+		if size, ok := sizes[v.Name]; ok {
+			removableVolumes[i].Size = size
+		}
+	}
+
+	return removableVolumes, nil
+}
+
+// volumeSizes returns the size in bytes of each volume, keyed by name, as
+// computed by the disk usage endpoint. The size is -1 for the volumes the
+// daemon could not compute it for.
+func (d *dockerClient) volumeSizes(ctx context.Context) (map[string]int64, error) {
+	du, err := d.cli.DiskUsage(ctx, types.DiskUsageOptions{
+		Types: []types.DiskUsageObject{types.VolumeObject},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("docker volume disk usage error: %w", err)
+	}
+
+	sizes := make(map[string]int64, len(du.Volumes))
+	for _, v := range du.Volumes {
+		if v.UsageData != nil {
+			sizes[v.Name] = v.UsageData.Size
+		}
+	}
+
+	return sizes, nil
+}
+
+// RemoveVolume removes a Docker volume by its name.
+//
+// Parameters:
+//   - ctx: The context for managing request lifetime and cancellation.
+//   - options: A RemoveVolumeOptions struct containing the name of the volume
+//     to be removed and a force flag to indicate whether the volume should
+//     be forcibly removed.
+//
+// Returns:
+//   - An error if there is an issue removing the volume.
+func (d *dockerClient) RemoveVolume(ctx context.Context, options RemoveVolumeOptions) error {
+	return d.cli.VolumeRemove(ctx, options.Name, options.Force)
+}
+
+// isAnonymousVolume checks if a Docker volume was created without a name,
+// relying on the label set by the daemon and falling back to the format of
+// the generated name for daemons that do not set it.
+func isAnonymousVolume(v *volume.Volume) bool {
+	if _, ok := v.Labels[anonymousVolumeLabel]; ok {
+		return true
+	}
+
+	if len(v.Name) != anonymousVolumeNameLength {
+		return false
+	}
+
+	_, err := hex.DecodeString(v.Name)
+	return err == nil
+}
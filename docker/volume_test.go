@@ -0,0 +1,208 @@
+package docker_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/lucasmendesl/beerus/docker"
+	mock "github.com/lucasmendesl/beerus/docker/mocks"
+	"github.com/lucasmendesl/beerus/selector"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDockerClient_ListExpiredVolumes(t *testing.T) {
+	var (
+		ctrl         = gomock.NewController(t)
+		dockerClient = mock.NewMockClient(ctrl)
+		logger       = slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		listVolumesError = errors.New("list volumes error")
+
+		expiredAt   = time.Now().Add(-time.Hour * 24 * 20).UTC().Truncate(time.Second)
+		recentAt    = time.Now().Add(-time.Hour * 24 * 2).UTC().Truncate(time.Second)
+		anonymousID = "8e5d2b3a1f0c4d7e9b6a5c3d2e1f0a9b8c7d6e5f4a3b2c1d0e9f8a7b6c5d4e3f"
+	)
+	type args struct {
+		ctx     context.Context
+		options docker.ExpiredVolumeListOptions
+	}
+	tests := []struct {
+		name      string
+		args      args
+		mockSetup func()
+		expected  []docker.Volume
+		wantErr   wantErr
+	}{
+		{
+			name: "error on list volumes",
+			args: args{
+				ctx: context.Background(),
+				options: docker.ExpiredVolumeListOptions{
+					LifetimeThresholdInDays: 10,
+				},
+			},
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					VolumeList(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return(volume.ListResponse{}, listVolumesError).
+					Times(1)
+			},
+			wantErr: func(t *testing.T, err error) bool {
+				require.EqualError(t, err, "expired docker volumes error: list volumes error")
+				return true
+			},
+		},
+		{
+			name: "only expired anonymous volumes",
+			args: args{
+				ctx: context.Background(),
+				options: docker.ExpiredVolumeListOptions{
+					LifetimeThresholdInDays: 10,
+				},
+			},
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					VolumeList(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return(volume.ListResponse{
+						Volumes: []*volume.Volume{
+							{
+								Name:      "f3a9c1d2e0b4",
+								CreatedAt: expiredAt.Format(time.RFC3339),
+								Labels:    map[string]string{"com.docker.volume.anonymous": ""},
+							},
+							{
+								Name:      anonymousID,
+								CreatedAt: expiredAt.Format(time.RFC3339),
+								Labels:    map[string]string{},
+							},
+							{
+								Name:      "postgres-data",
+								CreatedAt: expiredAt.Format(time.RFC3339),
+								Labels:    map[string]string{},
+							},
+							{
+								Name:      "a1b2c3d4e5f6",
+								CreatedAt: recentAt.Format(time.RFC3339),
+								Labels:    map[string]string{"com.docker.volume.anonymous": ""},
+							},
+						},
+					}, nil).
+					Times(1)
+
+				// the listing does not report the sizes, which are requested
+				// from the disk usage endpoint
+				dockerClient.
+					EXPECT().
+					DiskUsage(
+						gomock.Any(),
+						types.DiskUsageOptions{Types: []types.DiskUsageObject{types.VolumeObject}},
+					).
+					Return(types.DiskUsage{
+						Volumes: []*volume.Volume{
+							{Name: "f3a9c1d2e0b4", UsageData: &volume.UsageData{Size: 4096}},
+							{Name: anonymousID, UsageData: &volume.UsageData{Size: -1}},
+						},
+					}, nil).
+					Times(1)
+			},
+			wantErr: nopErr,
+			expected: []docker.Volume{
+				{
+					Name:      "f3a9c1d2e0b4",
+					CreatedAt: expiredAt,
+					Labels:    map[string]string{"com.docker.volume.anonymous": ""},
+					Anonymous: true,
+					Size:      4096,
+				},
+				{
+					Name:      anonymousID,
+					CreatedAt: expiredAt,
+					Labels:    map[string]string{},
+					Anonymous: true,
+					Size:      -1,
+				},
+			},
+		},
+		{
+			name: "include named volumes and filter by label",
+			args: args{
+				ctx: context.Background(),
+				options: docker.ExpiredVolumeListOptions{
+					LifetimeThresholdInDays: 10,
+					IncludeNamed:            true,
+					IgnoreLabels:            []selector.Selector{selector.MustParse("com.github.lucasmendesl.beerus.testLabel")},
+				},
+			},
+			mockSetup: func() {
+				dockerClient.
+					EXPECT().
+					VolumeList(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return(volume.ListResponse{
+						Volumes: []*volume.Volume{
+							{
+								Name:      "postgres-data",
+								CreatedAt: expiredAt.Format(time.RFC3339),
+								Labels:    map[string]string{},
+							},
+							{
+								Name:      "redis-data",
+								CreatedAt: expiredAt.Format(time.RFC3339),
+								Labels:    map[string]string{"com.github.lucasmendesl.beerus.testLabel": "true"},
+							},
+						},
+					}, nil).
+					Times(1)
+
+				// the size is left unknown when it cannot be computed
+				dockerClient.
+					EXPECT().
+					DiskUsage(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return(types.DiskUsage{}, errors.New("disk usage error")).
+					Times(1)
+			},
+			wantErr: nopErr,
+			expected: []docker.Volume{
+				{
+					Name:      "postgres-data",
+					CreatedAt: expiredAt,
+					Labels:    map[string]string{},
+					Size:      -1,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			d := docker.New(dockerClient, logger)
+
+			got, err := d.ListExpiredVolumes(tt.args.ctx, tt.args.options)
+			if tt.wantErr(t, err) {
+				return
+			}
+
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Package executor caps the number of tasks in flight, such as the removals
+// and inspections sent to a Docker daemon, sharing a fixed number of slots
+// between every caller, so large cleanups do not flood the daemon.
+package executor
+
+import (
+	"context"
+	"sync"
+)
+
+// Executor runs tasks holding one of a fixed number of slots, so at most
+// that number of tasks run at the same time across every caller sharing it.
+// Tasks must not wait for other tasks of the same Executor, since they could
+// wait for a slot forever. It is safe for concurrent use.
+type Executor struct {
+	slots chan struct{}
+}
+
+// New returns an Executor running at most limit tasks at the same time. A
+// limit lower than one is raised to one.
+func New(limit int) *Executor {
+	return &Executor{slots: make(chan struct{}, max(limit, 1))}
+}
+
+// Limit returns the maximum number of tasks running at the same time.
+func (e *Executor) Limit() int {
+	return cap(e.slots)
+}
+
+// acquire waits for a free slot, returning the error of the context when
+// it is canceled first.
+func (e *Executor) acquire(ctx context.Context) error {
+	select {
+	case e.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// both cases may be ready at the same time, so a slot freed along with
+	// the cancellation must not start a new task
+	if err := ctx.Err(); err != nil {
+		e.release()
+		return err
+	}
+
+	return nil
+}
+
+// release frees a slot taken by acquire.
+func (e *Executor) release() {
+	<-e.slots
+}
+
+// Group runs a set of tasks on an Executor, much like an errgroup.Group
+// whose limit is shared with every other user of the Executor.
+type Group struct {
+	e      *Executor
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	errOnce sync.Once
+	err     error
+}
+
+// Group returns a new Group running its tasks on the Executor, along with a
+// context derived from ctx, canceled once a task returns an error or Wait
+// returns, whichever occurs first.
+func (e *Executor) Group(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{e: e, ctx: ctx, cancel: cancel}, ctx
+}
+
+// Go waits for a free slot and runs the given task in a new goroutine, so
+// no more goroutines are started than slots are available. The task is not
+// run when the context of the Group is canceled before a slot is free. The
+// first error returned by a task cancels the context of the Group.
+func (g *Group) Go(task func() error) {
+	if err := g.e.acquire(g.ctx); err != nil {
+		g.setErr(err)
+		return
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer g.e.release()
+
+		if err := task(); err != nil {
+			g.setErr(err)
+		}
+	}()
+}
+
+// Wait blocks until every task started by Go returns, then returns the
+// first error, if any.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+
+	return g.err
+}
+
+// setErr keeps the first error and cancels the context of the Group.
+func (g *Group) setErr(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		g.cancel()
+	})
+}
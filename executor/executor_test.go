@@ -0,0 +1,148 @@
+package executor_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lucasmendesl/beerus/executor"
+	"github.com/stretchr/testify/require"
+)
+
+// inFlight tracks the number of tasks running at the same time and the
+// highest number reached.
+type inFlight struct {
+	current atomic.Int32
+	peak    atomic.Int32
+}
+
+func (f *inFlight) run() {
+	current := f.current.Add(1)
+	defer f.current.Add(-1)
+
+	for {
+		peak := f.peak.Load()
+		if current <= peak || f.peak.CompareAndSwap(peak, current) {
+			break
+		}
+	}
+
+	time.Sleep(5 * time.Millisecond)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		want  int
+	}{
+		{name: "positive limit", limit: 5, want: 5},
+		{name: "zero limit raised to one", limit: 0, want: 1},
+		{name: "negative limit raised to one", limit: -3, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, executor.New(tt.limit).Limit())
+		})
+	}
+}
+
+func TestGroup_Cap(t *testing.T) {
+	tests := []struct {
+		name   string
+		limit  int
+		groups int
+		tasks  int
+	}{
+		{name: "single group", limit: 3, groups: 1, tasks: 50},
+		{name: "groups sharing the executor", limit: 4, groups: 5, tasks: 20},
+		{name: "one slot", limit: 1, groups: 3, tasks: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				e        = executor.New(tt.limit)
+				f        = &inFlight{}
+				executed atomic.Int32
+				wg       sync.WaitGroup
+				errs     = make(chan error, tt.groups)
+			)
+
+			for range tt.groups {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					g, _ := e.Group(context.Background())
+					for range tt.tasks {
+						g.Go(func() error {
+							f.run()
+							executed.Add(1)
+							return nil
+						})
+					}
+
+					errs <- g.Wait()
+				}()
+			}
+
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, int32(tt.groups*tt.tasks), executed.Load())
+			require.LessOrEqual(t, f.peak.Load(), int32(tt.limit))
+			require.Equal(t, int32(tt.limit), f.peak.Load())
+		})
+	}
+}
+
+func TestGroup_Error(t *testing.T) {
+	var (
+		e        = executor.New(1)
+		taskErr  = errors.New("task error")
+		executed atomic.Int32
+	)
+
+	g, ctx := e.Group(context.Background())
+	g.Go(func() error {
+		executed.Add(1)
+		return taskErr
+	})
+
+	// the failed task cancels the group, so the remaining tasks are skipped
+	// once they wait for a slot
+	for range 5 {
+		g.Go(func() error {
+			executed.Add(1)
+			return nil
+		})
+	}
+
+	require.ErrorIs(t, g.Wait(), taskErr)
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+	require.Equal(t, int32(1), executed.Load())
+}
+
+func TestGroup_Canceled(t *testing.T) {
+	e := executor.New(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	g, _ := e.Group(ctx)
+	g.Go(func() error {
+		t.Fatal("task run after the context was canceled")
+		return nil
+	})
+
+	require.ErrorIs(t, g.Wait(), context.Canceled)
+}
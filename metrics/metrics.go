@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "beerus"
+
+// DefaultEndpoint is the endpoint label of the metrics updated through the
+// Metrics returned by New, used when a single Docker endpoint is managed.
+const DefaultEndpoint = "default"
+
+// Metrics holds the Prometheus collectors describing what the cleaner is
+// doing, such as the resources removed, the failed removals, the events
+// received and the duration of the cleanup cycles. Every collector is
+// registered in a dedicated registry, exposed through Handler, and every
+// metric is labeled with the Docker endpoint it was updated for.
+type Metrics struct {
+	registry *prometheus.Registry
+	endpoint string
+
+	removed        *prometheus.CounterVec
+	failures       *prometheus.CounterVec
+	events         *prometheus.CounterVec
+	cycleDuration  *prometheus.HistogramVec
+	reclaimedBytes *prometheus.CounterVec
+}
+
+// New returns a new Metrics object, with every collector registered along
+// with the Go runtime and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		endpoint: DefaultEndpoint,
+		removed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "resources_removed_total",
+			Help:      "Number of resources removed, by resource kind and matching rule.",
+		}, []string{"endpoint", "resource", "rule"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "removal_failures_total",
+			Help:      "Number of failed removals, by resource kind and error type.",
+		}, []string{"endpoint", "resource", "error_type"}),
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_received_total",
+			Help:      "Number of Docker events received, by action.",
+		}, []string{"endpoint", "action"}),
+		cycleDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "cycle_duration_seconds",
+			Help:      "Duration of the cleanup cycles, by cycle.",
+			Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+		}, []string{"endpoint", "cycle"}),
+		reclaimedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reclaimed_bytes_total",
+			Help:      "Number of bytes reclaimed by the removals, by resource kind.",
+		}, []string{"endpoint", "resource"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.removed,
+		m.failures,
+		m.events,
+		m.cycleDuration,
+		m.reclaimedBytes,
+	)
+
+	return m
+}
+
+// Handler returns the HTTP handler exposing the metrics in the Prometheus
+// text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// WithEndpoint returns a Metrics sharing the collectors of m, updating the
+// metrics labeled with the given Docker endpoint.
+func (m *Metrics) WithEndpoint(name string) *Metrics {
+	e := *m
+	e.endpoint = name
+	return &e
+}
+
+// ResourceRemoved counts a resource removed by the given rule, along with
+// the amount of bytes reclaimed by the removal.
+func (m *Metrics) ResourceRemoved(resource, rule string, size int64) {
+	m.removed.WithLabelValues(m.endpoint, resource, rule).Inc()
+
+	if size > 0 {
+		m.reclaimedBytes.WithLabelValues(m.endpoint, resource).Add(float64(size))
+	}
+}
+
+// RemovalFailed counts a failed removal of the given resource kind, by the
+// type of the error returned.
+func (m *Metrics) RemovalFailed(resource, errorType string) {
+	m.failures.WithLabelValues(m.endpoint, resource, errorType).Inc()
+}
+
+// EventReceived counts a Docker event received with the given action.
+func (m *Metrics) EventReceived(action string) {
+	m.events.WithLabelValues(m.endpoint, action).Inc()
+}
+
+// CycleFinished records the duration of a cleanup cycle.
+func (m *Metrics) CycleFinished(cycle string, duration time.Duration) {
+	m.cycleDuration.WithLabelValues(m.endpoint, cycle).Observe(duration.Seconds())
+}
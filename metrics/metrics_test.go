@@ -0,0 +1,60 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lucasmendesl/beerus/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_Handler(t *testing.T) {
+	m := metrics.New()
+
+	m.ResourceRemoved("image", "expired", 2048)
+	m.ResourceRemoved("image", "expired", 1024)
+	m.ResourceRemoved("container", "restart-policy", 0)
+	m.RemovalFailed("image", "conflict")
+	m.EventReceived("die")
+	m.CycleFinished("image poller", 250*time.Millisecond)
+
+	srv := httptest.NewServer(m.Handler())
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	exposed := string(body)
+	require.Contains(t, exposed, `beerus_resources_removed_total{endpoint="default",resource="image",rule="expired"} 2`)
+	require.Contains(t, exposed, `beerus_resources_removed_total{endpoint="default",resource="container",rule="restart-policy"} 1`)
+	require.Contains(t, exposed, `beerus_reclaimed_bytes_total{endpoint="default",resource="image"} 3072`)
+	require.NotContains(t, exposed, `beerus_reclaimed_bytes_total{endpoint="default",resource="container"}`)
+	require.Contains(t, exposed, `beerus_removal_failures_total{endpoint="default",error_type="conflict",resource="image"} 1`)
+	require.Contains(t, exposed, `beerus_events_received_total{action="die",endpoint="default"} 1`)
+	require.Contains(t, exposed, `beerus_cycle_duration_seconds_count{cycle="image poller",endpoint="default"} 1`)
+}
+
+func TestMetrics_WithEndpoint(t *testing.T) {
+	m := metrics.New()
+
+	m.ResourceRemoved("image", "expired", 0)
+	m.WithEndpoint("build-01").ResourceRemoved("image", "expired", 0)
+	m.WithEndpoint("build-01").ResourceRemoved("image", "expired", 0)
+	m.WithEndpoint("build-02").EventReceived("die")
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	exposed := rec.Body.String()
+	require.Contains(t, exposed, `beerus_resources_removed_total{endpoint="default",resource="image",rule="expired"} 1`)
+	require.Contains(t, exposed, `beerus_resources_removed_total{endpoint="build-01",resource="image",rule="expired"} 2`)
+	require.Contains(t, exposed, `beerus_events_received_total{action="die",endpoint="build-02"} 1`)
+	require.NotContains(t, exposed, `beerus_events_received_total{action="die",endpoint="default"}`)
+}
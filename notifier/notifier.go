@@ -0,0 +1,97 @@
+// Package notifier sends the summary of each cleanup cycle to external
+// systems, such as chat or incident tools, so operators learn when a lot of
+// space is reclaimed or when removals start failing.
+package notifier
+
+import (
+	"context"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/lucasmendesl/beerus/config"
+)
+
+// Counts holds a number for each kind of resource handled by the cleaner.
+// For the build cache, the number of records is counted.
+type Counts struct {
+	Containers int `json:"containers"`
+	Images     int `json:"images"`
+	Volumes    int `json:"volumes"`
+	Networks   int `json:"networks"`
+	BuildCache int `json:"buildCache"`
+}
+
+// Total returns the sum of the counts of every kind of resource.
+func (c Counts) Total() int {
+	return c.Containers + c.Images + c.Volumes + c.Networks + c.BuildCache
+}
+
+// Summary describes the outcome of a cleanup cycle, as sent to the sinks.
+type Summary struct {
+	Endpoint       string    `json:"endpoint,omitempty"`
+	Cycle          string    `json:"cycle"`
+	StartedAt      time.Time `json:"startedAt"`
+	FinishedAt     time.Time `json:"finishedAt"`
+	DryRun         bool      `json:"dryRun"`
+	Removed        Counts    `json:"removed"`
+	Failed         Counts    `json:"failed"`
+	ReclaimedBytes int64     `json:"reclaimedBytes"`
+}
+
+// Reclaimed returns the reclaimed space in a human readable size, such as
+// "1.5GB", to be used by the templates.
+func (s Summary) Reclaimed() string {
+	return units.HumanSize(float64(s.ReclaimedBytes))
+}
+
+// Notifier sends the summary of a cleanup cycle somewhere.
+type Notifier interface {
+	Notify(ctx context.Context, s Summary) error
+}
+
+// New returns the Notifier described by the given configuration. Summaries
+// of cycles without failed removals reclaiming less than the configured
+// minimum are not sent. When no sink is configured, every summary is
+// discarded.
+func New(config config.Notifications) (Notifier, error) {
+	if config.Webhook.URL == "" {
+		return Discard(), nil
+	}
+
+	webhook, err := NewWebhook(config.Webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.MinReclaimed == 0 {
+		return webhook, nil
+	}
+
+	return &threshold{next: webhook, minReclaimed: int64(config.MinReclaimed)}, nil
+}
+
+// Discard returns a Notifier discarding every summary.
+func Discard() Notifier {
+	return discard{}
+}
+
+type discard struct{}
+
+func (discard) Notify(context.Context, Summary) error {
+	return nil
+}
+
+// threshold forwards to the next Notifier the summaries with failed removals
+// or reclaiming at least the minimum amount of bytes.
+type threshold struct {
+	next         Notifier
+	minReclaimed int64
+}
+
+func (t *threshold) Notify(ctx context.Context, s Summary) error {
+	if s.Failed.Total() == 0 && s.ReclaimedBytes < t.minReclaimed {
+		return nil
+	}
+
+	return t.next.Notify(ctx, s)
+}
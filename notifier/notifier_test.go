@@ -0,0 +1,213 @@
+package notifier_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lucasmendesl/beerus/config"
+	"github.com/lucasmendesl/beerus/notifier"
+	"github.com/stretchr/testify/require"
+)
+
+var summary = notifier.Summary{
+	Cycle:      "image poller",
+	StartedAt:  time.Date(2025, time.January, 8, 10, 30, 0, 0, time.UTC),
+	FinishedAt: time.Date(2025, time.January, 8, 10, 31, 0, 0, time.UTC),
+	Removed: notifier.Counts{
+		Containers: 2,
+		Images:     3,
+	},
+	Failed: notifier.Counts{
+		Images: 1,
+	},
+	ReclaimedBytes: 1536 * 1024 * 1024,
+}
+
+func TestWebhook_Notify(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       config.Webhook
+		statuses     []int
+		wantAttempts int32
+		wantBody     string
+		wantErr      string
+	}{
+		{
+			name:         "summary sent as json",
+			config:       config.Webhook{},
+			statuses:     []int{http.StatusNoContent},
+			wantAttempts: 1,
+			wantBody:     `{"cycle":"image poller","startedAt":"2025-01-08T10:30:00Z","finishedAt":"2025-01-08T10:31:00Z","dryRun":false,"removed":{"containers":2,"images":3,"volumes":0,"networks":0,"buildCache":0},"failed":{"containers":0,"images":1,"volumes":0,"networks":0,"buildCache":0},"reclaimedBytes":1610612736}`,
+		},
+		{
+			name: "summary rendered by the template",
+			config: config.Webhook{
+				Template: `{"text":{{ printf "%s: %d removed, %d failed, %s reclaimed" .Cycle .Removed.Total .Failed.Total .Reclaimed | json }}}`,
+			},
+			statuses:     []int{http.StatusOK},
+			wantAttempts: 1,
+			wantBody:     `{"text":"image poller: 5 removed, 1 failed, 1.611GB reclaimed"}`,
+		},
+		{
+			name:         "server errors retried",
+			config:       config.Webhook{MaxRetries: 3},
+			statuses:     []int{http.StatusBadGateway, http.StatusTooManyRequests, http.StatusOK},
+			wantAttempts: 3,
+		},
+		{
+			name:         "retries exhausted",
+			config:       config.Webhook{MaxRetries: 1},
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			wantAttempts: 2,
+			wantErr:      "webhook notification error after 2 attempts: unexpected status 503 Service Unavailable",
+		},
+		{
+			name:         "client errors not retried",
+			config:       config.Webhook{MaxRetries: 3},
+			statuses:     []int{http.StatusBadRequest},
+			wantAttempts: 1,
+			wantErr:      "webhook notification error after 1 attempts: unexpected status 400 Bad Request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				attempts atomic.Int32
+				body     atomic.Value
+			)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempt := attempts.Add(1)
+
+				require.Equal(t, http.MethodPost, r.Method)
+				require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+				content, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				body.Store(string(content))
+
+				w.WriteHeader(tt.statuses[attempt-1])
+			}))
+			defer server.Close()
+
+			tt.config.URL = server.URL
+			webhook, err := notifier.NewWebhook(tt.config, notifier.WithRetryDelay(time.Millisecond))
+			require.NoError(t, err)
+
+			err = webhook.Notify(context.Background(), summary)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tt.wantAttempts, attempts.Load())
+			if tt.wantBody != "" {
+				require.JSONEq(t, tt.wantBody, body.Load().(string))
+			}
+		})
+	}
+}
+
+func TestWebhook_NotifyTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	webhook, err := notifier.NewWebhook(config.Webhook{URL: server.URL, MaxRetries: 3})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	require.ErrorIs(t, webhook.Notify(ctx, summary), context.DeadlineExceeded)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     config.Notifications
+		summary    notifier.Summary
+		wantNotify bool
+		wantErr    string
+	}{
+		{
+			name:    "no sink configured",
+			config:  config.Notifications{},
+			summary: summary,
+		},
+		{
+			name:       "no minimum reclaimed",
+			config:     config.Notifications{Webhook: config.Webhook{URL: "{server}"}},
+			summary:    notifier.Summary{Cycle: "initial sweep"},
+			wantNotify: true,
+		},
+		{
+			name:    "under the minimum reclaimed",
+			config:  config.Notifications{MinReclaimed: 2 << 30, Webhook: config.Webhook{URL: "{server}"}},
+			summary: notifier.Summary{Cycle: "initial sweep", ReclaimedBytes: 1024},
+		},
+		{
+			name:       "over the minimum reclaimed",
+			config:     config.Notifications{MinReclaimed: 1 << 30, Webhook: config.Webhook{URL: "{server}"}},
+			summary:    summary,
+			wantNotify: true,
+		},
+		{
+			name:       "failures always notified",
+			config:     config.Notifications{MinReclaimed: 2 << 30, Webhook: config.Webhook{URL: "{server}"}},
+			summary:    notifier.Summary{Cycle: "initial sweep", Failed: notifier.Counts{Volumes: 1}},
+			wantNotify: true,
+		},
+		{
+			name:    "invalid template",
+			config:  config.Notifications{Webhook: config.Webhook{URL: "{server}", Template: "{{ .Cycle"}},
+			wantErr: "invalid webhook template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received []notifier.Summary
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var s notifier.Summary
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&s))
+				received = append(received, s)
+			}))
+			defer server.Close()
+
+			if tt.config.Webhook.URL != "" {
+				tt.config.Webhook.URL = server.URL
+			}
+
+			n, err := notifier.New(tt.config)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, n.Notify(context.Background(), tt.summary))
+
+			if tt.wantNotify {
+				require.Equal(t, []notifier.Summary{tt.summary}, received)
+			} else {
+				require.Empty(t, received)
+			}
+		})
+	}
+}
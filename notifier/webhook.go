@@ -0,0 +1,167 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/lucasmendesl/beerus/config"
+)
+
+const (
+	// defaultWebhookTimeout is the time limit of each request when none is
+	// configured.
+	defaultWebhookTimeout = 10 * time.Second
+
+	// defaultRetryDelay is the delay before the first retry of a failed
+	// request, doubled on every attempt.
+	defaultRetryDelay = time.Second
+)
+
+// Webhook is a generic sink posting the summary of each cleanup cycle to an
+// HTTP endpoint, either as JSON or rendered by a text/template.
+type Webhook struct {
+	url        string
+	tmpl       *template.Template
+	client     *http.Client
+	maxRetries int
+	retryDelay time.Duration
+}
+
+// WebhookOption configures optional behavior of the Webhook.
+type WebhookOption func(*Webhook)
+
+// WithRetryDelay sets the delay before the first retry of a failed request,
+// which is doubled on every attempt. By default, it is one second.
+func WithRetryDelay(d time.Duration) WebhookOption {
+	return func(w *Webhook) {
+		w.retryDelay = d
+	}
+}
+
+// NewWebhook returns a Webhook described by the given configuration. It
+// returns an error when the body template can not be parsed.
+func NewWebhook(config config.Webhook, options ...WebhookOption) (*Webhook, error) {
+	timeout := defaultWebhookTimeout
+	if config.Timeout > 0 {
+		timeout = time.Duration(config.Timeout) * time.Second
+	}
+
+	w := &Webhook{
+		url:        config.URL,
+		client:     &http.Client{Timeout: timeout},
+		maxRetries: int(config.MaxRetries),
+		retryDelay: defaultRetryDelay,
+	}
+
+	if config.Template != "" {
+		tmpl, err := template.New("webhook").Funcs(template.FuncMap{"json": toJSON}).Parse(config.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook template: %w", err)
+		}
+
+		w.tmpl = tmpl
+	}
+
+	for _, option := range options {
+		option(w)
+	}
+
+	return w, nil
+}
+
+// Notify posts the given summary to the webhook. Requests failing with a
+// network error or a server error response are retried, waiting longer
+// between each attempt, until the maximum number of retries is reached or
+// the context is canceled.
+func (w *Webhook) Notify(ctx context.Context, s Summary) error {
+	body, err := w.render(s)
+	if err != nil {
+		return err
+	}
+
+	delay := w.retryDelay
+
+	for attempt := 0; ; attempt++ {
+		retryable, err := w.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+
+		if !retryable || attempt >= w.maxRetries {
+			return fmt.Errorf("webhook notification error after %d attempts: %w", attempt+1, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("webhook notification error: %w", ctx.Err())
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+	}
+}
+
+// render returns the request body for the given summary.
+func (w *Webhook) render(s Summary) ([]byte, error) {
+	if w.tmpl == nil {
+		body, err := json.Marshal(s)
+		if err != nil {
+			return nil, fmt.Errorf("encoding webhook summary error: %w", err)
+		}
+
+		return body, nil
+	}
+
+	var body bytes.Buffer
+	if err := w.tmpl.Execute(&body, s); err != nil {
+		return nil, fmt.Errorf("rendering webhook template error: %w", err)
+	}
+
+	return body.Bytes(), nil
+}
+
+// post sends a single request with the given body, reporting whether its
+// failure is worth retrying.
+func (w *Webhook) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	// drain the body, so the connection can be reused
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return false, nil
+	}
+
+	err = fmt.Errorf("unexpected status %s", resp.Status)
+	retryable := resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+
+	return retryable, err
+}
+
+// toJSON encodes the given value as JSON, so the templates can safely embed
+// values in JSON payloads.
+func toJSON(v any) (string, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("encoding template value error: %w", err)
+	}
+
+	return string(encoded), nil
+}
@@ -0,0 +1,176 @@
+// Package policy decides which containers and images are removed from rules
+// written as boolean expressions over a typed view of each resource, such as
+// `status == "exited" && glob("ci/*", repository) && age > duration("30m")`,
+// in place of the fixed rules of the cleaner.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/docker/go-units"
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/lucasmendesl/beerus/config"
+)
+
+// Kind is the kind of resource a rule applies to.
+type Kind string
+
+const (
+	// KindContainer is the kind of the rules applying to stopped containers.
+	KindContainer Kind = "container"
+
+	// KindImage is the kind of the rules applying to images.
+	KindImage Kind = "image"
+)
+
+// Action is what happens to the resources matching a rule.
+type Action string
+
+const (
+	// ActionRemove removes the resources matching the rule.
+	ActionRemove Action = "remove"
+
+	// ActionKeep keeps the resources matching the rule.
+	ActionKeep Action = "keep"
+)
+
+// Decision is the outcome of the evaluation of a resource. Rule is the name
+// of the rule that matched the resource, or empty when none did, in which
+// case the resource is kept.
+type Decision struct {
+	Rule   string
+	Action Action
+}
+
+// Remove reports whether the resource is removed.
+func (d Decision) Remove() bool {
+	return d.Action == ActionRemove
+}
+
+// rule is a compiled policy.
+type rule struct {
+	name    string
+	action  Action
+	program *vm.Program
+}
+
+// Engine evaluates the rules of each kind of resource in order, the first
+// matching one deciding. It is safe for concurrent use, and a nil Engine
+// has no rule.
+type Engine struct {
+	rules map[Kind][]rule
+}
+
+// New compiles the given policies, returning an error naming the first
+// invalid one. The expressions are type checked against the Resource view,
+// so a misspelled field or a comparison between mismatched types is reported
+// here instead of at evaluation time.
+func New(policies []config.Policy) (*Engine, error) {
+	e := &Engine{rules: make(map[Kind][]rule)}
+	seen := make(map[string]bool, len(policies))
+
+	for _, p := range policies {
+		r, kind, err := compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy %q: %w", p.Name, err)
+		}
+
+		if seen[p.Name] {
+			return nil, fmt.Errorf("duplicated policy name %q", p.Name)
+		}
+
+		seen[p.Name] = true
+		e.rules[kind] = append(e.rules[kind], r)
+	}
+
+	return e, nil
+}
+
+// compile returns the rule described by the given policy, along with the
+// kind of resource it applies to.
+func compile(p config.Policy) (rule, Kind, error) {
+	if p.Name == "" {
+		return rule{}, "", errors.New("empty name")
+	}
+
+	kind := Kind(p.Resource)
+	if kind != KindContainer && kind != KindImage {
+		return rule{}, "", fmt.Errorf("unknown resource %q", p.Resource)
+	}
+
+	action := ActionRemove
+	if p.Action != "" {
+		action = Action(p.Action)
+	}
+
+	if action != ActionRemove && action != ActionKeep {
+		return rule{}, "", fmt.Errorf("unknown action %q", p.Action)
+	}
+
+	program, err := expr.Compile(p.Expression,
+		expr.Env(Resource{}),
+		expr.AsBool(),
+		expr.Function("glob", glob, new(func(string, string) bool)),
+		expr.Function("bytes", parseBytes, new(func(string) int64)),
+	)
+
+	if err != nil {
+		return rule{}, "", err
+	}
+
+	return rule{name: p.Name, action: action, program: program}, kind, nil
+}
+
+// Applies reports whether rules are configured for the given kind of
+// resource, in which case they take the place of the fixed rules.
+func (e *Engine) Applies(kind Kind) bool {
+	return e != nil && len(e.rules[kind]) > 0
+}
+
+// Evaluate returns the decision of the first rule of the given kind matching
+// the given resource. It returns an error when an expression fails, such as
+// a division by zero, leaving the resource undecided.
+func (e *Engine) Evaluate(kind Kind, r Resource) (Decision, error) {
+	if e == nil {
+		return Decision{Action: ActionKeep}, nil
+	}
+
+	for _, rule := range e.rules[kind] {
+		out, err := expr.Run(rule.program, r)
+		if err != nil {
+			return Decision{}, fmt.Errorf("evaluating policy %q error: %w", rule.name, err)
+		}
+
+		if matched, _ := out.(bool); matched {
+			return Decision{Rule: rule.name, Action: rule.action}, nil
+		}
+	}
+
+	return Decision{Action: ActionKeep}, nil
+}
+
+// glob reports whether the given value matches the given shell pattern,
+// where * does not match the / separating the path components of a
+// repository.
+func glob(params ...any) (any, error) {
+	matched, err := path.Match(params[0].(string), params[1].(string))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", params[0], err)
+	}
+
+	return matched, nil
+}
+
+// parseBytes returns the number of bytes of the given human readable size,
+// such as "500MB", so sizes can be written as bytes("500MB").
+func parseBytes(params ...any) (any, error) {
+	size, err := units.RAMInBytes(params[0].(string))
+	if err != nil {
+		return nil, fmt.Errorf("invalid size %q: %w", params[0], err)
+	}
+
+	return size, nil
+}
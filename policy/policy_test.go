@@ -0,0 +1,198 @@
+package policy_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/lucasmendesl/beerus/config"
+	"github.com/lucasmendesl/beerus/docker"
+	"github.com/lucasmendesl/beerus/policy"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// fixture is a rule fixture of testdata/rules, holding policies along with
+// the decisions expected for a set of resources.
+type fixture struct {
+	Policies []config.Policy `yaml:"policies"`
+	Cases    []fixtureCase   `yaml:"cases"`
+}
+
+// fixtureCase is a resource, either a container or an image, along with the
+// rule expected to match it, empty when none does, and the expected action.
+type fixtureCase struct {
+	Name      string            `yaml:"name"`
+	Container *fixtureContainer `yaml:"container"`
+	Image     *fixtureImage     `yaml:"image"`
+	Rule      string            `yaml:"rule"`
+	Action    policy.Action     `yaml:"action"`
+}
+
+type fixtureContainer struct {
+	Names         []string          `yaml:"names"`
+	Labels        map[string]string `yaml:"labels"`
+	Image         string            `yaml:"image"`
+	Status        string            `yaml:"status"`
+	ExitCode      int               `yaml:"exitCode"`
+	Age           time.Duration     `yaml:"age"`
+	RestartCount  int               `yaml:"restartCount"`
+	RestartPolicy string            `yaml:"restartPolicy"`
+	Size          int64             `yaml:"size"`
+}
+
+type fixtureImage struct {
+	Tags     []string          `yaml:"tags"`
+	Labels   map[string]string `yaml:"labels"`
+	Dangling bool              `yaml:"dangling"`
+	Size     int64             `yaml:"size"`
+	Age      time.Duration     `yaml:"age"`
+}
+
+// resource returns the kind and the view of the resource of the case, its
+// age being counted until the given time.
+func (c fixtureCase) resource(now time.Time) (policy.Kind, policy.Resource) {
+	if c.Image != nil {
+		return policy.KindImage, policy.ImageResource(docker.Image{
+			ID:         "sha256:1a2b3c4d5e6f",
+			Labels:     c.Image.Labels,
+			Tags:       c.Image.Tags,
+			Dangling:   c.Image.Dangling,
+			Size:       c.Image.Size,
+			LastUsedAt: now.Add(-c.Image.Age),
+		}, now)
+	}
+
+	return policy.KindContainer, policy.ContainerResource(docker.Container{
+		ID:            "3f1c2a9d7e5b",
+		Names:         c.Container.Names,
+		Image:         c.Container.Image,
+		Labels:        c.Container.Labels,
+		CreatedAt:     now.Add(-c.Container.Age),
+		Status:        docker.ContainerStatus(c.Container.Status),
+		ExitCode:      c.Container.ExitCode,
+		RestartCount:  c.Container.RestartCount,
+		RestartPolicy: container.RestartPolicy{Name: container.RestartPolicyMode(c.Container.RestartPolicy)},
+		Size:          c.Container.Size,
+	}, now)
+}
+
+func TestEngine_Rules(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "rules", "*.yaml"))
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+
+	now := time.Now()
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+
+			var f fixture
+			require.NoError(t, yaml.Unmarshal(content, &f))
+
+			engine, err := policy.New(f.Policies)
+			require.NoError(t, err)
+
+			for _, c := range f.Cases {
+				t.Run(c.Name, func(t *testing.T) {
+					decision, err := engine.Evaluate(c.resource(now))
+					require.NoError(t, err)
+					require.Equal(t, policy.Decision{Rule: c.Rule, Action: c.Action}, decision)
+				})
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies []config.Policy
+		wantErr  string
+	}{
+		{
+			name: "valid policies",
+			policies: []config.Policy{
+				{Name: "ci", Resource: "container", Expression: `glob("ci/*", repository)`},
+				{Name: "base", Resource: "image", Action: "keep", Expression: `labels["tier"] == "base"`},
+			},
+		},
+		{
+			name:     "empty name",
+			policies: []config.Policy{{Resource: "container", Expression: "true"}},
+			wantErr:  `invalid policy "": empty name`,
+		},
+		{
+			name:     "unknown resource",
+			policies: []config.Policy{{Name: "volumes", Resource: "volume", Expression: "true"}},
+			wantErr:  `invalid policy "volumes": unknown resource "volume"`,
+		},
+		{
+			name:     "unknown action",
+			policies: []config.Policy{{Name: "ci", Resource: "container", Action: "delete", Expression: "true"}},
+			wantErr:  `invalid policy "ci": unknown action "delete"`,
+		},
+		{
+			name:     "unknown field",
+			policies: []config.Policy{{Name: "ci", Resource: "container", Expression: "exit_code == 0"}},
+			wantErr:  `invalid policy "ci": unknown name exit_code`,
+		},
+		{
+			name:     "mismatched types",
+			policies: []config.Policy{{Name: "ci", Resource: "container", Expression: `status > 1`}},
+			wantErr:  `invalid policy "ci": invalid operation`,
+		},
+		{
+			name:     "not a boolean",
+			policies: []config.Policy{{Name: "ci", Resource: "container", Expression: "size"}},
+			wantErr:  `invalid policy "ci": expected bool`,
+		},
+		{
+			name: "duplicated name",
+			policies: []config.Policy{
+				{Name: "ci", Resource: "container", Expression: "true"},
+				{Name: "ci", Resource: "image", Expression: "true"},
+			},
+			wantErr: `duplicated policy name "ci"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := policy.New(tt.policies)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestEngine_Evaluate(t *testing.T) {
+	engine, err := policy.New([]config.Policy{
+		{Name: "pattern", Resource: "container", Expression: `glob("[", name)`},
+		{Name: "images", Resource: "image", Expression: "dangling"},
+	})
+	require.NoError(t, err)
+
+	require.True(t, engine.Applies(policy.KindContainer))
+	require.True(t, engine.Applies(policy.KindImage))
+
+	// a failed expression leaves the resource undecided
+	_, err = engine.Evaluate(policy.KindContainer, policy.Resource{Name: "ci-build"})
+	require.ErrorContains(t, err, `evaluating policy "pattern" error`)
+
+	// a nil engine has no rule, keeping every resource
+	var none *policy.Engine
+	require.False(t, none.Applies(policy.KindImage))
+
+	decision, err := none.Evaluate(policy.KindImage, policy.Resource{Dangling: true})
+	require.NoError(t, err)
+	require.False(t, decision.Remove())
+}
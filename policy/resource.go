@@ -0,0 +1,126 @@
+package policy
+
+import (
+	"strings"
+	"time"
+
+	"github.com/lucasmendesl/beerus/docker"
+)
+
+// danglingTag is the tag reported by the daemon for the dangling images.
+const danglingTag = "<none>:<none>"
+
+// Resource is the view of a container or an image the expressions are
+// evaluated over. Each field is available to the expressions under the name
+// of its expr tag.
+type Resource struct {
+	// ID is the ID of the resource.
+	ID string `expr:"id"`
+
+	// Name is the first name of a container, without the leading slash, or
+	// the first tag of an image.
+	Name string `expr:"name"`
+
+	// Names are the names of a container, without the leading slash, or the
+	// tags of an image.
+	Names []string `expr:"names"`
+
+	// Labels are the labels of the resource.
+	Labels map[string]string `expr:"labels"`
+
+	// Image is the image reference of a container, such as "ci/app:1.2", or
+	// the first tag of an image.
+	Image string `expr:"image"`
+
+	// Repository is the repository of Image in its familiar form, such as
+	// "ci/app" or "nginx", or empty when Image is not a reference.
+	Repository string `expr:"repository"`
+
+	// Tag is the tag of Image, or empty when it has none.
+	Tag string `expr:"tag"`
+
+	// Status is the status of a container, such as "exited", or either
+	// "dangling" or "tagged" for an image.
+	Status string `expr:"status"`
+
+	// ExitCode is the exit code of a container.
+	ExitCode int `expr:"exitCode"`
+
+	// Age is the time elapsed since a container was created, or since an
+	// image was last used by a container.
+	Age time.Duration `expr:"age"`
+
+	// RestartCount is the number of times a container was restarted.
+	RestartCount int `expr:"restartCount"`
+
+	// RestartPolicy is the name of the restart policy of a container, such
+	// as "no" or "always".
+	RestartPolicy string `expr:"restartPolicy"`
+
+	// Size is the size of the resource in bytes, which is the size of the
+	// writable layer of a container when it is known.
+	Size int64 `expr:"size"`
+
+	// Dangling reports whether an image has no tag pointing to it.
+	Dangling bool `expr:"dangling"`
+}
+
+// ContainerResource returns the view of the given container, its age being
+// counted until the given time.
+func ContainerResource(c docker.Container, now time.Time) Resource {
+	names := make([]string, 0, len(c.Names))
+	for _, name := range c.Names {
+		names = append(names, strings.TrimPrefix(name, "/"))
+	}
+
+	r := Resource{
+		ID:            c.ID,
+		Names:         names,
+		Labels:        c.Labels,
+		Image:         c.Image,
+		Status:        string(c.Status),
+		ExitCode:      c.ExitCode,
+		Age:           now.Sub(c.CreatedAt),
+		RestartCount:  c.RestartCount,
+		RestartPolicy: string(c.RestartPolicy.Name),
+		Size:          c.Size,
+	}
+
+	if len(names) > 0 {
+		r.Name = names[0]
+	}
+
+	r.Repository, r.Tag = docker.SplitReference(c.Image)
+	return r
+}
+
+// ImageResource returns the view of the given image, its age being counted
+// from its last use until the given time.
+func ImageResource(img docker.Image, now time.Time) Resource {
+	r := Resource{
+		ID:       img.ID,
+		Labels:   img.Labels,
+		Status:   "tagged",
+		Age:      now.Sub(img.LastUsedAt),
+		Size:     img.Size,
+		Dangling: img.Dangling,
+	}
+
+	if img.Dangling {
+		r.Status = "dangling"
+	}
+
+	for _, tag := range img.Tags {
+		if tag != danglingTag {
+			r.Names = append(r.Names, tag)
+		}
+	}
+
+	if len(r.Names) > 0 {
+		r.Name = r.Names[0]
+		r.Image = r.Names[0]
+		r.Repository, r.Tag = docker.SplitReference(r.Image)
+	}
+
+	return r
+}
@@ -0,0 +1,254 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	// the time zones are embedded, since the runtime image ships without them
+	_ "time/tzdata"
+
+	"github.com/lucasmendesl/beerus/config"
+	"github.com/robfig/cron/v3"
+)
+
+// weekdays maps the names of the days accepted by the windows to their
+// weekday.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Calendar decides when the periodic sweeps run and when the resources may
+// be removed. Its methods are safe for concurrent use, and a nil Calendar
+// follows the poll interval and allows the removals at any time.
+type Calendar struct {
+	cron         cron.Schedule
+	location     *time.Location
+	windows      []window
+	bypassEvents bool
+}
+
+// window is a compiled maintenance window. The times of the day are minutes
+// since midnight, and a window whose end is not after its start closes on
+// the next day.
+type window struct {
+	days     [7]bool
+	start    int
+	end      int
+	location *time.Location
+}
+
+// New returns the calendar of the given schedule settings. It returns an
+// error naming the first malformed setting, such as an invalid cron
+// expression, an unknown time zone or a malformed window.
+func New(cfg config.Schedule) (*Calendar, error) {
+	location, err := loadLocation(cfg.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Calendar{location: location, bypassEvents: cfg.EventsBypassWindows}
+
+	if cfg.Cron != "" {
+		if c.cron, err = cron.ParseStandard(cfg.Cron); err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", cfg.Cron, err)
+		}
+	}
+
+	for i, w := range cfg.MaintenanceWindows {
+		compiled, err := newWindow(w, location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window %d: %w", i+1, err)
+		}
+
+		c.windows = append(c.windows, compiled)
+	}
+
+	return c, nil
+}
+
+// Next returns the time of the first sweep after the given time, following
+// the cron expression, or the given interval when there is none.
+func (c *Calendar) Next(after time.Time, interval time.Duration) time.Time {
+	if c == nil || c.cron == nil {
+		return after.Add(interval)
+	}
+
+	return c.cron.Next(after.In(c.location))
+}
+
+// Open reports whether the resources may be removed at the given time, that
+// is, when the time falls in one of the maintenance windows or when there is
+// none.
+func (c *Calendar) Open(t time.Time) bool {
+	if c == nil || len(c.windows) == 0 {
+		return true
+	}
+
+	for _, w := range c.windows {
+		if w.contains(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NextOpen returns the first time, from the given one, the resources may be
+// removed, which is the given time itself when the calendar is open.
+func (c *Calendar) NextOpen(t time.Time) time.Time {
+	if c.Open(t) {
+		return t
+	}
+
+	var next time.Time
+	for _, w := range c.windows {
+		if start := w.nextStart(t); next.IsZero() || start.Before(next) {
+			next = start
+		}
+	}
+
+	return next
+}
+
+// EventsAllowed reports whether the removals triggered by the events may
+// happen at the given time, which they always may when the events bypass the
+// maintenance windows.
+func (c *Calendar) EventsAllowed(t time.Time) bool {
+	return c == nil || c.bypassEvents || c.Open(t)
+}
+
+// newWindow compiles the given maintenance window, its times being read in
+// its own time zone, or in the given one when it has none.
+func newWindow(w config.MaintenanceWindow, location *time.Location) (window, error) {
+	compiled := window{location: location}
+
+	if w.TimeZone != "" {
+		var err error
+		if compiled.location, err = loadLocation(w.TimeZone); err != nil {
+			return window{}, err
+		}
+	}
+
+	if len(w.Days) == 0 {
+		compiled.days = [7]bool{true, true, true, true, true, true, true}
+	}
+
+	for _, days := range w.Days {
+		if err := compiled.addDays(days); err != nil {
+			return window{}, err
+		}
+	}
+
+	var err error
+	if compiled.start, err = parseTimeOfDay(w.Start); err != nil || compiled.start == 24*60 {
+		return window{}, fmt.Errorf("invalid start %q: the time of the day must be formatted as HH:MM", w.Start)
+	}
+
+	if compiled.end, err = parseTimeOfDay(w.End); err != nil {
+		return window{}, fmt.Errorf("invalid end %q: %w", w.End, err)
+	}
+
+	if compiled.start == compiled.end {
+		return window{}, errors.New("the window must not start and end at the same time")
+	}
+
+	return compiled, nil
+}
+
+// addDays opens the window on the given day, such as "mon", or on the given
+// range of days, such as "mon-fri" or "fri-mon".
+func (w *window) addDays(days string) error {
+	from, to, isRange := strings.Cut(strings.ToLower(strings.TrimSpace(days)), "-")
+	if !isRange {
+		to = from
+	}
+
+	first, ok := weekdays[strings.TrimSpace(from)]
+	if !ok {
+		return fmt.Errorf("unknown day %q", days)
+	}
+
+	last, ok := weekdays[strings.TrimSpace(to)]
+	if !ok {
+		return fmt.Errorf("unknown day %q", days)
+	}
+
+	for day := first; ; day = (day + 1) % 7 {
+		w.days[day] = true
+		if day == last {
+			return nil
+		}
+	}
+}
+
+// contains reports whether the given time falls in the window.
+func (w window) contains(t time.Time) bool {
+	t = t.In(w.location)
+	minute := t.Hour()*60 + t.Minute()
+
+	if w.start < w.end {
+		return w.days[t.Weekday()] && minute >= w.start && minute < w.end
+	}
+
+	// the window opened on the day before closes today
+	yesterday := (t.Weekday() + 6) % 7
+	return (w.days[t.Weekday()] && minute >= w.start) || (w.days[yesterday] && minute < w.end)
+}
+
+// nextStart returns the first time after the given one the window opens.
+func (w window) nextStart(t time.Time) time.Time {
+	t = t.In(w.location)
+
+	for day := 0; day <= 7; day++ {
+		date := t.AddDate(0, 0, day)
+		if !w.days[date.Weekday()] {
+			continue
+		}
+
+		start := time.Date(date.Year(), date.Month(), date.Day(), w.start/60, w.start%60, 0, 0, w.location)
+		if start.After(t) {
+			return start
+		}
+	}
+
+	// unreachable, since a window opens on at least one day of the week
+	return t
+}
+
+// parseTimeOfDay returns the minutes since midnight of the given time of the
+// day, such as "06:30", where "24:00" is the end of the day.
+func parseTimeOfDay(value string) (int, error) {
+	if value == "24:00" {
+		return 24 * 60, nil
+	}
+
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, errors.New("the time of the day must be formatted as HH:MM")
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// loadLocation returns the time zone of the given IANA name, or the local
+// time zone when the name is empty.
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", name, err)
+	}
+
+	return location, nil
+}
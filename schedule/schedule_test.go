@@ -0,0 +1,176 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lucasmendesl/beerus/config"
+	"github.com/lucasmendesl/beerus/schedule"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendar_Open(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	calendar, err := schedule.New(config.Schedule{
+		TimeZone: "Europe/Paris",
+		MaintenanceWindows: []config.MaintenanceWindow{
+			// weeknights, closing on the next morning
+			{Days: []string{"mon-thu"}, Start: "22:00", End: "06:00"},
+			// the whole weekend, in another time zone
+			{Days: []string{"sat", "Sun"}, Start: "00:00", End: "24:00", TimeZone: "UTC"},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected bool
+		nextOpen time.Time
+	}{
+		{
+			name:     "weeknight",
+			at:       time.Date(2025, time.January, 6, 23, 30, 0, 0, paris), // monday
+			expected: true,
+		},
+		{
+			name:     "morning after a weeknight",
+			at:       time.Date(2025, time.January, 7, 5, 59, 0, 0, paris), // tuesday
+			expected: true,
+		},
+		{
+			name:     "weekday",
+			at:       time.Date(2025, time.January, 7, 6, 0, 0, 0, paris), // tuesday
+			expected: false,
+			nextOpen: time.Date(2025, time.January, 7, 22, 0, 0, 0, paris),
+		},
+		{
+			name:     "friday night",
+			at:       time.Date(2025, time.January, 10, 23, 0, 0, 0, paris), // friday
+			expected: false,
+			nextOpen: time.Date(2025, time.January, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "saturday in utc",
+			at:       time.Date(2025, time.January, 11, 0, 30, 0, 0, paris), // friday in utc
+			expected: false,
+			nextOpen: time.Date(2025, time.January, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekend",
+			at:       time.Date(2025, time.January, 12, 15, 0, 0, 0, paris), // sunday
+			expected: true,
+		},
+		{
+			name:     "sunday night",
+			at:       time.Date(2025, time.January, 13, 2, 0, 0, 0, paris), // monday
+			expected: false,
+			nextOpen: time.Date(2025, time.January, 13, 22, 0, 0, 0, paris),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, calendar.Open(tt.at))
+			require.Equal(t, tt.expected, calendar.EventsAllowed(tt.at))
+
+			if tt.expected {
+				require.Equal(t, tt.at, calendar.NextOpen(tt.at))
+				return
+			}
+
+			require.True(t, tt.nextOpen.Equal(calendar.NextOpen(tt.at)), "next open at %s, got %s", tt.nextOpen, calendar.NextOpen(tt.at))
+		})
+	}
+}
+
+func TestCalendar_Next(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	after := time.Date(2025, time.January, 6, 23, 30, 0, 0, paris)
+
+	calendar, err := schedule.New(config.Schedule{Cron: "0 3 * * *", TimeZone: "Europe/Paris"})
+	require.NoError(t, err)
+	require.True(t, time.Date(2025, time.January, 7, 3, 0, 0, 0, paris).Equal(calendar.Next(after, time.Hour)))
+
+	// the poll interval is followed when there is no cron expression
+	calendar, err = schedule.New(config.Schedule{})
+	require.NoError(t, err)
+	require.Equal(t, after.Add(time.Hour), calendar.Next(after, time.Hour))
+
+	var none *schedule.Calendar
+	require.Equal(t, after.Add(time.Hour), none.Next(after, time.Hour))
+	require.True(t, none.Open(after))
+	require.True(t, none.EventsAllowed(after))
+}
+
+func TestCalendar_EventsBypassWindows(t *testing.T) {
+	calendar, err := schedule.New(config.Schedule{
+		TimeZone:            "UTC",
+		MaintenanceWindows:  []config.MaintenanceWindow{{Start: "01:00", End: "02:00"}},
+		EventsBypassWindows: true,
+	})
+	require.NoError(t, err)
+
+	noon := time.Date(2025, time.January, 6, 12, 0, 0, 0, time.UTC)
+	require.False(t, calendar.Open(noon))
+	require.True(t, calendar.EventsAllowed(noon))
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule config.Schedule
+		wantErr  string
+	}{
+		{
+			name:     "invalid cron expression",
+			schedule: config.Schedule{Cron: "0 3 * *"},
+			wantErr:  `invalid cron expression "0 3 * *"`,
+		},
+		{
+			name:     "unknown time zone",
+			schedule: config.Schedule{TimeZone: "Mars/Olympus"},
+			wantErr:  `invalid time zone "Mars/Olympus"`,
+		},
+		{
+			name: "unknown day",
+			schedule: config.Schedule{MaintenanceWindows: []config.MaintenanceWindow{
+				{Days: []string{"mon-fry"}, Start: "22:00", End: "06:00"},
+			}},
+			wantErr: `invalid maintenance window 1: unknown day "mon-fry"`,
+		},
+		{
+			name: "malformed start",
+			schedule: config.Schedule{MaintenanceWindows: []config.MaintenanceWindow{
+				{Start: "10pm", End: "06:00"},
+			}},
+			wantErr: `invalid maintenance window 1: invalid start "10pm"`,
+		},
+		{
+			name: "malformed end",
+			schedule: config.Schedule{MaintenanceWindows: []config.MaintenanceWindow{
+				{Start: "22:00", End: "25:00"},
+			}},
+			wantErr: `invalid maintenance window 1: invalid end "25:00"`,
+		},
+		{
+			name: "empty window",
+			schedule: config.Schedule{MaintenanceWindows: []config.MaintenanceWindow{
+				{Start: "22:00", End: "06:00"},
+				{Start: "12:00", End: "12:00"},
+			}},
+			wantErr: "invalid maintenance window 2: the window must not start and end at the same time",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := schedule.New(tt.schedule)
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
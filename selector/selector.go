@@ -0,0 +1,241 @@
+package selector
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// operator is the way a requirement compares the value of its label.
+type operator string
+
+const (
+	opExists    operator = "exists"
+	opNotExists operator = "!"
+	opEquals    operator = "="
+	opNotEquals operator = "!="
+	opIn        operator = "in"
+	opNotIn     operator = "notin"
+)
+
+// setRequirement matches the set-based requirements, such as "env in (dev, qa)".
+var setRequirement = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+
+// Selector selects resources by their labels, following the syntax of the
+// Kubernetes label selectors. A selector is a comma separated list of
+// requirements, all of them having to be met:
+//
+//   - "env" and "!env" require the label to be present or absent.
+//   - "env=prod", or "env==prod", requires the label to have the value.
+//   - "env!=prod" requires the label to be absent or to have another value.
+//   - "env in (dev, qa)" requires the label to have one of the values.
+//   - "env notin (dev, qa)" requires the label to be absent or to have none
+//     of the values.
+type Selector struct {
+	text         string
+	requirements []requirement
+}
+
+// requirement is a condition on a single label.
+type requirement struct {
+	key    string
+	op     operator
+	values []string
+}
+
+// Parse returns the selector described by the given text, or an error naming
+// the first malformed requirement.
+func Parse(text string) (Selector, error) {
+	parts, err := split(text)
+	if err != nil {
+		return Selector{}, fmt.Errorf("invalid label selector %q: %w", text, err)
+	}
+
+	if len(parts) == 0 {
+		return Selector{}, fmt.Errorf("invalid label selector %q: empty selector", text)
+	}
+
+	s := Selector{text: strings.TrimSpace(text)}
+	for _, part := range parts {
+		r, err := parseRequirement(part)
+		if err != nil {
+			return Selector{}, fmt.Errorf("invalid label selector %q: %w", text, err)
+		}
+
+		s.requirements = append(s.requirements, r)
+	}
+
+	return s, nil
+}
+
+// MustParse is like Parse but panics when the text is malformed. It is meant
+// for the selectors known at compile time.
+func MustParse(text string) Selector {
+	s, err := Parse(text)
+	if err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+// Matches reports whether the given labels meet every requirement of the
+// selector.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s.requirements {
+		if !r.matches(labels) {
+			return false
+		}
+	}
+
+	return len(s.requirements) > 0
+}
+
+// String returns the text the selector was parsed from.
+func (s Selector) String() string {
+	return s.text
+}
+
+// MatchesAny reports whether the given labels are matched by one of the given
+// selectors.
+func MatchesAny(labels map[string]string, selectors []Selector) bool {
+	return slices.ContainsFunc(selectors, func(s Selector) bool {
+		return s.Matches(labels)
+	})
+}
+
+// DecodeHook returns a mapstructure decode hook parsing the strings decoded
+// into a Selector. A single string decoded into a list of selectors, as read
+// from an environment variable, is split on the commas outside parentheses,
+// each part being a selector of its own.
+func DecodeHook() mapstructure.DecodeHookFuncType {
+	return func(from, to reflect.Type, data any) (any, error) {
+		if from.Kind() != reflect.String {
+			return data, nil
+		}
+
+		switch to {
+		case reflect.TypeOf(Selector{}):
+			return Parse(data.(string))
+		case reflect.TypeOf([]Selector{}):
+			parts, err := split(data.(string))
+			if err != nil {
+				return nil, fmt.Errorf("invalid label selectors %q: %w", data, err)
+			}
+
+			return parts, nil
+		}
+
+		return data, nil
+	}
+}
+
+// parseRequirement returns the requirement described by the given text.
+func parseRequirement(text string) (requirement, error) {
+	if text == "" {
+		return requirement{}, errors.New("empty requirement")
+	}
+
+	var r requirement
+
+	switch {
+	case strings.HasPrefix(text, "!"):
+		r = requirement{key: strings.TrimSpace(text[1:]), op: opNotExists}
+	case strings.Contains(text, "!="):
+		key, value, _ := strings.Cut(text, "!=")
+		r = requirement{key: strings.TrimSpace(key), op: opNotEquals, values: []string{strings.TrimSpace(value)}}
+	case strings.Contains(text, "="):
+		key, value, _ := strings.Cut(text, "=")
+		value = strings.TrimPrefix(value, "=")
+		r = requirement{key: strings.TrimSpace(key), op: opEquals, values: []string{strings.TrimSpace(value)}}
+	case strings.Contains(text, "("):
+		m := setRequirement.FindStringSubmatch(text)
+		if m == nil {
+			return requirement{}, fmt.Errorf("malformed requirement %q", text)
+		}
+
+		r = requirement{key: m[1], op: operator(m[2])}
+		for _, value := range strings.Split(m[3], ",") {
+			if value = strings.TrimSpace(value); value == "" {
+				return requirement{}, fmt.Errorf("empty value in requirement %q", text)
+			}
+
+			r.values = append(r.values, value)
+		}
+	default:
+		r = requirement{key: text, op: opExists}
+	}
+
+	if r.key == "" || strings.ContainsAny(r.key, " \t=!(),") {
+		return requirement{}, fmt.Errorf("invalid label key in requirement %q", text)
+	}
+
+	if len(r.values) == 1 && strings.ContainsAny(r.values[0], "=!()") {
+		return requirement{}, fmt.Errorf("invalid label value in requirement %q", text)
+	}
+
+	return r, nil
+}
+
+// matches reports whether the given labels meet the requirement.
+func (r requirement) matches(labels map[string]string) bool {
+	value, ok := labels[r.key]
+
+	switch r.op {
+	case opExists:
+		return ok
+	case opNotExists:
+		return !ok
+	case opEquals:
+		return ok && value == r.values[0]
+	case opNotEquals:
+		return !ok || value != r.values[0]
+	case opIn:
+		return ok && slices.Contains(r.values, value)
+	case opNotIn:
+		return !ok || !slices.Contains(r.values, value)
+	default:
+		return false
+	}
+}
+
+// split returns the trimmed parts of the given text separated by the commas
+// outside parentheses. It returns no part when the text is blank.
+func split(text string) ([]string, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+
+	var (
+		parts []string
+		depth int
+		start int
+	)
+
+	for i, c := range text {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth < 0 {
+				return nil, errors.New("unbalanced parentheses")
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(text[start:i]))
+				start = i + 1
+			}
+		}
+	}
+
+	if depth != 0 {
+		return nil, errors.New("unbalanced parentheses")
+	}
+
+	return append(parts, strings.TrimSpace(text[start:])), nil
+}
@@ -0,0 +1,117 @@
+package selector_test
+
+import (
+	"testing"
+
+	"github.com/lucasmendesl/beerus/selector"
+	"github.com/mitchellh/mapstructure"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelector_Matches(t *testing.T) {
+	labels := map[string]string{
+		"env":  "prod",
+		"tier": "db",
+		"team": "",
+	}
+
+	tests := []struct {
+		name     string
+		selector string
+		expected bool
+	}{
+		{name: "existence", selector: "env", expected: true},
+		{name: "existence of a missing label", selector: "owner", expected: false},
+		{name: "non existence", selector: "!owner", expected: true},
+		{name: "non existence of a present label", selector: "!env", expected: false},
+		{name: "equality", selector: "env=prod", expected: true},
+		{name: "double equality", selector: "env == prod", expected: true},
+		{name: "equality with another value", selector: "env=dev", expected: false},
+		{name: "equality with an empty value", selector: "team=", expected: true},
+		{name: "inequality", selector: "env!=dev", expected: true},
+		{name: "inequality with the same value", selector: "env!=prod", expected: false},
+		{name: "inequality of a missing label", selector: "owner!=ci", expected: true},
+		{name: "in", selector: "env in (dev, prod)", expected: true},
+		{name: "in without the value", selector: "env in (dev,qa)", expected: false},
+		{name: "in of a missing label", selector: "owner in (ci)", expected: false},
+		{name: "notin", selector: "env notin (dev, qa)", expected: true},
+		{name: "notin with the value", selector: "env notin (prod)", expected: false},
+		{name: "notin of a missing label", selector: "owner notin (ci)", expected: true},
+		{name: "every requirement met", selector: "env=prod, tier in (db, cache), !owner", expected: true},
+		{name: "one requirement not met", selector: "env=prod,tier=web", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := selector.Parse(tt.selector)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, s.Matches(labels))
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		wantErr  string
+	}{
+		{name: "empty selector", selector: " ", wantErr: "empty selector"},
+		{name: "empty requirement", selector: "env=prod,", wantErr: "empty requirement"},
+		{name: "unbalanced parentheses", selector: "env in (dev", wantErr: "unbalanced parentheses"},
+		{name: "empty set value", selector: "env in (dev,)", wantErr: `empty value in requirement "env in (dev,)"`},
+		{name: "unknown set operator", selector: "env within (dev)", wantErr: `malformed requirement "env within (dev)"`},
+		{name: "missing key", selector: "=prod", wantErr: `invalid label key in requirement "=prod"`},
+		{name: "key with spaces", selector: "my env", wantErr: `invalid label key in requirement "my env"`},
+		{name: "malformed value", selector: "env=prod=1", wantErr: `invalid label value in requirement "env=prod=1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := selector.Parse(tt.selector)
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestDecodeHook(t *testing.T) {
+	var target struct {
+		Single selector.Selector   `mapstructure:"single"`
+		List   []selector.Selector `mapstructure:"list"`
+		Env    []selector.Selector `mapstructure:"env"`
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: selector.DecodeHook(),
+		Result:     &target,
+	})
+	require.NoError(t, err)
+
+	err = decoder.Decode(map[string]any{
+		"single": "env=prod",
+		"list":   []any{"env in (dev, qa),tier=web", "keep"},
+		"env":    "env in (dev, qa),keep",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "env=prod", target.Single.String())
+	require.Equal(t, []string{"env in (dev, qa),tier=web", "keep"}, texts(target.List))
+
+	// the commas outside parentheses separate the selectors of a single string
+	require.Equal(t, []string{"env in (dev, qa)", "keep"}, texts(target.Env))
+
+	require.True(t, selector.MatchesAny(map[string]string{"keep": "true"}, target.List))
+	require.False(t, selector.MatchesAny(map[string]string{"env": "qa"}, target.List))
+
+	err = decoder.Decode(map[string]any{"list": []any{"env in (dev"}})
+	require.ErrorContains(t, err, "unbalanced parentheses")
+}
+
+func texts(selectors []selector.Selector) []string {
+	texts := make([]string, 0, len(selectors))
+	for _, s := range selectors {
+		texts = append(texts, s.String())
+	}
+
+	return texts
+}
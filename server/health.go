@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// readyTimeout bounds the time spent checking the readiness, since it
+// reaches the Docker daemon.
+const readyTimeout = 3 * time.Second
+
+const (
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+)
+
+// healthResponse is the body returned by the health endpoints.
+type healthResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// healthzHandler returns the handler of the liveness endpoint, answering
+// with 200 while the probe is alive and 503 otherwise.
+func healthzHandler(probe Probe) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeHealth(w, probe.Live())
+	})
+}
+
+// readyzHandler returns the handler of the readiness endpoint, answering
+// with 200 when the probe is ready and 503 otherwise.
+func readyzHandler(probe Probe) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+
+		writeHealth(w, probe.Ready(ctx))
+	})
+}
+
+// writeHealth writes the health response matching the given error.
+func writeHealth(w http.ResponseWriter, err error) {
+	response := healthResponse{Status: statusOK}
+	statusCode := http.StatusOK
+
+	if err != nil {
+		response = healthResponse{Status: statusUnavailable, Error: err.Error()}
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(response)
+}
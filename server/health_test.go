@@ -0,0 +1,98 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lucasmendesl/beerus/metrics"
+	"github.com/lucasmendesl/beerus/server"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProbe struct {
+	liveErr  error
+	readyErr error
+}
+
+func (p fakeProbe) Live() error {
+	return p.liveErr
+}
+
+func (p fakeProbe) Ready(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return errors.New("readiness checked without deadline")
+	}
+
+	return p.readyErr
+}
+
+func TestServer_Health(t *testing.T) {
+	testCases := []struct {
+		name               string
+		path               string
+		probe              fakeProbe
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:               "should report alive when the probe is live",
+			path:               "/healthz",
+			probe:              fakeProbe{},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"status":"ok"}`,
+		},
+		{
+			name:               "should report unavailable when the event stream is disconnected",
+			path:               "/healthz",
+			probe:              fakeProbe{liveErr: errors.New("docker event stream disconnected")},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedBody:       `{"status":"unavailable","error":"docker event stream disconnected"}`,
+		},
+		{
+			name:               "should report ready when the probe is ready",
+			path:               "/readyz",
+			probe:              fakeProbe{},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"status":"ok"}`,
+		},
+		{
+			name:               "should report unavailable when the daemon is unreachable",
+			path:               "/readyz",
+			probe:              fakeProbe{readyErr: errors.New("docker daemon unreachable: connection refused")},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedBody:       `{"status":"unavailable","error":"docker daemon unreachable: connection refused"}`,
+		},
+		{
+			name:               "should not depend on the readiness for liveness",
+			path:               "/healthz",
+			probe:              fakeProbe{readyErr: errors.New("initial sweep not finished")},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"status":"ok"}`,
+		},
+	}
+
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(server.New(":0", metrics.New(), tc.probe, logger).Handler())
+			defer srv.Close()
+
+			res, err := http.Get(srv.URL + tc.path)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectedStatusCode, res.StatusCode)
+			require.Equal(t, "application/json", res.Header.Get("Content-Type"))
+			require.JSONEq(t, tc.expectedBody, string(body))
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/lucasmendesl/beerus/metrics"
+)
+
+const (
+	readHeaderTimeout = 5 * time.Second
+	shutdownTimeout   = 10 * time.Second
+)
+
+// Probe reports the state of the application to the health endpoints.
+// Live returns an error when the process should be restarted, and Ready
+// returns an error when the process is not able to do its work yet.
+type Probe interface {
+	Live() error
+	Ready(ctx context.Context) error
+}
+
+// Server is the optional HTTP listener exposing the operational endpoints
+// of the application, such as the Prometheus metrics and the health checks.
+type Server struct {
+	srv *http.Server
+	log *slog.Logger
+}
+
+// New returns a new Server listening on the given address, exposing the
+// given metrics on the /metrics endpoint and the liveness and readiness of
+// the given probe on the /healthz and /readyz endpoints.
+func New(address string, m *metrics.Metrics, probe Probe, log *slog.Logger) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", m.Handler())
+	mux.Handle("GET /healthz", healthzHandler(probe))
+	mux.Handle("GET /readyz", readyzHandler(probe))
+
+	return &Server{
+		srv: &http.Server{
+			Addr:              address,
+			Handler:           mux,
+			ReadHeaderTimeout: readHeaderTimeout,
+		},
+		log: log,
+	}
+}
+
+// Handler returns the handler serving the endpoints of the server.
+func (s *Server) Handler() http.Handler {
+	return s.srv.Handler
+}
+
+// Run starts listening for HTTP requests and blocks until the context is
+// canceled, gracefully shutting the server down afterwards. It returns an
+// error if the server could not listen on its address.
+func (s *Server) Run(ctx context.Context) error {
+	errCh := make(chan error, 1)
+
+	go func() {
+		s.log.Info("Starting HTTP server", "address", s.srv.Addr)
+		if err := s.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- fmt.Errorf("error starting http server: %w", err)
+		}
+		close(errCh)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.log.Info("Shutting down HTTP server")
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+
+	return s.srv.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,222 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// stateFile is the name of the file, under the data directory, where the
+	// state is persisted.
+	stateFile = "state.json"
+
+	// currentVersion is the version of the state file format, bumped when the
+	// format changes in a way older releases cannot read.
+	currentVersion = 1
+
+	// maxDeletions bounds the deletion history, dropping the oldest entries.
+	maxDeletions = 1000
+)
+
+// document is the persisted representation of the state.
+type document struct {
+	Version        int                  `json:"version"`
+	EventCursor    time.Time            `json:"eventCursor"`
+	ImageLastUsed  map[string]time.Time `json:"imageLastUsed"`
+	ImageFirstSeen map[string]time.Time `json:"imageFirstSeen"`
+	Deletions      []Deletion           `json:"deletions"`
+}
+
+// fileStore is a Store kept in memory and persisted as a JSON document in a
+// file, which is replaced atomically on every flush.
+type fileStore struct {
+	path string
+
+	mu    sync.Mutex
+	doc   document
+	dirty bool
+}
+
+// NewMemory returns a Store that is only kept in memory, losing its content
+// when the process exits.
+func NewMemory() Store {
+	return &fileStore{doc: emptyDocument()}
+}
+
+// Open returns a Store persisted in a file under the given data directory,
+// loading its previous content when the file exists. When the data directory
+// is empty, the returned Store is only kept in memory.
+func Open(dataDir string) (Store, error) {
+	if dataDir == "" {
+		return NewMemory(), nil
+	}
+
+	s := &fileStore{
+		path: filepath.Join(dataDir, stateFile),
+		doc:  emptyDocument(),
+	}
+
+	data, err := os.ReadFile(s.path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return s, nil
+	case err != nil:
+		return nil, fmt.Errorf("reading state file error: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.doc); err != nil {
+		return nil, fmt.Errorf("decoding state file error: %w", err)
+	}
+
+	if s.doc.Version > currentVersion {
+		return nil, fmt.Errorf("state file version %d is newer than the supported version %d", s.doc.Version, currentVersion)
+	}
+
+	if s.doc.ImageLastUsed == nil {
+		s.doc.ImageLastUsed = make(map[string]time.Time)
+	}
+
+	if s.doc.ImageFirstSeen == nil {
+		s.doc.ImageFirstSeen = make(map[string]time.Time)
+	}
+
+	s.doc.Version = currentVersion
+	return s, nil
+}
+
+func emptyDocument() document {
+	return document{
+		Version:        currentVersion,
+		ImageLastUsed:  make(map[string]time.Time),
+		ImageFirstSeen: make(map[string]time.Time),
+	}
+}
+
+func (s *fileStore) ImageLastUsed() map[string]time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return maps.Clone(s.doc.ImageLastUsed)
+}
+
+func (s *fileStore) TouchImage(imageID string, usedAt time.Time) {
+	if imageID == "" || usedAt.IsZero() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if usedAt.After(s.doc.ImageLastUsed[imageID]) {
+		s.doc.ImageLastUsed[imageID] = usedAt
+		s.dirty = true
+	}
+}
+
+func (s *fileStore) ImageFirstSeen() map[string]time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return maps.Clone(s.doc.ImageFirstSeen)
+}
+
+func (s *fileStore) SeeImages(imageIDs []string, seenAt time.Time) {
+	existing := make(map[string]struct{}, len(imageIDs))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range imageIDs {
+		existing[id] = struct{}{}
+
+		if _, ok := s.doc.ImageFirstSeen[id]; !ok {
+			s.doc.ImageFirstSeen[id] = seenAt
+			s.dirty = true
+		}
+	}
+
+	for _, times := range []map[string]time.Time{s.doc.ImageLastUsed, s.doc.ImageFirstSeen} {
+		for id := range times {
+			if _, ok := existing[id]; !ok {
+				delete(times, id)
+				s.dirty = true
+			}
+		}
+	}
+}
+
+func (s *fileStore) RecordDeletion(d Deletion) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.doc.Deletions = append(s.doc.Deletions, d)
+	if exceeding := len(s.doc.Deletions) - maxDeletions; exceeding > 0 {
+		s.doc.Deletions = slices.Delete(s.doc.Deletions, 0, exceeding)
+	}
+
+	delete(s.doc.ImageLastUsed, d.ID)
+	delete(s.doc.ImageFirstSeen, d.ID)
+	s.dirty = true
+}
+
+func (s *fileStore) EventCursor() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.doc.EventCursor
+}
+
+func (s *fileStore) SetEventCursor(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t.After(s.doc.EventCursor) {
+		s.doc.EventCursor = t
+		s.dirty = true
+	}
+}
+
+// Flush writes the state to a temporary file and renames it over the state
+// file, so a crash never leaves it half written. It does nothing for stores
+// kept in memory or when nothing changed since the last flush.
+func (s *fileStore) Flush() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(s.doc)
+	if err != nil {
+		return fmt.Errorf("encoding state error: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("creating data directory error: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing state file error: %w", err)
+	}
+
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("replacing state file error: %w", err)
+	}
+
+	s.dirty = false
+	return nil
+}
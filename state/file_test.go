@@ -0,0 +1,161 @@
+package state_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lucasmendesl/beerus/state"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+	usedAt := time.Date(2025, time.January, 8, 10, 30, 0, 0, time.UTC)
+	cursor := time.Date(2025, time.January, 8, 11, 0, 0, 123, time.UTC)
+
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, dataDir string)
+		check   func(t *testing.T, dataDir string, s state.Store)
+		wantErr string
+	}{
+		{
+			name:  "empty data directory",
+			setup: func(*testing.T, string) {},
+			check: func(t *testing.T, dataDir string, s state.Store) {
+				require.Empty(t, s.ImageLastUsed())
+				require.Empty(t, s.ImageFirstSeen())
+				require.NoFileExists(t, filepath.Join(dataDir, "state.json"))
+				require.True(t, s.EventCursor().IsZero())
+			},
+		},
+		{
+			name: "state persisted by a previous run",
+			setup: func(t *testing.T, dataDir string) {
+				s, err := state.Open(dataDir)
+				require.NoError(t, err)
+
+				s.TouchImage("sha256:6512bd43d9ca", usedAt)
+				s.SeeImages([]string{"sha256:6512bd43d9ca"}, usedAt)
+				s.SetEventCursor(cursor)
+				s.RecordDeletion(state.Deletion{Kind: "image", ID: "sha256:c20ad4d76fe9", Rule: "expired", Size: 1024, DeletedAt: usedAt})
+				require.NoError(t, s.Flush())
+			},
+			check: func(t *testing.T, dataDir string, s state.Store) {
+				require.Equal(t, map[string]time.Time{"sha256:6512bd43d9ca": usedAt}, s.ImageLastUsed())
+				require.Equal(t, map[string]time.Time{"sha256:6512bd43d9ca": usedAt}, s.ImageFirstSeen())
+				require.True(t, cursor.Equal(s.EventCursor()))
+				require.Equal(t, []state.Deletion{
+					{Kind: "image", ID: "sha256:c20ad4d76fe9", Rule: "expired", Size: 1024, DeletedAt: usedAt},
+				}, persistedDeletions(t, dataDir))
+			},
+		},
+		{
+			name: "state file written by a newer release",
+			setup: func(t *testing.T, dataDir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dataDir, "state.json"), []byte(`{"version":99}`), 0o644))
+			},
+			wantErr: "state file version 99 is newer than the supported version 1",
+		},
+		{
+			name: "corrupted state file",
+			setup: func(t *testing.T, dataDir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dataDir, "state.json"), []byte(`{`), 0o644))
+			},
+			wantErr: "decoding state file error: unexpected end of JSON input",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			tt.setup(t, dataDir)
+
+			s, err := state.Open(dataDir)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			tt.check(t, dataDir, s)
+		})
+	}
+}
+
+func TestStore_RecordDeletion(t *testing.T) {
+	dataDir := t.TempDir()
+	s, err := state.Open(dataDir)
+	require.NoError(t, err)
+
+	usedAt := time.Now().Add(-time.Hour)
+
+	s.TouchImage("sha256:6512bd43d9ca", usedAt)
+	s.SeeImages([]string{"sha256:6512bd43d9ca"}, usedAt)
+
+	for i := range 1005 {
+		s.RecordDeletion(state.Deletion{Kind: "container", ID: fmt.Sprintf("container-%d", i)})
+	}
+	s.RecordDeletion(state.Deletion{Kind: "image", ID: "sha256:6512bd43d9ca"})
+
+	require.NoError(t, s.Flush())
+
+	deletions := persistedDeletions(t, dataDir)
+	require.Len(t, deletions, 1000)
+	require.Equal(t, "container-6", deletions[0].ID)
+	require.Equal(t, "sha256:6512bd43d9ca", deletions[len(deletions)-1].ID)
+
+	// the deleted image is forgotten
+	require.Empty(t, s.ImageLastUsed())
+	require.Empty(t, s.ImageFirstSeen())
+}
+
+func TestStore_TouchImage(t *testing.T) {
+	s := state.NewMemory()
+	recent := time.Now()
+
+	s.TouchImage("sha256:6512bd43d9ca", recent)
+	s.TouchImage("sha256:6512bd43d9ca", recent.Add(-time.Hour))
+	s.TouchImage("", recent)
+
+	require.Equal(t, map[string]time.Time{"sha256:6512bd43d9ca": recent}, s.ImageLastUsed())
+}
+
+func TestStore_SeeImages(t *testing.T) {
+	s := state.NewMemory()
+	seenAt := time.Now().Add(-time.Hour)
+
+	s.TouchImage("sha256:6512bd43d9ca", seenAt)
+	s.TouchImage("sha256:c20ad4d76fe9", seenAt)
+	s.SeeImages([]string{"sha256:6512bd43d9ca", "sha256:c20ad4d76fe9"}, seenAt)
+
+	// the first image keeps the time it was first seen, while the second
+	// one was removed outside of the cleaner
+	now := time.Now()
+	s.SeeImages([]string{"sha256:6512bd43d9ca", "sha256:c51ce410c124"}, now)
+
+	require.Equal(t, map[string]time.Time{"sha256:6512bd43d9ca": seenAt}, s.ImageLastUsed())
+	require.Equal(t, map[string]time.Time{
+		"sha256:6512bd43d9ca": seenAt,
+		"sha256:c51ce410c124": now,
+	}, s.ImageFirstSeen())
+}
+
+// persistedDeletions returns the deletion history persisted in the state file
+// under the given data directory.
+func persistedDeletions(t *testing.T, dataDir string) []state.Deletion {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dataDir, "state.json"))
+	require.NoError(t, err)
+
+	var doc struct {
+		Deletions []state.Deletion `json:"deletions"`
+	}
+	require.NoError(t, json.Unmarshal(data, &doc))
+
+	return doc.Deletions
+}
@@ -0,0 +1,54 @@
+// Package state keeps the bookkeeping of the cleaner across restarts, such as
+// the resources it deleted, when it first saw each image, the last time each
+// image was used and the position in the Docker event stream.
+package state
+
+import "time"
+
+// Store persists the bookkeeping of the cleaner. Changes are kept in memory
+// until Flush is called. Implementations are safe for concurrent use.
+type Store interface {
+	// ImageLastUsed returns a copy of the last time each image was used by a
+	// container, keyed by image ID.
+	ImageLastUsed() map[string]time.Time
+
+	// TouchImage records that the given image was used at the given time,
+	// unless a later use is already known.
+	TouchImage(imageID string, usedAt time.Time)
+
+	// ImageFirstSeen returns a copy of the first time each image was seen,
+	// keyed by image ID.
+	ImageFirstSeen() map[string]time.Time
+
+	// SeeImages records that the given images, which are every existing
+	// image, were seen at the given time, unless they were seen before. The
+	// images missing from them no longer exist, so everything known about
+	// them is forgotten.
+	SeeImages(imageIDs []string, seenAt time.Time)
+
+	// RecordDeletion appends the given deletion to the history, forgetting
+	// everything else known about the deleted resource. The history is only
+	// persisted, for the operators to look into.
+	RecordDeletion(d Deletion)
+
+	// EventCursor returns the time of the last processed Docker event, or
+	// the zero time when no event was processed yet.
+	EventCursor() time.Time
+
+	// SetEventCursor records the time of the last processed Docker event.
+	SetEventCursor(t time.Time)
+
+	// Flush persists the changes made since the last flush.
+	Flush() error
+}
+
+// Deletion describes a resource deleted by the cleaner. Its size is in bytes,
+// or -1 when unknown.
+type Deletion struct {
+	Kind      string    `json:"kind"`
+	ID        string    `json:"id"`
+	Names     []string  `json:"names,omitempty"`
+	Rule      string    `json:"rule"`
+	Size      int64     `json:"size"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
@@ -0,0 +1,171 @@
+// Package supervisor runs the independent cleaners of several Docker
+// endpoints within a single process.
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/lucasmendesl/beerus/cleaner"
+)
+
+// Cleaner is the cleaner managing the resources of a single Docker endpoint.
+type Cleaner interface {
+	Run(ctx context.Context) error
+	RunOnce(ctx context.Context) (cleaner.Summary, error)
+	Live() error
+	Ready(ctx context.Context) error
+}
+
+// Endpoint is a Docker endpoint along with the cleaner managing it.
+type Endpoint struct {
+	Name    string
+	Cleaner Cleaner
+}
+
+// Supervisor runs the cleaners of several Docker endpoints. The cleaners are
+// independent: a cleaner stopped by an error, such as an unreachable daemon,
+// does not stop the other ones.
+type Supervisor struct {
+	endpoints []Endpoint
+	log       *slog.Logger
+
+	mu      sync.Mutex
+	stopped map[string]error
+}
+
+// New returns a new Supervisor running the cleaners of the given endpoints.
+func New(log *slog.Logger, endpoints ...Endpoint) *Supervisor {
+	return &Supervisor{
+		endpoints: endpoints,
+		log:       log,
+		stopped:   make(map[string]error),
+	}
+}
+
+// Run runs the cleaner of every endpoint and blocks until all of them are
+// stopped, either because the context is canceled or because they failed.
+// It returns the errors of the failed cleaners, along with the name of their
+// endpoint, or the context's error when every cleaner was canceled.
+func (s *Supervisor) Run(ctx context.Context) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	for _, e := range s.endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := e.Cleaner.Run(ctx)
+			if err == nil || errors.Is(err, context.Canceled) {
+				return
+			}
+
+			s.log.Error("Cleaner stopped", "endpoint", e.Name, "error", err)
+			s.setStopped(e.Name, err)
+
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("endpoint %s: %w", e.Name, err))
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	if len(errs) == 0 {
+		return ctx.Err()
+	}
+
+	return errors.Join(errs...)
+}
+
+// RunOnce runs a single cleanup pass of every endpoint at the same time and
+// returns the sum of their summaries, along with the errors of the failed
+// passes and the name of their endpoint.
+func (s *Supervisor) RunOnce(ctx context.Context) (cleaner.Summary, error) {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		summary cleaner.Summary
+		errs    []error
+	)
+
+	for _, e := range s.endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			endpointSummary, err := e.Cleaner.RunOnce(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			summary = summary.Add(endpointSummary)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("endpoint %s: %w", e.Name, err))
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return summary, errors.Join(errs...)
+}
+
+// Live reports whether every cleaner is alive. A cleaner stopped by an error
+// is not, so the process can be restarted to bring it back.
+func (s *Supervisor) Live() error {
+	var errs []error
+
+	for _, e := range s.endpoints {
+		err := s.stoppedErr(e.Name)
+		if err != nil {
+			err = fmt.Errorf("cleaner stopped: %w", err)
+		} else {
+			err = e.Cleaner.Live()
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("endpoint %s: %w", e.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Ready reports whether every cleaner is ready.
+func (s *Supervisor) Ready(ctx context.Context) error {
+	var errs []error
+
+	for _, e := range s.endpoints {
+		if err := e.Cleaner.Ready(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("endpoint %s: %w", e.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// setStopped records that the cleaner of the given endpoint was stopped by
+// the given error.
+func (s *Supervisor) setStopped(name string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped[name] = err
+}
+
+// stoppedErr returns the error that stopped the cleaner of the given
+// endpoint, or nil when it is running.
+func (s *Supervisor) stoppedErr(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stopped[name]
+}
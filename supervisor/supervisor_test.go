@@ -0,0 +1,180 @@
+package supervisor_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/lucasmendesl/beerus/cleaner"
+	"github.com/lucasmendesl/beerus/supervisor"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCleaner is a cleaner returning the configured results, blocking in Run
+// until the context is canceled unless it is configured to fail.
+type fakeCleaner struct {
+	runErr   error
+	summary  cleaner.Summary
+	onceErr  error
+	liveErr  error
+	readyErr error
+}
+
+func (f *fakeCleaner) Run(ctx context.Context) error {
+	if f.runErr != nil {
+		return f.runErr
+	}
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (f *fakeCleaner) RunOnce(context.Context) (cleaner.Summary, error) {
+	return f.summary, f.onceErr
+}
+
+func (f *fakeCleaner) Live() error {
+	return f.liveErr
+}
+
+func (f *fakeCleaner) Ready(context.Context) error {
+	return f.readyErr
+}
+
+func TestSupervisor_Run(t *testing.T) {
+	var (
+		logger    = slog.New(slog.NewJSONHandler(io.Discard, nil))
+		daemonErr = errors.New("docker daemon unreachable")
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sv := supervisor.New(logger,
+		supervisor.Endpoint{Name: "build-01", Cleaner: &fakeCleaner{runErr: daemonErr}},
+		supervisor.Endpoint{Name: "build-02", Cleaner: &fakeCleaner{}},
+	)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- sv.Run(ctx)
+	}()
+
+	// the failed cleaner does not stop the other one, which keeps running
+	// until the context is canceled, but the process is no longer alive
+	require.Eventually(t, func() bool {
+		return sv.Live() != nil
+	}, time.Second, time.Millisecond)
+
+	select {
+	case err := <-errCh:
+		t.Fatalf("supervisor stopped before the context was canceled: %v", err)
+	default:
+	}
+
+	require.ErrorIs(t, sv.Live(), daemonErr)
+	require.ErrorContains(t, sv.Live(), "endpoint build-01")
+
+	cancel()
+
+	err := <-errCh
+	require.ErrorIs(t, err, daemonErr)
+	require.ErrorContains(t, err, "endpoint build-01")
+}
+
+func TestSupervisor_RunCanceled(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	sv := supervisor.New(logger,
+		supervisor.Endpoint{Name: "build-01", Cleaner: &fakeCleaner{}},
+		supervisor.Endpoint{Name: "build-02", Cleaner: &fakeCleaner{}},
+	)
+
+	require.ErrorIs(t, sv.Run(ctx), context.Canceled)
+	require.NoError(t, sv.Live())
+}
+
+func TestSupervisor_RunOnce(t *testing.T) {
+	var (
+		logger  = slog.New(slog.NewJSONHandler(io.Discard, nil))
+		passErr = errors.New("error listing containers")
+	)
+
+	sv := supervisor.New(logger,
+		supervisor.Endpoint{Name: "build-01", Cleaner: &fakeCleaner{
+			summary: cleaner.Summary{ContainersRemoved: 2, ImagesFailed: 1, ReclaimedBytes: 1024},
+		}},
+		supervisor.Endpoint{Name: "build-02", Cleaner: &fakeCleaner{
+			summary: cleaner.Summary{ImagesRemoved: 3, ReclaimedBytes: 2048},
+			onceErr: passErr,
+		}},
+	)
+
+	summary, err := sv.RunOnce(context.Background())
+	require.ErrorIs(t, err, passErr)
+	require.ErrorContains(t, err, "endpoint build-02")
+	require.NotContains(t, err.Error(), "build-01")
+
+	require.Equal(t, cleaner.Summary{
+		ContainersRemoved: 2,
+		ImagesRemoved:     3,
+		ImagesFailed:      1,
+		ReclaimedBytes:    3072,
+	}, summary)
+}
+
+func TestSupervisor_Probes(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints []supervisor.Endpoint
+		wantLive  string
+		wantReady string
+	}{
+		{
+			name: "every endpoint healthy",
+			endpoints: []supervisor.Endpoint{
+				{Name: "build-01", Cleaner: &fakeCleaner{}},
+				{Name: "build-02", Cleaner: &fakeCleaner{}},
+			},
+		},
+		{
+			name: "endpoint not ready",
+			endpoints: []supervisor.Endpoint{
+				{Name: "build-01", Cleaner: &fakeCleaner{}},
+				{Name: "build-02", Cleaner: &fakeCleaner{readyErr: errors.New("initial sweep not finished")}},
+			},
+			wantReady: "endpoint build-02: initial sweep not finished",
+		},
+		{
+			name: "endpoint not alive",
+			endpoints: []supervisor.Endpoint{
+				{Name: "build-01", Cleaner: &fakeCleaner{liveErr: errors.New("docker event stream disconnected")}},
+				{Name: "build-02", Cleaner: &fakeCleaner{}},
+			},
+			wantLive: "endpoint build-01: docker event stream disconnected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := supervisor.New(slog.New(slog.NewJSONHandler(io.Discard, nil)), tt.endpoints...)
+
+			if tt.wantLive != "" {
+				require.EqualError(t, sv.Live(), tt.wantLive)
+			} else {
+				require.NoError(t, sv.Live())
+			}
+
+			if tt.wantReady != "" {
+				require.EqualError(t, sv.Ready(context.Background()), tt.wantReady)
+			} else {
+				require.NoError(t, sv.Ready(context.Background()))
+			}
+		})
+	}
+}